@@ -1,23 +1,34 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"task_API/internal/config"
+	"task_API/internal/events"
 	"task_API/internal/handlers"
+	"task_API/internal/health"
+	"task_API/internal/metrics"
+	"task_API/internal/openapi"
+	"task_API/internal/ratelimit"
+	"task_API/internal/realtime"
+	"task_API/internal/server"
 	"task_API/internal/services"
 	"task_API/internal/storage"
-	"task_API/internal/storage/repositories"
+	"task_API/internal/storage/postgres"
+	"task_API/internal/tracing"
+	"task_API/pkg/clock"
 	"task_API/pkg/logger"
 	"task_API/pkg/middleware"
-
-	"github.com/gorilla/mux"
+	"task_API/pkg/utils"
+	"time"
 )
 
 func main() {
-	// choose backend:
-	// store := storage.NewMemoryStorage()
-
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
@@ -25,55 +36,161 @@ func main() {
 
 	appLogger := logger.NewLogger(cfg.Logging.Level, cfg.Logging.Format)
 
-	// Initialize database storage
-	dbStorage, err := storage.NewPostgresStorage(config.GetDBConnectionString(&cfg.Database))
+	// Tracing, TRACING_EXPORTER selects where the spans go
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("Failed to set up tracing")
+	}
+
+	// Initialize storage, DATABASE_DRIVER selects the backend
+	store, err := storage.Open(&cfg.Database, appLogger)
 	if err != nil {
-		appLogger.Fatal().Err(err).Msg("Failed to connect to database")
-		// log.Fatalf("Failed to connect to database: %v", err)
+		appLogger.Fatal().Err(err).Msg("Failed to open storage")
 	}
-	defer dbStorage.Close()
 
-	dbStorage.CreateTables()
+	if cfg.Database.AutoMigrate {
+		if err := store.Migrate(context.Background()); err != nil {
+			store.Close()
+			appLogger.Fatal().Err(err).Msg("Failed to migrate database")
+		}
+	}
 
 	// Repositories
-	userRepo := repositories.NewUserRepository(dbStorage)
-	taskRepo := repositories.NewTaskRepository(dbStorage)
+	userRepo := store.Users()
+	taskRepo := store.Tasks()
+	taskEventRepo := store.TaskEvents()
+	auditLogRepo := store.AuditLogs()
+	webhookRepo := store.Webhooks()
+	reminderRepo := store.Reminders()
+	notificationRepo := store.Notifications()
+
+	// Task events are fanned out to every subscriber of the bus
+	eventBus := events.NewBus()
+
+	// services, every call is traced
+	authService := services.TracedAuthService(services.NewAuthService(userRepo, store.TxManager(), cfg.JWT.Secret, cfg.JWT.Expiration))
+	taskService := services.TracedTaskService(services.NewTaskService(taskRepo, taskEventRepo, reminderRepo, webhookRepo, store.TxManager(), eventBus))
+	auditService := services.TracedAuditService(services.NewAuditService(auditLogRepo, appLogger))
+	webhookNetworks, err := utils.ParseNetworks(cfg.Webhook.AllowedNetworks)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("Invalid WEBHOOK_ALLOWED_NETWORKS")
+	}
+	webhookService := services.TracedWebhookService(services.NewWebhookService(webhookRepo, webhookNetworks))
+	notificationService := services.TracedNotificationService(services.NewNotificationService(notificationRepo))
+	eventBus.Subscribe(metrics.ObserveTaskEvent)
 
-	// services
-	authService := services.NewAuthService(userRepo, cfg.JWT.Secret, cfg.JWT.Expiration)
-	taskService := services.NewTaskService(taskRepo)
+	// Connection pool statistics of the SQL backends
+	if pooled, ok := store.(interface{ Pool() *sql.DB }); ok {
+		metrics.RegisterDBStats(cfg.Database.Driver, pooled.Pool())
+	}
+
+	// Realtime hub, fanned out across instances through Postgres LISTEN/NOTIFY
+	var broker realtime.Broker
+	if cfg.Realtime.Fanout == "postgres" {
+		postgresStorage, ok := store.(*postgres.Storage)
+		if !ok {
+			appLogger.Fatal().Str("driver", cfg.Database.Driver).Msg("REALTIME_FANOUT=postgres requires the postgres database driver")
+		}
+		broker, err = realtime.NewPostgresBroker(postgresStorage.DB, config.GetDBConnectionString(&cfg.Database), appLogger)
+		if err != nil {
+			appLogger.Fatal().Err(err).Msg("Failed to create realtime broker")
+		}
+	}
+	hub := realtime.NewHub(cfg.Realtime.BufferSize, broker, appLogger)
+	eventBus.Subscribe(hub.Publish)
+
+	// Health checks, the worker check is added with the server
+	healthRegistry := health.NewRegistry(cfg.Health.CheckTimeout)
+	healthRegistry.Ready("database", store.Ping)
+	healthRegistry.Ready("migrations", health.SchemaVersion(store.SchemaVersion))
+	if cfg.Health.DiskPath != "" {
+		healthRegistry.Ready("disk", health.DiskSpace(cfg.Health.DiskPath, uint64(cfg.Health.DiskMinFreeMB)<<20))
+	}
+
+	// Rate limits, kept in Postgres when the instances share them
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == "postgres" {
+		postgresStorage, ok := store.(*postgres.Storage)
+		if !ok {
+			appLogger.Fatal().Str("driver", cfg.Database.Driver).Msg("RATE_LIMIT_STORE=postgres requires the postgres database driver")
+		}
+		rateLimitStore = ratelimit.NewPostgresStore(postgresStorage.DB)
+	}
+	rateLimits := map[string]ratelimit.Limit{}
+	if cfg.RateLimit.Enabled {
+		rateLimits[ratelimit.ClassAuth] = ratelimit.Limit{Requests: cfg.RateLimit.AuthRequests, Period: cfg.RateLimit.AuthPeriod}
+		rateLimits[ratelimit.ClassRead] = ratelimit.Limit{Requests: cfg.RateLimit.ReadRequests, Period: cfg.RateLimit.ReadPeriod}
+		rateLimits[ratelimit.ClassWrite] = ratelimit.Limit{Requests: cfg.RateLimit.WriteRequests, Period: cfg.RateLimit.WritePeriod}
+	}
+	limiter := ratelimit.NewLimiter(rateLimitStore, rateLimits)
+
+	// Background workers, started with the server
+	webhookDispatcher := services.NewWebhookDispatcher(webhookRepo, cfg.Webhook, webhookNetworks, appLogger)
+	reminderScheduler := services.NewReminderScheduler(reminderRepo, cfg.Reminder, clock.Real{}, appLogger)
 
 	// Handlers
-	taskHandler := handlers.NewTaskHandler(taskService)
-	authHandler := handlers.NewAuthHandler(authService)
-
-	router := mux.NewRouter()
-	router.Use(middleware.LoggingMiddleware)
-
-	//  // Global middleware
-	// router.Use(middleware.Logging(appLogger))
-	// router.Use(middleware.Recovery(appLogger))
-
-	// Auth routes
-	router.HandleFunc("/register", authHandler.Register).Methods("POST")
-	router.HandleFunc("/login", authHandler.Login).Methods("POST")
-
-	// Health check endpoint
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(`{"status": "healthy", "service": "task-api", "database": "connected"}\n`))
-	}).Methods("GET")
-
-	// Protected Task routes
-	protectedRouter := router.PathPrefix("").Subrouter()
-	protectedRouter.Use(middleware.AuthMiddleware(dbStorage))
-	protectedRouter.HandleFunc("/tasks", taskHandler.GetAllTasks).Methods("GET")
-	protectedRouter.HandleFunc("/tasks/{id}", taskHandler.GetTask).Methods("GET")
-	protectedRouter.HandleFunc("/tasks", taskHandler.CreateTask).Methods("POST")
-	protectedRouter.HandleFunc("/tasks/{id}", taskHandler.UpdateTask).Methods("PUT")
-	protectedRouter.HandleFunc("/tasks/{id}", taskHandler.DeleteTask).Methods("DELETE")
-
-	// Start server
-	log.Println("Server running at :8080")
-	log.Fatal(http.ListenAndServe(":8080", router))
+	taskHandler := handlers.NewTaskHandler(taskService, auditService)
+	authHandler := handlers.NewAuthHandler(authService, auditService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	realtimeHandler := handlers.NewRealtimeHandler(hub, taskService, cfg.Realtime)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	healthHandler := handlers.NewHealthHandler(healthRegistry)
+
+	// The OpenAPI document and its docs UI
+	spec, err := json.Marshal(openapi.Build())
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("Failed to build the OpenAPI document")
+	}
+	docsPage, docsPolicy := openapi.DocsPage()
+	docsHandler := handlers.NewDocsHandler(spec, docsPage, docsPolicy)
+
+	router := newRouter(&apiRoutes{
+		cfg:                 cfg,
+		limiter:             limiter,
+		authenticate:        middleware.AuthMiddleware(userRepo, auditService, limiter, appLogger),
+		requireAdmin:        middleware.RequireAdmin(auditService),
+		taskHandler:         taskHandler,
+		authHandler:         authHandler,
+		auditHandler:        auditHandler,
+		webhookHandler:      webhookHandler,
+		realtimeHandler:     realtimeHandler,
+		notificationHandler: notificationHandler,
+		healthHandler:       healthHandler,
+		docsHandler:         docsHandler,
+	}, appLogger)
+
+	// Browser and proxy handling wraps the router, preflight requests match no route
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.Security.TrustedProxies)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("Invalid TRUSTED_PROXIES")
+	}
+	handler := middleware.TrustedProxies(trustedProxies)(
+		middleware.SecurityHeaders(cfg.Security)(
+			middleware.CORS(cfg.CORS)(router)))
+
+	// Start server. On SIGINT or SIGTERM it drains the requests in flight, stops the
+	// workers in the order they were added and closes the storage last.
+	apiServer := server.New(cfg.Server, handler, appLogger)
+	apiServer.AddCloser("storage", store.Close)
+	apiServer.AddCloser("tracing", func() error {
+		// Flushes the spans of the last requests, it is the first closer to run
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdownTracing(ctx)
+	})
+	apiServer.OnShutdown(hub.Close)
+	apiServer.Go("realtime hub", hub.Run)
+	apiServer.Go("webhook dispatcher", webhookDispatcher.Run)
+	apiServer.Go("reminder scheduler", reminderScheduler.Run)
+	apiServer.BeforeShutdown(healthRegistry.SetDraining)
+	healthRegistry.Live("workers", apiServer.CheckWorkers)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := apiServer.Run(ctx); err != nil {
+		appLogger.Fatal().Err(err).Msg("Server stopped with errors")
+	}
+	appLogger.Info().Msg("Server stopped")
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"task_API/internal/config"
+	"task_API/internal/dto"
+	"task_API/internal/handlers"
+	"task_API/internal/metrics"
+	"task_API/internal/ratelimit"
+	"task_API/pkg/logger"
+	"task_API/pkg/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+// apiRoutes are the routes of the API proper. They are mounted once per API version,
+// and once more without a prefix for the clients of the unversioned API.
+type apiRoutes struct {
+	cfg          *config.Config
+	limiter      *ratelimit.Limiter
+	authenticate mux.MiddlewareFunc
+	requireAdmin mux.MiddlewareFunc
+
+	taskHandler         *handlers.TaskHandler
+	authHandler         *handlers.AuthHandler
+	auditHandler        *handlers.AuditHandler
+	webhookHandler      *handlers.WebhookHandler
+	realtimeHandler     *handlers.RealtimeHandler
+	notificationHandler *handlers.NotificationHandler
+	healthHandler       *handlers.HealthHandler
+	docsHandler         *handlers.DocsHandler
+}
+
+// newRouter returns the router of every route the API serves. The OpenAPI document
+// describes each of them, a test walks the router to check it.
+func newRouter(routes *apiRoutes, appLogger *logger.Logger) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(middleware.RequestId)
+	router.Use(middleware.Tracing)
+	router.Use(middleware.Metrics)
+	router.Use(middleware.Logging(appLogger))
+	router.Use(middleware.Recovery(appLogger))
+	router.Use(middleware.ClientCertificates(routes.cfg.Server.TLSClientAccounts))
+
+	// Health endpoints, /health is kept for the probes that still use it
+	router.HandleFunc("/livez", routes.healthHandler.Livez).Methods("GET")
+	router.HandleFunc("/readyz", routes.healthHandler.Readyz).Methods("GET")
+	router.HandleFunc("/health", routes.healthHandler.Readyz).Methods("GET")
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// API documentation
+	router.HandleFunc("/openapi.json", routes.docsHandler.Spec).Methods("GET")
+	router.HandleFunc("/docs", routes.docsHandler.Docs).Methods("GET")
+
+	// The API routes under each version prefix, v2 returns the corrected models
+	for _, version := range dto.Versions {
+		versionRouter := router.PathPrefix("/" + version).Subrouter()
+		versionRouter.Use(middleware.APIVersion(version))
+		routes.register(versionRouter, appLogger)
+	}
+
+	// The unversioned routes answer like v1 until their sunset
+	legacyRouter := router.PathPrefix("").Subrouter()
+	legacyRouter.Use(middleware.Deprecated(routes.cfg.API, dto.V1))
+	legacyRouter.Use(middleware.APIVersion(dto.V1))
+	routes.register(legacyRouter, appLogger)
+
+	return router
+}
+
+// register adds the routes to router
+func (routes *apiRoutes) register(router *mux.Router, appLogger *logger.Logger) {
+	// Every request but the event streams gets a deadline and a bounded body
+	apiRouter := router.PathPrefix("").Subrouter()
+	apiRouter.Use(middleware.Timeout(routes.cfg.Server.RequestTimeout))
+	apiRouter.Use(middleware.MaxBodySize(routes.cfg.Server.MaxBodyBytes))
+
+	// Auth routes, limited per client IP
+	authRouter := apiRouter.PathPrefix("").Subrouter()
+	authRouter.Use(middleware.RateLimit(routes.limiter, middleware.FixedClass(ratelimit.ClassAuth), appLogger))
+	authRouter.HandleFunc("/register", routes.authHandler.Register).Methods("POST")
+	authRouter.HandleFunc("/login", routes.authHandler.Login).Methods("POST")
+
+	// Protected Task routes
+	protectedRouter := apiRouter.PathPrefix("").Subrouter()
+	protectedRouter.Use(routes.authenticate)
+	protectedRouter.Use(middleware.RateLimit(routes.limiter, middleware.ClassByMethod, appLogger))
+	protectedRouter.HandleFunc("/profile", routes.authHandler.GetProfile).Methods("GET")
+	protectedRouter.HandleFunc("/profile/password", routes.authHandler.ChangePassword).Methods("PUT")
+	protectedRouter.HandleFunc("/tasks", routes.taskHandler.GetAllTasks).Methods("GET")
+	protectedRouter.HandleFunc("/tasks/{id}", routes.taskHandler.GetTask).Methods("GET")
+	protectedRouter.HandleFunc("/tasks", routes.taskHandler.CreateTask).Methods("POST")
+	protectedRouter.HandleFunc("/tasks/{id}", routes.taskHandler.UpdateTask).Methods("PUT")
+	protectedRouter.HandleFunc("/tasks/{id}", routes.taskHandler.DeleteTask).Methods("DELETE")
+	protectedRouter.HandleFunc("/tasks/{id}/restore", routes.taskHandler.RestoreTask).Methods("POST")
+	protectedRouter.HandleFunc("/tasks/{id}/history", routes.taskHandler.GetTaskHistory).Methods("GET")
+	protectedRouter.HandleFunc("/tasks/{id}/snapshot", routes.taskHandler.GetTaskSnapshot).Methods("GET")
+
+	// Realtime routes stay open as long as the client listens, so they have no deadline
+	streamRouter := router.PathPrefix("").Subrouter()
+	streamRouter.Use(routes.authenticate)
+	streamRouter.Use(middleware.RateLimit(routes.limiter, middleware.ClassByMethod, appLogger))
+	streamRouter.HandleFunc("/events", routes.realtimeHandler.StreamEvents).Methods("GET")
+	streamRouter.HandleFunc("/ws", routes.realtimeHandler.WebSocket).Methods("GET")
+
+	// Notification routes
+	protectedRouter.HandleFunc("/notifications", routes.notificationHandler.GetNotifications).Methods("GET")
+	protectedRouter.HandleFunc("/notifications/read-all", routes.notificationHandler.MarkAllRead).Methods("POST")
+	protectedRouter.HandleFunc("/notifications/{id}/read", routes.notificationHandler.MarkRead).Methods("POST")
+
+	// Webhook routes
+	protectedRouter.HandleFunc("/webhooks", routes.webhookHandler.GetWebhooks).Methods("GET")
+	protectedRouter.HandleFunc("/webhooks", routes.webhookHandler.CreateWebhook).Methods("POST")
+	protectedRouter.HandleFunc("/webhooks/{id}", routes.webhookHandler.DeleteWebhook).Methods("DELETE")
+	protectedRouter.HandleFunc("/webhooks/{id}/deliveries", routes.webhookHandler.GetWebhookDeliveries).Methods("GET")
+	protectedRouter.HandleFunc("/webhooks/{id}/deliveries/{deliveryId}/retry", routes.webhookHandler.RetryWebhookDelivery).Methods("POST")
+
+	// Admin routes
+	adminRouter := protectedRouter.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(routes.requireAdmin)
+	adminRouter.HandleFunc("/audit-logs", routes.auditHandler.GetAuditLogs).Methods("GET")
+	adminRouter.HandleFunc("/audit-logs/verify", routes.auditHandler.VerifyAuditChain).Methods("GET")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"task_API/internal/config"
+	"task_API/internal/openapi"
+	"task_API/pkg/logger"
+
+	"github.com/gorilla/mux"
+)
+
+// TestOpenAPIDescribesEveryRoute fails when a route is registered without being in the
+// OpenAPI document, or the document lists an operation no route serves
+func TestOpenAPIDescribesEveryRoute(t *testing.T) {
+	passThrough := func(next http.Handler) http.Handler { return next }
+	router := newRouter(&apiRoutes{
+		cfg:          &config.Config{},
+		authenticate: passThrough,
+		requireAdmin: passThrough,
+	}, logger.NewLogger("error", "json"))
+	document := openapi.Build()
+
+	served := map[string]bool{}
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Subrouters match a prefix, their routes are walked on their own
+			return nil
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			served[method+" "+path] = true
+			if !document.HasOperation(method, path) {
+				t.Errorf("%s %s is not in the OpenAPI document", method, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, item := range document.Paths {
+		for method := range item {
+			if !served[strings.ToUpper(method)+" "+path] {
+				t.Errorf("the OpenAPI document lists %s %s, which no route serves", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"task_API/internal/config"
+	"task_API/internal/storage"
+	"task_API/internal/storage/migrate"
+	"task_API/pkg/logger"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up          Apply all pending migrations
+  down [n]    Revert the last n applied migrations (default 1)
+  status      List migrations and whether they are applied
+  redo        Revert and re-apply the latest applied migration`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	appLogger := logger.NewLogger(cfg.Logging.Level, cfg.Logging.Format)
+
+	store, err := storage.Open(&cfg.Database, appLogger)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("Failed to connect to database")
+	}
+	defer store.Close()
+
+	migratable, ok := store.(interface {
+		Migrator() *migrate.Migrator
+	})
+	if !ok {
+		appLogger.Fatal().Str("driver", cfg.Database.Driver).Msg("The driver has no migrations")
+	}
+	migrator := migratable.Migrator()
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			appLogger.Fatal().Err(err).Msg("Migration failed")
+		}
+		if len(applied) == 0 {
+			appLogger.Info().Msg("Database is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil || steps < 1 {
+				appLogger.Fatal().Str("steps", os.Args[2]).Msg("Invalid number of steps")
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			appLogger.Fatal().Err(err).Msg("Migration failed")
+		}
+		if len(reverted) == 0 {
+			appLogger.Info().Msg("No migration to revert")
+		}
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			appLogger.Fatal().Err(err).Msg("Failed to read migration status")
+		}
+		printStatus(statuses)
+
+	case "redo":
+		if _, err := migrator.Redo(ctx); err != nil {
+			appLogger.Fatal().Err(err).Msg("Migration failed")
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func printStatus(statuses []migrate.Status) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state := "pending"
+		switch {
+		case status.Missing:
+			state = "applied, file missing"
+		case status.Modified:
+			state = "applied, modified"
+		case status.Applied:
+			state = "applied"
+		}
+
+		appliedAt := ""
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	writer.Flush()
+}
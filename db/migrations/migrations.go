@@ -0,0 +1,31 @@
+// Package migrations embeds the SQL schema migrations of every database dialect.
+//
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql and are applied
+// in version order by internal/storage/migrate.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Postgres returns the migrations of the Postgres schema
+func Postgres() fs.FS {
+	return dialect("postgres")
+}
+
+// SQLite returns the migrations of the SQLite schema
+func SQLite() fs.FS {
+	return dialect("sqlite")
+}
+
+func dialect(name string) fs.FS {
+	migrations, err := fs.Sub(files, name)
+	if err != nil {
+		panic(err)
+	}
+	return migrations
+}
@@ -0,0 +1,4 @@
+-- Demo account, password "password"
+INSERT INTO users (email, password_hash, name)
+VALUES ('faheemahmed@golang.com', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'Faheem Ahmed')
+ON CONFLICT (email) DO NOTHING;
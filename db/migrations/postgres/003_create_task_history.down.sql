@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS task_events;
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
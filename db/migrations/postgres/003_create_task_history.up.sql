@@ -0,0 +1,16 @@
+-- Soft deletion, so deleted tasks can be restored
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Append-only task history
+CREATE TABLE IF NOT EXISTS task_events (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_event_task_id ON task_events(task_id, id);
+CREATE INDEX IF NOT EXISTS idx_task_event_user_id ON task_events(user_id, id);
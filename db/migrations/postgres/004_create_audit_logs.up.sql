@@ -0,0 +1,22 @@
+-- User roles, used to restrict admin endpoints
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
+
+-- Security audit log, entries are chained by hash. user_id has no foreign key so the
+-- trail survives user deletion.
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER,
+    event VARCHAR(50) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_user_id ON audit_logs(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_event ON audit_logs(event);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_logs(created_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS task_reminders;
+ALTER TABLE tasks DROP COLUMN IF EXISTS reminder_offsets;
+ALTER TABLE tasks DROP COLUMN IF EXISTS due_date;
@@ -0,0 +1,26 @@
+-- Due dates, reminders and in-app notifications
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_date TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS reminder_offsets JSONB NOT NULL DEFAULT '[]';
+
+CREATE TABLE IF NOT EXISTS task_reminders (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    offset_minutes INTEGER NOT NULL,
+    fire_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL,
+    type VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_reminder_due ON task_reminders(fire_at) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_task_reminder_task_id ON task_reminders(task_id);
+CREATE INDEX IF NOT EXISTS idx_notification_user_id ON notifications(user_id, id);
@@ -0,0 +1,7 @@
+-- Rate limit buckets shared by the instances, see internal/ratelimit
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key VARCHAR(255) PRIMARY KEY,
+    tat TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_tat ON rate_limits(tat);
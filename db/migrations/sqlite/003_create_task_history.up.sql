@@ -0,0 +1,16 @@
+-- Soft deletion, so deleted tasks can be restored
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMP;
+
+-- Append-only task history, changes hold a JSON object
+CREATE TABLE task_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    changes TEXT NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_event_task_id ON task_events(task_id, id);
+CREATE INDEX idx_task_event_user_id ON task_events(user_id, id);
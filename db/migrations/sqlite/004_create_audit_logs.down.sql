@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS audit_logs;
+ALTER TABLE users DROP COLUMN role;
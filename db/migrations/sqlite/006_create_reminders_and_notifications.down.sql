@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS task_reminders;
+ALTER TABLE tasks DROP COLUMN reminder_offsets;
+ALTER TABLE tasks DROP COLUMN due_date;
@@ -0,0 +1,26 @@
+-- Due dates, reminders and in-app notifications, reminder_offsets holds a JSON array
+ALTER TABLE tasks ADD COLUMN due_date TIMESTAMP;
+ALTER TABLE tasks ADD COLUMN reminder_offsets TEXT NOT NULL DEFAULT '[]';
+
+CREATE TABLE task_reminders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    offset_minutes INTEGER NOT NULL,
+    fire_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP
+);
+
+CREATE TABLE notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL,
+    type VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_reminder_due ON task_reminders(fire_at) WHERE sent_at IS NULL;
+CREATE INDEX idx_task_reminder_task_id ON task_reminders(task_id);
+CREATE INDEX idx_notification_user_id ON notifications(user_id, id);
@@ -4,14 +4,24 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Logging  LoggingConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	Logging   LoggingConfig
+	Webhook   WebhookConfig
+	Realtime  RealtimeConfig
+	Reminder  ReminderConfig
+	Health    HealthConfig
+	Tracing   TracingConfig
+	RateLimit RateLimitConfig
+	CORS      CORSConfig
+	Security  SecurityConfig
+	API       APIConfig
 }
 
 type ServerConfig struct {
@@ -20,17 +30,47 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+	// RequestTimeout is the deadline of a request, streams excluded
+	RequestTimeout time.Duration
+	// MaxBodyBytes is the largest request body accepted
+	MaxBodyBytes int64
+	// ShutdownTimeout bounds the drain of connections and the stop of the workers
+	ShutdownTimeout time.Duration
+	// ShutdownDelay is the time between readiness turning false and the drain
+	ShutdownDelay time.Duration
+	// TLSCertFile and TLSKeyFile make the server serve HTTPS, and HTTP/2 with it
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile is the CA bundle client certificates are verified against, empty
+	// for no client certificates
+	TLSClientCAFile string
+	// TLSClientAuth is request, to verify a certificate when the client sends one, or
+	// require
+	TLSClientAuth string
+	// TLSClientAccounts maps the common name of client certificates to the email of the
+	// service account user they sign in as
+	TLSClientAccounts map[string]string
+	// TLSReloadInterval is how often the certificate files are checked for changes
+	TLSReloadInterval time.Duration
 }
 
 type DatabaseConfig struct {
+	Driver          string
 	DBHost          string
 	DBPort          int
 	DBUser          string
 	DBPassword      string
 	DBName          string
+	SQLitePath      string
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	AutoMigrate     bool
+	// QueryTimeout bounds every repository call, zero disables it
+	QueryTimeout time.Duration
+	// TxMaxRetries is how often a transaction is retried after a deadlock, or after
+	// SQLITE_BUSY on SQLite
+	TxMaxRetries int
 }
 
 type JWTConfig struct {
@@ -43,24 +83,130 @@ type LoggingConfig struct {
 	Format string
 }
 
+type WebhookConfig struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	RequestTimeout time.Duration
+	PollInterval   time.Duration
+	BatchSize      int
+	// AllowedNetworks are internal addresses or CIDR ranges webhooks may still be sent to
+	AllowedNetworks []string
+}
+
+type RealtimeConfig struct {
+	Fanout            string
+	BufferSize        int
+	ReplayLimit       int
+	HeartbeatInterval time.Duration
+	WriteTimeout      time.Duration
+}
+
+type ReminderConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+// HealthConfig configures the checks of /livez and /readyz. An empty DiskPath skips
+// the disk space check.
+type HealthConfig struct {
+	CheckTimeout  time.Duration
+	DiskPath      string
+	DiskMinFreeMB int
+}
+
+// TracingConfig configures OpenTelemetry. Exporter is otlp, stdout or none, and an
+// empty Endpoint leaves the OTLP endpoint to the standard OTEL_EXPORTER_* variables.
+type TracingConfig struct {
+	Exporter    string
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the share of new traces recorded, traces started by the caller
+	// follow its sampling decision
+	SampleRatio float64
+}
+
+// RateLimitConfig configures the limits of the route classes, Requests per Period
+// each. Store is memory or postgres, which shares the limits between instances. A
+// class with zero requests is not limited.
+type RateLimitConfig struct {
+	Enabled       bool
+	Store         string
+	AuthRequests  int
+	AuthPeriod    time.Duration
+	ReadRequests  int
+	ReadPeriod    time.Duration
+	WriteRequests int
+	WritePeriod   time.Duration
+}
+
+// CORSConfig configures the browser access of other origins. No AllowedOrigins
+// disables CORS, and an origin of * allows every origin but not with credentials.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight answer
+	MaxAge time.Duration
+}
+
+// SecurityConfig configures the security headers and the proxies whose forwarded
+// headers are trusted
+type SecurityConfig struct {
+	// HSTSMaxAge is the max-age of Strict-Transport-Security on HTTPS, zero omits it
+	HSTSMaxAge time.Duration
+	// ContentSecurityPolicy is sent with HTML responses
+	ContentSecurityPolicy string
+	// TrustedProxies are the addresses or CIDR ranges of the proxies in front of the API
+	TrustedProxies []string
+}
+
+// APIConfig configures the API versions. The unversioned routes answer like v1 and
+// announce their deprecation.
+type APIConfig struct {
+	// LegacyDeprecatedAt is when the unversioned routes were deprecated
+	LegacyDeprecatedAt time.Time
+	// LegacySunset is when the unversioned routes go away, zero omits the Sunset header
+	LegacySunset time.Time
+}
+
 func Load() (*Config, error) {
-	return &Config{
+	driver := getEnv("DATABASE_DRIVER", "postgres")
+
+	cfg := &Config{
 		Server: ServerConfig{
-			Port:         getEnv("SERVER_PORT", "8080"),
-			Environment:  getEnv("ENVIRONMENT", "development"),
-			ReadTimeout:  getEnvAsDuration("SERVER_READ_TIMEOUT", 15*time.Second),
-			WriteTimeout: getEnvAsDuration("SERVER_WRITE_TIMEOUT", 15*time.Second),
-			IdleTimeout:  getEnvAsDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			Port:              getEnv("SERVER_PORT", "8080"),
+			Environment:       getEnv("ENVIRONMENT", "development"),
+			ReadTimeout:       getEnvAsDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+			WriteTimeout:      getEnvAsDuration("SERVER_WRITE_TIMEOUT", 15*time.Second),
+			IdleTimeout:       getEnvAsDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			RequestTimeout:    getEnvAsDuration("SERVER_REQUEST_TIMEOUT", 30*time.Second),
+			MaxBodyBytes:      int64(getEnvAsInt("SERVER_MAX_BODY_BYTES", 1<<20)),
+			ShutdownTimeout:   getEnvAsDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+			ShutdownDelay:     getEnvAsDuration("SERVER_SHUTDOWN_DELAY", 0),
+			TLSCertFile:       getEnv("SERVER_TLS_CERT_FILE", ""),
+			TLSKeyFile:        getEnv("SERVER_TLS_KEY_FILE", ""),
+			TLSClientCAFile:   getEnv("SERVER_TLS_CLIENT_CA_FILE", ""),
+			TLSClientAuth:     getEnv("SERVER_TLS_CLIENT_AUTH", "require"),
+			TLSClientAccounts: getEnvAsMap("SERVER_TLS_CLIENT_ACCOUNTS"),
+			TLSReloadInterval: getEnvAsDuration("SERVER_TLS_RELOAD_INTERVAL", 30*time.Second),
 		},
 		Database: DatabaseConfig{
+			Driver:          driver,
 			DBHost:          getEnv("DB_HOST", "localhost"),
 			DBPort:          getEnvAsInt("DB_PORT", 5432),
 			DBUser:          getEnv("DB_USER", "task_user"),
 			DBPassword:      getEnv("DB_PASSWORD", "task_password"),
 			DBName:          getEnv("DB_NAME", "task_database"),
+			SQLitePath:      getEnv("SQLITE_PATH", "task_api.db"),
 			MaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
 			MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 25),
 			ConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+			AutoMigrate:     getEnvAsBool("DB_AUTO_MIGRATE", true),
+			QueryTimeout:    getEnvAsDuration("DB_QUERY_TIMEOUT", 5*time.Second),
+			TxMaxRetries:    getEnvAsInt("DB_TX_MAX_RETRIES", 3),
 		},
 		JWT: JWTConfig{
 			Secret:     getEnv("JWT_SECRET", "default_production_secret"),
@@ -70,7 +216,93 @@ func Load() (*Config, error) {
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "text"),
 		},
-	}, nil
+		Webhook: WebhookConfig{
+			MaxAttempts:     getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			InitialBackoff:  getEnvAsDuration("WEBHOOK_INITIAL_BACKOFF", 30*time.Second),
+			MaxBackoff:      getEnvAsDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+			RequestTimeout:  getEnvAsDuration("WEBHOOK_REQUEST_TIMEOUT", 10*time.Second),
+			PollInterval:    getEnvAsDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			BatchSize:       getEnvAsInt("WEBHOOK_BATCH_SIZE", 20),
+			AllowedNetworks: getEnvAsSlice("WEBHOOK_ALLOWED_NETWORKS", nil),
+		},
+		Realtime: RealtimeConfig{
+			Fanout:            getEnv("REALTIME_FANOUT", defaultFanout(driver)),
+			BufferSize:        getEnvAsInt("REALTIME_BUFFER_SIZE", 64),
+			ReplayLimit:       getEnvAsInt("REALTIME_REPLAY_LIMIT", 500),
+			HeartbeatInterval: getEnvAsDuration("REALTIME_HEARTBEAT_INTERVAL", 25*time.Second),
+			WriteTimeout:      getEnvAsDuration("REALTIME_WRITE_TIMEOUT", 10*time.Second),
+		},
+		Reminder: ReminderConfig{
+			PollInterval: getEnvAsDuration("REMINDER_POLL_INTERVAL", 30*time.Second),
+			BatchSize:    getEnvAsInt("REMINDER_BATCH_SIZE", 100),
+		},
+		Health: HealthConfig{
+			CheckTimeout:  getEnvAsDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+			DiskPath:      getEnv("HEALTH_DISK_PATH", ""),
+			DiskMinFreeMB: getEnvAsInt("HEALTH_DISK_MIN_FREE_MB", 100),
+		},
+		Tracing: TracingConfig{
+			Exporter:    getEnv("TRACING_EXPORTER", "none"),
+			Endpoint:    getEnv("TRACING_ENDPOINT", ""),
+			ServiceName: getEnv("TRACING_SERVICE_NAME", "task-api"),
+			SampleRatio: getEnvAsFloat("TRACING_SAMPLE_RATIO", 1),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:       getEnvAsBool("RATE_LIMIT_ENABLED", true),
+			Store:         getEnv("RATE_LIMIT_STORE", "memory"),
+			AuthRequests:  getEnvAsInt("RATE_LIMIT_AUTH_REQUESTS", 10),
+			AuthPeriod:    getEnvAsDuration("RATE_LIMIT_AUTH_PERIOD", time.Minute),
+			ReadRequests:  getEnvAsInt("RATE_LIMIT_READ_REQUESTS", 300),
+			ReadPeriod:    getEnvAsDuration("RATE_LIMIT_READ_PERIOD", time.Minute),
+			WriteRequests: getEnvAsInt("RATE_LIMIT_WRITE_REQUESTS", 60),
+			WritePeriod:   getEnvAsDuration("RATE_LIMIT_WRITE_PERIOD", time.Minute),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   getEnvAsSlice("CORS_ALLOWED_ORIGINS", nil),
+			AllowedMethods:   getEnvAsSlice("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE"}),
+			AllowedHeaders:   getEnvAsSlice("CORS_ALLOWED_HEADERS", []string{"Authorization", "Content-Type", "X-Request-ID"}),
+			ExposedHeaders:   getEnvAsSlice("CORS_EXPOSED_HEADERS", []string{"X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "Deprecation", "Sunset", "Link"}),
+			AllowCredentials: getEnvAsBool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           getEnvAsDuration("CORS_MAX_AGE", 10*time.Minute),
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            getEnvAsDuration("SECURITY_HSTS_MAX_AGE", 365*24*time.Hour),
+			ContentSecurityPolicy: getEnv("SECURITY_CSP", "default-src 'self'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'"),
+			TrustedProxies:        getEnvAsSlice("TRUSTED_PROXIES", nil),
+		},
+		API: APIConfig{
+			LegacyDeprecatedAt: getEnvAsDate("API_LEGACY_DEPRECATED_AT", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)),
+			LegacySunset:       getEnvAsDate("API_LEGACY_SUNSET", time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	if err := cfg.CORS.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate refuses credentials for every origin: any site could then call the API
+// with the cookies and HTTP authentication of its visitors
+func (cfg CORSConfig) validate() error {
+	if !cfg.AllowCredentials {
+		return nil
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			return fmt.Errorf("CORS_ALLOW_CREDENTIALS cannot be combined with the origin * in CORS_ALLOWED_ORIGINS, list the origins instead")
+		}
+	}
+	return nil
+}
+
+// defaultFanout shares realtime events through Postgres when the data lives there,
+// the other drivers have no LISTEN/NOTIFY so events stay in process
+func defaultFanout(driver string) string {
+	if driver == "postgres" {
+		return "postgres"
+	}
+	return "local"
 }
 
 func getEnv(key, defaultValue string) string {
@@ -89,6 +321,52 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsSlice splits a comma separated list, dropping empty entries
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			values = append(values, entry)
+		}
+	}
+	return values
+}
+
+// getEnvAsMap reads a comma separated list of key=value pairs. Keys may contain =,
+// the value is what follows the last one.
+func getEnvAsMap(key string) map[string]string {
+	values := make(map[string]string)
+	for _, entry := range getEnvAsSlice(key, nil) {
+		if index := strings.LastIndex(entry, "="); index > 0 {
+			values[strings.TrimSpace(entry[:index])] = strings.TrimSpace(entry[index+1:])
+		}
+	}
+	return values
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -98,6 +376,20 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getEnvAsDate reads a date such as 2027-04-19, in UTC. "none" is the zero time.
+func getEnvAsDate(key string, defaultValue time.Time) time.Time {
+	value := os.Getenv(key)
+	if value == "none" {
+		return time.Time{}
+	}
+	if value != "" {
+		if date, err := time.Parse(time.DateOnly, value); err == nil {
+			return date
+		}
+	}
+	return defaultValue
+}
+
 func GetDBConnectionString(cfg *DatabaseConfig) string {
 	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable",
 		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
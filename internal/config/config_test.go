@@ -0,0 +1,25 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadRefusesCredentialsForEveryOrigin(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com,*")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "CORS_ALLOW_CREDENTIALS") {
+		t.Fatalf("err = %v, want the wildcard with credentials refused", err)
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("listed origins with credentials: %v", err)
+	}
+	if !cfg.CORS.AllowCredentials {
+		t.Fatal("AllowCredentials = false, want true")
+	}
+}
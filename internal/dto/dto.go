@@ -0,0 +1,334 @@
+// Package dto holds the response bodies of the API. They are built from the models, so
+// a change to a model, or to its JSON tags, does not change what clients receive.
+// Bodies that differ between API versions have a type per version.
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"task_API/internal/events"
+	"task_API/internal/models"
+)
+
+// API versions
+const (
+	V1 = "v1"
+	V2 = "v2"
+)
+
+// Versions lists the versions served, oldest first
+var Versions = []string{V1, V2}
+
+// TaskV1 is a task as v1 and the unversioned routes return it
+type TaskV1 struct {
+	ID              int        `json:"id"`
+	Title           string     `json:"title"`
+	Completed       bool       `json:"completed"`
+	UserId          int        `json:"user_id"`
+	DueDate         *time.Time `json:"due_date,omitempty"`
+	ReminderOffsets []int      `json:"reminder_offsets,omitempty"`
+	CreatedAt       time.Time  `json:"creation_time"`
+	UpdatedAt       time.Time  `json:"updatation_time"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+}
+
+// TaskV2 is a task as v2 returns it, with timestamps named like those of every other
+// resource
+type TaskV2 struct {
+	ID              int        `json:"id"`
+	Title           string     `json:"title"`
+	Completed       bool       `json:"completed"`
+	UserId          int        `json:"user_id"`
+	DueDate         *time.Time `json:"due_date,omitempty"`
+	ReminderOffsets []int      `json:"reminder_offsets,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Task returns the body of task for the API version, v1 for any version before v2
+func Task(version string, task *models.Task) interface{} {
+	if task == nil {
+		return nil
+	}
+	if version == V2 {
+		return TaskV2{
+			ID:              task.ID,
+			Title:           task.Title,
+			Completed:       task.Completed,
+			UserId:          task.UserId,
+			DueDate:         task.DueDate,
+			ReminderOffsets: task.ReminderOffsets,
+			CreatedAt:       task.CreatedAt,
+			UpdatedAt:       task.UpdatedAt,
+			DeletedAt:       task.DeletedAt,
+		}
+	}
+	return TaskV1{
+		ID:              task.ID,
+		Title:           task.Title,
+		Completed:       task.Completed,
+		UserId:          task.UserId,
+		DueDate:         task.DueDate,
+		ReminderOffsets: task.ReminderOffsets,
+		CreatedAt:       task.CreatedAt,
+		UpdatedAt:       task.UpdatedAt,
+		DeletedAt:       task.DeletedAt,
+	}
+}
+
+// Tasks returns the body of a list of tasks for the API version
+func Tasks(version string, tasks []*models.Task) []interface{} {
+	bodies := make([]interface{}, 0, len(tasks))
+	for _, task := range tasks {
+		bodies = append(bodies, Task(version, task))
+	}
+	return bodies
+}
+
+// Event is a task event as the event streams send it
+type Event struct {
+	ID         int         `json:"id"`
+	Type       string      `json:"type"`
+	UserId     int         `json:"user_id"`
+	Task       interface{} `json:"task"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
+
+// NewEvent returns the body of event, with its task in the shape of the API version
+func NewEvent(version string, event events.Event) Event {
+	return Event{
+		ID:         event.ID,
+		Type:       event.Type,
+		UserId:     event.UserId,
+		Task:       Task(version, event.Task),
+		OccurredAt: event.OccurredAt,
+	}
+}
+
+// User is a user without the password hash
+type User struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewUser(user *models.User) User {
+	return User{
+		ID:        user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+// AuthResponse is the body of a successful registration or login
+type AuthResponse struct {
+	User  User   `json:"user"`
+	Token string `json:"token"`
+}
+
+func NewAuthResponse(response *models.AuthResponse) AuthResponse {
+	return AuthResponse{User: NewUser(&response.User), Token: response.Token}
+}
+
+// FieldChange is the value of a task field before and after a change
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// TaskEvent is an entry of the history of a task
+type TaskEvent struct {
+	ID        int                    `json:"id"`
+	TaskId    int                    `json:"task_id"`
+	UserId    int                    `json:"user_id"`
+	ActorId   int                    `json:"actor_id"`
+	Action    string                 `json:"action"`
+	Changes   map[string]FieldChange `json:"changes"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+func NewTaskEvents(history []*models.TaskEvent) []TaskEvent {
+	bodies := make([]TaskEvent, 0, len(history))
+	for _, entry := range history {
+		changes := make(map[string]FieldChange, len(entry.Changes))
+		for field, change := range entry.Changes {
+			changes[field] = FieldChange{Before: change.Before, After: change.After}
+		}
+		bodies = append(bodies, TaskEvent{
+			ID:        entry.ID,
+			TaskId:    entry.TaskId,
+			UserId:    entry.UserId,
+			ActorId:   entry.ActorId,
+			Action:    entry.Action,
+			Changes:   changes,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	return bodies
+}
+
+// WebhookSubscription is a webhook subscription. The secret is only returned when the
+// subscription is created.
+type WebhookSubscription struct {
+	ID         int       `json:"id"`
+	UserId     int       `json:"user_id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewWebhookSubscription(subscription *models.WebhookSubscription) WebhookSubscription {
+	return WebhookSubscription{
+		ID:         subscription.ID,
+		UserId:     subscription.UserId,
+		URL:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		Secret:     subscription.Secret,
+		Active:     subscription.Active,
+		CreatedAt:  subscription.CreatedAt,
+	}
+}
+
+func NewWebhookSubscriptions(subscriptions []*models.WebhookSubscription) []WebhookSubscription {
+	bodies := make([]WebhookSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		bodies = append(bodies, NewWebhookSubscription(subscription))
+	}
+	return bodies
+}
+
+// WebhookDelivery is an attempt to deliver an event to a subscription. The payload is
+// the body sent, whatever the version of the route that lists it.
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	SubscriptionId int             `json:"subscription_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      string          `json:"last_error,omitempty"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+func NewWebhookDelivery(delivery *models.WebhookDelivery) WebhookDelivery {
+	return WebhookDelivery{
+		ID:             delivery.ID,
+		SubscriptionId: delivery.SubscriptionId,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastError:      delivery.LastError,
+		ResponseStatus: delivery.ResponseStatus,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+}
+
+func NewWebhookDeliveries(deliveries []*models.WebhookDelivery) []WebhookDelivery {
+	bodies := make([]WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		bodies = append(bodies, NewWebhookDelivery(delivery))
+	}
+	return bodies
+}
+
+// Notification is a notification of the user
+type Notification struct {
+	ID        int        `json:"id"`
+	UserId    int        `json:"user_id"`
+	TaskId    *int       `json:"task_id,omitempty"`
+	Type      string     `json:"type"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func NewNotifications(notifications []*models.Notification) []Notification {
+	bodies := make([]Notification, 0, len(notifications))
+	for _, notification := range notifications {
+		bodies = append(bodies, Notification{
+			ID:        notification.ID,
+			UserId:    notification.UserId,
+			TaskId:    notification.TaskId,
+			Type:      notification.Type,
+			Message:   notification.Message,
+			ReadAt:    notification.ReadAt,
+			CreatedAt: notification.CreatedAt,
+		})
+	}
+	return bodies
+}
+
+// MarkedNotifications is the number of notifications marked as read
+type MarkedNotifications struct {
+	Marked int `json:"marked"`
+}
+
+// AuditLog is an entry of the audit log
+type AuditLog struct {
+	ID        int       `json:"id"`
+	UserId    *int      `json:"user_id"`
+	Event     string    `json:"event"`
+	Email     string    `json:"email,omitempty"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Details   string    `json:"details,omitempty"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewAuditLogs(entries []*models.AuditLog) []AuditLog {
+	bodies := make([]AuditLog, 0, len(entries))
+	for _, entry := range entries {
+		bodies = append(bodies, AuditLog{
+			ID:        entry.ID,
+			UserId:    entry.UserId,
+			Event:     entry.Event,
+			Email:     entry.Email,
+			IPAddress: entry.IPAddress,
+			UserAgent: entry.UserAgent,
+			Success:   entry.Success,
+			Details:   entry.Details,
+			PrevHash:  entry.PrevHash,
+			Hash:      entry.Hash,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	return bodies
+}
+
+// AuditChainVerification is the result of checking the hash chain of the audit log
+type AuditChainVerification struct {
+	Valid    bool   `json:"valid"`
+	Entries  int    `json:"entries"`
+	BrokenAt *int   `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+func NewAuditChainVerification(verification *models.AuditChainVerification) AuditChainVerification {
+	return AuditChainVerification{
+		Valid:    verification.Valid,
+		Entries:  verification.Entries,
+		BrokenAt: verification.BrokenAt,
+		Reason:   verification.Reason,
+	}
+}
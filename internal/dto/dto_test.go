@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"task_API/internal/events"
+	"task_API/internal/models"
+)
+
+func TestTaskVersions(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	task := &models.Task{ID: 1, Title: "Report", UserId: 2, CreatedAt: now, UpdatedAt: now}
+
+	tests := []struct {
+		version string
+		present []string
+		absent  []string
+	}{
+		{V1, []string{"creation_time", "updatation_time"}, []string{"created_at", "updated_at"}},
+		{"", []string{"creation_time", "updatation_time"}, []string{"created_at", "updated_at"}},
+		{V2, []string{"created_at", "updated_at"}, []string{"creation_time", "updatation_time"}},
+	}
+	for _, test := range tests {
+		data, err := json.Marshal(NewEvent(test.version, events.Event{ID: 3, Type: events.TaskCreated, Task: task}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Task map[string]interface{} `json:"task"`
+		}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range test.present {
+			if _, ok := body.Task[key]; !ok {
+				t.Errorf("version %q: task %v has no %s", test.version, body.Task, key)
+			}
+		}
+		for _, key := range test.absent {
+			if _, ok := body.Task[key]; ok {
+				t.Errorf("version %q: task %v has %s", test.version, body.Task, key)
+			}
+		}
+	}
+}
+
+func TestUserHasNoPasswordHash(t *testing.T) {
+	data, err := json.Marshal(NewUser(&models.User{ID: 1, Email: "ada@example.com", PasswordHash: "hash"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]interface{}
+	json.Unmarshal(data, &body)
+	for key, value := range body {
+		if value == "hash" {
+			t.Fatalf("%s leaks the password hash: %s", key, data)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"task_API/internal/models"
+)
+
+// Task event types
+const (
+	TaskCreated   = "task.created"
+	TaskUpdated   = "task.updated"
+	TaskCompleted = "task.completed"
+	TaskDeleted   = "task.deleted"
+	TaskRestored  = "task.restored"
+)
+
+// TaskEventTypes lists every event type a subscriber can ask for. The validate tag of
+// models.CreateWebhookRequest lists them too.
+var TaskEventTypes = []string{TaskCreated, TaskUpdated, TaskCompleted, TaskDeleted, TaskRestored}
+
+// IsTaskEventType reports whether eventType is a known task event type
+func IsTaskEventType(eventType string) bool {
+	for _, known := range TaskEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event describes a change to a task. ID is the id of the matching task history entry.
+type Event struct {
+	ID         int          `json:"id"`
+	Type       string       `json:"type"`
+	UserId     int          `json:"user_id"`
+	Task       *models.Task `json:"task"`
+	OccurredAt time.Time    `json:"occurred_at"`
+}
+
+// Emitter publishes task events
+type Emitter interface {
+	Emit(event Event)
+}
+
+// Handler receives emitted events
+type Handler func(event Event)
+
+// Bus is an Emitter that hands every event to all subscribed handlers, in order
+type Bus struct {
+	mutex    sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (bus *Bus) Subscribe(handler Handler) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	bus.handlers = append(bus.handlers, handler)
+}
+
+func (bus *Bus) Emit(event Event) {
+	bus.mutex.RLock()
+	defer bus.mutex.RUnlock()
+
+	for _, handler := range bus.handlers {
+		handler(event)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"task_API/internal/dto"
+	"task_API/internal/models"
+	"task_API/internal/services"
+	apperrors "task_API/pkg/errors"
+	"task_API/pkg/responses"
+	"task_API/pkg/validator"
+	"time"
+)
+
+type AuditHandler struct {
+	auditService services.AuditService
+}
+
+func NewAuditHandler(auditService services.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// GetAuditLogs lists audit entries, newest first. Supported filters: user_id, event,
+// success, ip, since, until (RFC 3339), before_id and limit.
+func (h *AuditHandler) GetAuditLogs(writer http.ResponseWriter, request *http.Request) {
+	filter, err := parseAuditLogFilter(request)
+	if err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	entries, err := h.auditService.GetAuditLogs(request.Context(), filter)
+	if err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(dto.NewAuditLogs(entries))
+}
+
+// VerifyAuditChain checks that no audit entry has been altered or removed
+func (h *AuditHandler) VerifyAuditChain(writer http.ResponseWriter, request *http.Request) {
+	result, err := h.auditService.VerifyChain(request.Context())
+	if err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(dto.NewAuditChainVerification(result))
+}
+
+func parseAuditLogFilter(request *http.Request) (*models.AuditLogFilter, error) {
+	query := request.URL.Query()
+	filter := &models.AuditLogFilter{
+		Event:     query.Get("event"),
+		IPAddress: query.Get("ip"),
+	}
+
+	if value := query.Get("user_id"); value != "" {
+		userId, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, invalidQueryParam("user_id")
+		}
+		filter.UserId = &userId
+	}
+	if value := query.Get("success"); value != "" {
+		success, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, invalidQueryParam("success")
+		}
+		filter.Success = &success
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, invalidQueryParam("since")
+		}
+		filter.Since = &since
+	}
+	if value := query.Get("until"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, invalidQueryParam("until")
+		}
+		filter.Until = &until
+	}
+	if value := query.Get("before_id"); value != "" {
+		beforeId, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, invalidQueryParam("before_id")
+		}
+		filter.BeforeId = beforeId
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, invalidQueryParam("limit")
+		}
+		filter.Limit = limit
+	}
+
+	if err := validator.Struct(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func invalidQueryParam(name string) error {
+	return apperrors.ErrBadRequest.Newf("Invalid '%s' query parameter", name)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/pkg/middleware"
+)
+
+// fakeAuditService keeps the filter of the last query and the recorded entries
+type fakeAuditService struct {
+	filter   *models.AuditLogFilter
+	recorded []*models.AuditLog
+}
+
+func (audit *fakeAuditService) Record(ctx context.Context, entry *models.AuditLog) {
+	audit.recorded = append(audit.recorded, entry)
+}
+
+func (audit *fakeAuditService) GetAuditLogs(ctx context.Context, filter *models.AuditLogFilter) ([]*models.AuditLog, error) {
+	audit.filter = filter
+	return []*models.AuditLog{{ID: 7, Event: models.AuditEventLoginFailure}}, nil
+}
+
+func (audit *fakeAuditService) VerifyChain(ctx context.Context) (*models.AuditChainVerification, error) {
+	return &models.AuditChainVerification{Valid: true}, nil
+}
+
+func TestGetAuditLogsReadsTheFilters(t *testing.T) {
+	audit := &fakeAuditService{}
+	handler := NewAuditHandler(audit)
+
+	request := httptest.NewRequest(http.MethodGet, "/admin/audit-logs?user_id=3&event=login_failure&success=false&ip=203.0.113.7&since=2026-06-01T00:00:00Z&until=2026-06-02T00:00:00Z&before_id=50&limit=20", nil)
+	recorder := httptest.NewRecorder()
+	handler.GetAuditLogs(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", recorder.Code, recorder.Body)
+	}
+	filter := audit.filter
+	if filter.UserId == nil || *filter.UserId != 3 || filter.Event != models.AuditEventLoginFailure ||
+		filter.Success == nil || *filter.Success || filter.IPAddress != "203.0.113.7" ||
+		filter.BeforeId != 50 || filter.Limit != 20 {
+		t.Errorf("filter = %+v", filter)
+	}
+	if filter.Since == nil || !filter.Since.Equal(time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)) ||
+		filter.Until == nil || !filter.Until.Equal(time.Date(2026, time.June, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("since = %v, until = %v", filter.Since, filter.Until)
+	}
+
+	var entries []map[string]interface{}
+	if err := json.NewDecoder(recorder.Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0]["id"] != float64(7) {
+		t.Errorf("entries = %v", entries)
+	}
+}
+
+func TestGetAuditLogsRefusesInvalidFilters(t *testing.T) {
+	tests := map[string]string{
+		"user id":        "user_id=abc",
+		"event":          "event=logout",
+		"success":        "success=maybe",
+		"since":          "since=yesterday",
+		"until":          "until=2026-06-01",
+		"until <= since": "since=2026-06-02T00:00:00Z&until=2026-06-01T00:00:00Z",
+		"before id":      "before_id=-1",
+		"limit":          "limit=ten",
+	}
+
+	for name, query := range tests {
+		t.Run(name, func(t *testing.T) {
+			audit := &fakeAuditService{}
+			recorder := httptest.NewRecorder()
+			NewAuditHandler(audit).GetAuditLogs(recorder, httptest.NewRequest(http.MethodGet, "/admin/audit-logs?"+query, nil))
+
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", recorder.Code)
+			}
+			if audit.filter != nil {
+				t.Error("the audit log was queried with an invalid filter")
+			}
+		})
+	}
+}
+
+func TestAuditLogsAreForAdminsOnly(t *testing.T) {
+	audit := &fakeAuditService{}
+	handler := middleware.RequireAdmin(audit)(http.HandlerFunc(NewAuditHandler(audit).GetAuditLogs))
+
+	send := func(user models.User) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/admin/audit-logs", nil)
+		request = request.WithContext(context.WithValue(request.Context(), "user", user))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	denied := send(models.User{ID: 2, Email: "user@example.com", Role: models.RoleUser})
+	if denied.Code != http.StatusForbidden {
+		t.Fatalf("status for a user = %d, want 403", denied.Code)
+	}
+	if audit.filter != nil {
+		t.Error("a user queried the audit log")
+	}
+	if len(audit.recorded) != 1 || audit.recorded[0].Event != models.AuditEventPermissionDenied {
+		t.Errorf("recorded = %+v, want the denied access", audit.recorded)
+	}
+
+	if allowed := send(models.User{ID: 1, Email: "admin@example.com", Role: models.RoleAdmin}); allowed.Code != http.StatusOK {
+		t.Fatalf("status for an admin = %d, want 200", allowed.Code)
+	}
+	if audit.filter == nil {
+		t.Error("the admin request did not reach the audit log")
+	}
+}
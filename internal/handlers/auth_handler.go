@@ -3,65 +3,81 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"task_API/internal/dto"
 	"task_API/internal/models"
 	"task_API/internal/services"
+	apperrors "task_API/pkg/errors"
+	"task_API/pkg/responses"
+	"task_API/pkg/utils"
+	"task_API/pkg/validator"
 )
 
 type AuthHandler struct {
-	authService services.AuthService
+	authService  services.AuthService
+	auditService services.AuditService
 }
 
-func NewAuthHandler(authService services.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+func NewAuthHandler(authService services.AuthService, auditService services.AuditService) *AuthHandler {
+	return &AuthHandler{authService: authService, auditService: auditService}
 }
 
 func (handler *AuthHandler) Register(writer http.ResponseWriter, request *http.Request) {
 	var registerRequest models.RegisterUserRequest
 
-	if error := json.NewDecoder(request.Body).Decode(&registerRequest); error != nil {
-		http.Error(writer, "Invalid request body", http.StatusBadRequest)
+	if error := utils.DecodeJSON(request, &registerRequest); error != nil {
+		responses.WriteError(writer, request, error)
 		return
 	}
 
-	if registerRequest.Email == "" || registerRequest.Name == "" || registerRequest.Password == "" {
-		http.Error(writer, "Some of Required Attributes empty. Email, Password, and Email can't be empty", http.StatusBadRequest)
-		return
-	}
-
-	if len(registerRequest.Password) < 8 {
-		http.Error(writer, "Password must be at least 8 character long", http.StatusBadRequest)
+	if error := validator.Struct(&registerRequest); error != nil {
+		responses.WriteError(writer, request, error)
 		return
 	}
 
 	// Create User
-	authResponse, error := handler.authService.Register(&registerRequest)
+	authResponse, error := handler.authService.Register(request.Context(), &registerRequest)
 	if error != nil {
-		http.Error(writer, "Unable to create user: "+error.Error(), http.StatusBadRequest)
+		if responses.WriteContextError(writer, request, error) {
+			return
+		}
+		handler.audit(request, models.AuditEventRegister, nil, registerRequest.Email, false, error.Error())
+		responses.WriteError(writer, request, error)
 		return
 	}
+	handler.audit(request, models.AuditEventRegister, &authResponse.User.ID, authResponse.User.Email, true, "")
 
 	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusCreated)
-	json.NewEncoder(writer).Encode(authResponse)
+	json.NewEncoder(writer).Encode(dto.NewAuthResponse(authResponse))
 }
 
 func (handler *AuthHandler) Login(writer http.ResponseWriter, request *http.Request) {
 	var loginRequest models.LoginUserRequest
 
-	if error := json.NewDecoder(request.Body).Decode(&loginRequest); error != nil {
-		http.Error(writer, "Invalid request body", http.StatusBadRequest)
+	if error := utils.DecodeJSON(request, &loginRequest); error != nil {
+		responses.WriteError(writer, request, error)
 		return
 	}
 
-	authResponse, error := handler.authService.Login(&loginRequest)
+	if error := validator.Struct(&loginRequest); error != nil {
+		responses.WriteError(writer, request, error)
+		return
+	}
+
+	authResponse, error := handler.authService.Login(request.Context(), &loginRequest)
 
 	if error != nil {
-		http.Error(writer, error.Error(), http.StatusNotFound)
+		if responses.WriteContextError(writer, request, error) {
+			return
+		}
+		handler.audit(request, models.AuditEventLoginFailure, nil, loginRequest.Email, false, error.Error())
+		responses.WriteError(writer, request, error)
 		return
 	}
+	handler.audit(request, models.AuditEventLoginSuccess, &authResponse.User.ID, authResponse.User.Email, true, "")
 
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(authResponse)
+	json.NewEncoder(writer).Encode(dto.NewAuthResponse(authResponse))
 }
 
 // Get Profile returns the current User Profile
@@ -69,12 +85,54 @@ func (handler *AuthHandler) GetProfile(writer http.ResponseWriter, request *http
 	// Get user ID from context( AuthMiddleware sets it)
 	user, ok := request.Context().Value("user").(models.User)
 	if !ok {
-		http.Error(writer, "Unable to get user from context", http.StatusInternalServerError)
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("Unable to get user from context"))
 		return
 	}
 
-	user.PasswordHash = ""
-
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(user)
+	json.NewEncoder(writer).Encode(dto.NewUser(&user))
+}
+
+// ChangePassword replaces the password of the current user after checking the current one
+func (handler *AuthHandler) ChangePassword(writer http.ResponseWriter, request *http.Request) {
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("Unable to get user from context"))
+		return
+	}
+
+	var changeRequest models.ChangePasswordRequest
+	if error := utils.DecodeJSON(request, &changeRequest); error != nil {
+		responses.WriteError(writer, request, error)
+		return
+	}
+
+	if error := validator.Struct(&changeRequest); error != nil {
+		responses.WriteError(writer, request, error)
+		return
+	}
+
+	if error := handler.authService.ChangePassword(request.Context(), user.ID, &changeRequest); error != nil {
+		if responses.WriteContextError(writer, request, error) {
+			return
+		}
+		handler.audit(request, models.AuditEventPasswordChange, &user.ID, user.Email, false, error.Error())
+		responses.WriteError(writer, request, error)
+		return
+	}
+	handler.audit(request, models.AuditEventPasswordChange, &user.ID, user.Email, true, "")
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *AuthHandler) audit(request *http.Request, event string, userId *int, email string, success bool, details string) {
+	handler.auditService.Record(request.Context(), &models.AuditLog{
+		UserId:    userId,
+		Event:     event,
+		Email:     email,
+		IPAddress: utils.ClientIP(request),
+		UserAgent: request.UserAgent(),
+		Success:   success,
+		Details:   details,
+	})
 }
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"task_API/internal/health"
+)
+
+type HealthHandler struct {
+	registry *health.Registry
+}
+
+func NewHealthHandler(registry *health.Registry) *HealthHandler {
+	return &HealthHandler{registry: registry}
+}
+
+// Livez answers 200 while the process can serve, and 503 when only a restart helps
+func (h *HealthHandler) Livez(writer http.ResponseWriter, request *http.Request) {
+	h.writeReport(writer, h.registry.Liveness(request.Context()))
+}
+
+// Readyz answers 200 when the API can take traffic, and 503 while a dependency is
+// down or the API is shutting down
+func (h *HealthHandler) Readyz(writer http.ResponseWriter, request *http.Request) {
+	h.writeReport(writer, h.registry.Readiness(request.Context()))
+}
+
+func (h *HealthHandler) writeReport(writer http.ResponseWriter, report health.Report) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	if !report.Healthy() {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(writer).Encode(report)
+}
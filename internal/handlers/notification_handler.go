@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"task_API/internal/dto"
+	"task_API/internal/models"
+	"task_API/internal/services"
+	apperrors "task_API/pkg/errors"
+	"task_API/pkg/responses"
+
+	"github.com/gorilla/mux"
+)
+
+type NotificationHandler struct {
+	notificationService services.NotificationService
+}
+
+func NewNotificationHandler(notificationService services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// GetNotifications lists the notifications of the user, newest first. ?unread=true
+// only returns unread ones and ?limit caps the result.
+func (h *NotificationHandler) GetNotifications(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	query := request.URL.Query()
+	unreadOnly := false
+	if value := query.Get("unread"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid 'unread' query parameter"))
+			return
+		}
+		unreadOnly = parsed
+	}
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid 'limit' query parameter"))
+			return
+		}
+		limit = parsed
+	}
+
+	notifications, err := h.notificationService.GetNotifications(request.Context(), user.ID, unreadOnly, limit)
+	if err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(dto.NewNotifications(notifications))
+}
+
+func (h *NotificationHandler) MarkRead(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	vars := mux.Vars(request)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid notification ID"))
+		return
+	}
+
+	if err := h.notificationService.MarkRead(request.Context(), id, user.ID); err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (h *NotificationHandler) MarkAllRead(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	marked, err := h.notificationService.MarkAllRead(request.Context(), user.ID)
+	if err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(dto.MarkedNotifications{Marked: marked})
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"task_API/internal/config"
+	"task_API/internal/dto"
+	"task_API/internal/events"
+	"task_API/internal/models"
+	"task_API/internal/realtime"
+	"task_API/internal/services"
+	apperrors "task_API/pkg/errors"
+	"task_API/pkg/responses"
+	"task_API/pkg/utils"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type RealtimeHandler struct {
+	hub         *realtime.Hub
+	taskService services.TaskService
+	config      config.RealtimeConfig
+	upgrader    websocket.Upgrader
+}
+
+func NewRealtimeHandler(hub *realtime.Hub, taskService services.TaskService, cfg config.RealtimeConfig) *RealtimeHandler {
+	return &RealtimeHandler{
+		hub:         hub,
+		taskService: taskService,
+		config:      cfg,
+	}
+}
+
+// StreamEvents pushes the task events of the user as Server-Sent Events. Clients resume
+// with the Last-Event-ID header, or the last_event_id query parameter.
+func (h *RealtimeHandler) StreamEvents(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	lastEventId, resume, err := lastEventId(request)
+	if err != nil {
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid Last-Event-ID"))
+		return
+	}
+
+	// Subscribe before catching up so that nothing published in between is lost
+	subscriber := h.hub.Subscribe(user.ID)
+	defer h.hub.Unsubscribe(subscriber)
+
+	version := utils.APIVersion(request.Context())
+	controller := http.NewResponseController(writer)
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(http.StatusOK)
+
+	// Each write gets its own deadline, a stalled client must not hold the stream forever
+	send := func(write func() error) bool {
+		controller.SetWriteDeadline(time.Now().Add(h.config.WriteTimeout))
+		if err := write(); err != nil {
+			return false
+		}
+		return controller.Flush() == nil
+	}
+
+	if !send(func() error { _, err := io.WriteString(writer, ": connected\n\n"); return err }) {
+		return
+	}
+
+	replayedId := 0
+	if resume {
+		for _, event := range h.missedEvents(request.Context(), user.ID, lastEventId) {
+			if !send(func() error { return writeServerSentEvent(writer, version, event) }) {
+				return
+			}
+			replayedId = event.ID
+		}
+	}
+
+	heartbeat := time.NewTicker(h.config.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-subscriber.Dropped():
+			// The client reconnects with Last-Event-ID and catches up from the history
+			return
+		case <-h.hub.Done():
+			return
+		case <-heartbeat.C:
+			if !send(func() error { _, err := io.WriteString(writer, ": heartbeat\n\n"); return err }) {
+				return
+			}
+		case event := <-subscriber.Events():
+			if event.ID <= replayedId {
+				continue
+			}
+			if !send(func() error { return writeServerSentEvent(writer, version, event) }) {
+				return
+			}
+		}
+	}
+}
+
+// WebSocket pushes the task events of the user as JSON messages. Clients resume with
+// the last_event_id query parameter.
+func (h *RealtimeHandler) WebSocket(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	lastEventId, resume, err := lastEventId(request)
+	if err != nil {
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid last_event_id"))
+		return
+	}
+
+	subscriber := h.hub.Subscribe(user.ID)
+	defer h.hub.Unsubscribe(subscriber)
+
+	// Upgrade replies with an error itself when it fails
+	conn, err := h.upgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// The client only sends control frames, reading is needed to process them
+	pongWait := 2 * h.config.HeartbeatInterval
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	version := utils.APIVersion(request.Context())
+	send := func(event events.Event) bool {
+		conn.SetWriteDeadline(time.Now().Add(h.config.WriteTimeout))
+		return conn.WriteJSON(dto.NewEvent(version, event)) == nil
+	}
+
+	replayedId := 0
+	if resume {
+		for _, event := range h.missedEvents(request.Context(), user.ID, lastEventId) {
+			if !send(event) {
+				return
+			}
+			replayedId = event.ID
+		}
+	}
+
+	heartbeat := time.NewTicker(h.config.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-subscriber.Dropped():
+			message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client too slow, reconnect with last_event_id")
+			conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(h.config.WriteTimeout))
+			return
+		case <-h.hub.Done():
+			message := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down, reconnect with last_event_id")
+			conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(h.config.WriteTimeout))
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.config.WriteTimeout)); err != nil {
+				return
+			}
+		case event := <-subscriber.Events():
+			if event.ID <= replayedId {
+				continue
+			}
+			if !send(event) {
+				return
+			}
+		}
+	}
+}
+
+// missedEvents returns the events published after lastEventId, or a single reset
+// event when they cannot all be replayed
+func (h *RealtimeHandler) missedEvents(ctx context.Context, userId int, lastEventId int) []events.Event {
+	missed, complete, err := h.taskService.GetEventsSince(ctx, userId, lastEventId, h.config.ReplayLimit)
+	if err != nil || !complete {
+		return []events.Event{{ID: lastEventId, Type: realtime.ResetEvent, UserId: userId}}
+	}
+	return missed
+}
+
+// lastEventId reads the id of the last event the client received, if it sent one
+func lastEventId(request *http.Request) (int, bool, error) {
+	value := request.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = request.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+func writeServerSentEvent(writer io.Writer, version string, event events.Event) error {
+	data, err := json.Marshal(dto.NewEvent(version, event))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
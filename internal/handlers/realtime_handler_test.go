@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"task_API/internal/config"
+	"task_API/internal/dto"
+	"task_API/internal/events"
+	"task_API/internal/models"
+	"task_API/internal/realtime"
+	"task_API/internal/services"
+	"task_API/internal/storage/memory"
+	"task_API/pkg/logger"
+	"task_API/pkg/middleware"
+
+	"github.com/gorilla/websocket"
+)
+
+var testRealtimeConfig = config.RealtimeConfig{
+	BufferSize:        16,
+	ReplayLimit:       100,
+	HeartbeatInterval: time.Hour,
+	WriteTimeout:      5 * time.Second,
+}
+
+// realtimeFixture is a task service whose events reach the hub, as in main
+type realtimeFixture struct {
+	hub     *realtime.Hub
+	tasks   services.TaskService
+	handler *RealtimeHandler
+	user    models.User
+}
+
+func newRealtimeFixture(t *testing.T, cfg config.RealtimeConfig) *realtimeFixture {
+	t.Helper()
+
+	store := memory.NewStorage()
+	user := models.User{Email: "owner@example.com", PasswordHash: "hash", Role: models.RoleUser}
+	if err := store.Users().CreateUser(context.Background(), &user); err != nil {
+		t.Fatal(err)
+	}
+
+	hub := realtime.NewHub(cfg.BufferSize, nil, logger.NewLogger("disabled", "json"))
+	t.Cleanup(hub.Close)
+	bus := events.NewBus()
+	bus.Subscribe(hub.Publish)
+
+	tasks := services.NewTaskService(store.Tasks(), store.TaskEvents(), store.Reminders(), store.Webhooks(), store.TxManager(), bus)
+	return &realtimeFixture{hub: hub, tasks: tasks, handler: NewRealtimeHandler(hub, tasks, cfg), user: user}
+}
+
+// serve runs handle for the fixture's user, as the routes do after authentication
+func (fixture *realtimeFixture) serve(t *testing.T, handle http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	authenticated := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		handle(writer, request.WithContext(context.WithValue(request.Context(), "user", fixture.user)))
+	})
+	server := httptest.NewServer(middleware.APIVersion(dto.V1)(authenticated))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (fixture *realtimeFixture) createTask(t *testing.T, title string) *models.Task {
+	t.Helper()
+	task, err := fixture.tasks.CreateTask(context.Background(), &models.CreateTaskRequest{Title: title}, fixture.user.ID)
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	return task
+}
+
+type serverSentEvent struct {
+	id        int
+	eventType string
+	data      dto.Event
+}
+
+// openStream connects to the event stream and waits until it is subscribed
+func openStream(t *testing.T, url string, lastEventId string) (*bufio.Reader, func()) {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventId != "" {
+		request.Header.Set("Last-Event-ID", lastEventId)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "text/event-stream" {
+		response.Body.Close()
+		t.Fatalf("status = %d, Content-Type = %q", response.StatusCode, response.Header.Get("Content-Type"))
+	}
+
+	reader := bufio.NewReader(response.Body)
+	if line, err := reader.ReadString('\n'); err != nil || line != ": connected\n" {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+	return reader, func() { response.Body.Close() }
+}
+
+// readEvent reads the next event of the stream, skipping comments
+func readEvent(t *testing.T, reader *bufio.Reader) serverSentEvent {
+	t.Helper()
+	var event serverSentEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.eventType != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id, _ = strconv.Atoi(strings.TrimPrefix(line, "id: "))
+		case strings.HasPrefix(line, "event: "):
+			event.eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.data); err != nil {
+				t.Fatalf("data %q: %v", line, err)
+			}
+		}
+	}
+}
+
+func TestStreamEventsReplaysWhatTheClientMissed(t *testing.T) {
+	fixture := newRealtimeFixture(t, testRealtimeConfig)
+	server := fixture.serve(t, fixture.handler.StreamEvents)
+
+	reader, disconnect := openStream(t, server.URL, "")
+	first := fixture.createTask(t, "First")
+	received := readEvent(t, reader)
+	if received.eventType != events.TaskCreated || received.data.ID != received.id || received.data.UserId != fixture.user.ID {
+		t.Fatalf("live event = %+v", received)
+	}
+	if task, _ := received.data.Task.(map[string]interface{}); task["id"] != float64(first.ID) {
+		t.Errorf("task of the event = %v, want task %d", received.data.Task, first.ID)
+	}
+	disconnect()
+
+	// Created while the client was away
+	fixture.createTask(t, "Second")
+	fixture.createTask(t, "Third")
+
+	reader, disconnect = openStream(t, server.URL, strconv.Itoa(received.id))
+	defer disconnect()
+	for _, want := range []int{received.id + 1, received.id + 2} {
+		if replayed := readEvent(t, reader); replayed.id != want || replayed.eventType != events.TaskCreated {
+			t.Fatalf("replayed event = %+v, want event %d", replayed, want)
+		}
+	}
+
+	// Live events carry on after the replay
+	fixture.createTask(t, "Fourth")
+	if live := readEvent(t, reader); live.id != received.id+3 {
+		t.Fatalf("live event after the replay = %+v, want event %d", live, received.id+3)
+	}
+}
+
+func TestStreamEventsResetsWhenTooManyEventsWereMissed(t *testing.T) {
+	cfg := testRealtimeConfig
+	cfg.ReplayLimit = 1
+	fixture := newRealtimeFixture(t, cfg)
+	server := fixture.serve(t, fixture.handler.StreamEvents)
+
+	fixture.createTask(t, "First")
+	fixture.createTask(t, "Second")
+
+	reader, disconnect := openStream(t, server.URL, "0")
+	defer disconnect()
+	if reset := readEvent(t, reader); reset.eventType != realtime.ResetEvent || reset.id != 0 {
+		t.Fatalf("event = %+v, want a reset", reset)
+	}
+}
+
+func TestStreamEventsRefusesAnInvalidLastEventId(t *testing.T) {
+	fixture := newRealtimeFixture(t, testRealtimeConfig)
+	server := fixture.serve(t, fixture.handler.StreamEvents)
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	request.Header.Set("Last-Event-ID", "latest")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", response.StatusCode)
+	}
+}
+
+// stalledWriter accepts the first write and blocks the second one until released, like
+// a client that stopped reading
+type stalledWriter struct {
+	header   http.Header
+	mutex    sync.Mutex
+	writes   int
+	stalled  chan struct{}
+	released chan struct{}
+}
+
+func (writer *stalledWriter) Header() http.Header { return writer.header }
+
+func (writer *stalledWriter) WriteHeader(status int) {}
+
+func (writer *stalledWriter) Flush() {}
+
+func (writer *stalledWriter) Write(data []byte) (int, error) {
+	writer.mutex.Lock()
+	writer.writes++
+	writes := writer.writes
+	writer.mutex.Unlock()
+
+	if writes == 2 {
+		close(writer.stalled)
+		<-writer.released
+	}
+	return len(data), nil
+}
+
+func (writer *stalledWriter) writesSoFar() int {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	return writer.writes
+}
+
+func TestStreamEventsDropsASlowClient(t *testing.T) {
+	cfg := testRealtimeConfig
+	cfg.BufferSize = 2
+	fixture := newRealtimeFixture(t, cfg)
+
+	writer := &stalledWriter{header: http.Header{}, stalled: make(chan struct{}), released: make(chan struct{})}
+	request := httptest.NewRequest(http.MethodGet, "/events", nil)
+	request = request.WithContext(context.WithValue(request.Context(), "user", fixture.user))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fixture.handler.StreamEvents(writer, request)
+	}()
+
+	// The first event blocks the stream, the next ones overflow the buffer
+	publish := func(id int) {
+		fixture.hub.Publish(events.Event{ID: id, Type: events.TaskUpdated, UserId: fixture.user.ID, Task: &models.Task{ID: 1}})
+	}
+	for writer.writesSoFar() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	publish(1)
+	<-writer.stalled
+	for id := 2; id <= 2+cfg.BufferSize; id++ {
+		publish(id)
+	}
+	close(writer.released)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stream of a slow client was not closed")
+	}
+}
+
+func TestWebSocketResumesAndStreamsEvents(t *testing.T) {
+	fixture := newRealtimeFixture(t, testRealtimeConfig)
+	server := fixture.serve(t, fixture.handler.WebSocket)
+
+	first := fixture.createTask(t, "First")
+	fixture.createTask(t, "Second")
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?last_event_id=1"
+	conn, response, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", response.StatusCode)
+	}
+
+	var replayed dto.Event
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&replayed); err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	if replayed.ID != 2 || replayed.Type != events.TaskCreated {
+		t.Fatalf("replayed = %+v, want the event after 1", replayed)
+	}
+
+	// The replay is done once the missed event arrived, later events are live
+	fixture.tasks.UpdateTask(context.Background(), first.ID, &models.UpdateTaskRequest{Title: "First", Completed: true}, fixture.user.ID)
+	var types []string
+	for len(types) < 2 {
+		var live dto.Event
+		if err := conn.ReadJSON(&live); err != nil {
+			t.Fatalf("ReadJSON: %v", err)
+		}
+		if live.ID != 3 {
+			t.Fatalf("live = %+v, want event 3", live)
+		}
+		types = append(types, live.Type)
+	}
+	if types[0] != events.TaskUpdated || types[1] != events.TaskCompleted {
+		t.Errorf("live types = %v, want updated then completed", types)
+	}
+}
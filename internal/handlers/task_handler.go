@@ -2,140 +2,263 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"task_API/internal/dto"
 	"task_API/internal/models"
 	"task_API/internal/services"
+	apperrors "task_API/pkg/errors"
+	"task_API/pkg/responses"
+	"task_API/pkg/utils"
+	"task_API/pkg/validator"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 type TaskHandler struct {
-	taskService services.TaskService
+	taskService  services.TaskService
+	auditService services.AuditService
 }
 
-func NewTaskHandler(taskService services.TaskService) *TaskHandler {
-	return &TaskHandler{taskService: taskService}
+func NewTaskHandler(taskService services.TaskService, auditService services.AuditService) *TaskHandler {
+	return &TaskHandler{taskService: taskService, auditService: auditService}
 }
 
 func (h *TaskHandler) GetAllTasks(writer http.ResponseWriter, request *http.Request) {
 	// Get User From Context
 	user, ok := request.Context().Value("user").(models.User)
 	if !ok {
-		http.Error(writer, "User not found in context", http.StatusInternalServerError)
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
 		return
 	}
 
-	tasks, err := h.taskService.GetAllTasks(user.ID)
+	tasks, err := h.taskService.GetAllTasks(request.Context(), user.ID)
 	if err != nil {
-		http.Error(writer, "Failed to fetch tasks", http.StatusInternalServerError)
+		responses.WriteError(writer, request, err)
 		return
 	}
 
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(tasks)
+	json.NewEncoder(writer).Encode(dto.Tasks(utils.APIVersion(request.Context()), tasks))
 }
 
 func (h *TaskHandler) GetTask(writer http.ResponseWriter, request *http.Request) {
 	// Get User From Context
 	user, ok := request.Context().Value("user").(models.User)
 	if !ok {
-		http.Error(writer, "User not found in context", http.StatusInternalServerError)
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
 		return
 	}
 
 	vars := mux.Vars(request)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(writer, "Invalid task ID", http.StatusBadRequest)
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid task ID"))
 		return
 	}
 
-	task, err := h.taskService.GetTaskById(id, user.ID)
+	task, err := h.taskService.GetTaskById(request.Context(), id, user.ID)
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusNotFound)
+		h.auditDenied(request, user, err)
+		responses.WriteError(writer, request, err)
 		return
 	}
 
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(task)
+	json.NewEncoder(writer).Encode(dto.Task(utils.APIVersion(request.Context()), task))
 }
 
 func (h *TaskHandler) CreateTask(writer http.ResponseWriter, request *http.Request) {
 	// Get User From Context
 	user, ok := request.Context().Value("user").(models.User)
 	if !ok {
-		http.Error(writer, "User not found in context", http.StatusInternalServerError)
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
 		return
 	}
 
 	var req models.CreateTaskRequest
-	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
-		http.Error(writer, "Invalid request payload", http.StatusBadRequest)
+	if err := utils.DecodeJSON(request, &req); err != nil {
+		responses.WriteError(writer, request, err)
 		return
 	}
 
-	task, err := h.taskService.CreateTask(&req, user.ID)
+	if err := validator.Struct(&req); err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	task, err := h.taskService.CreateTask(request.Context(), &req, user.ID)
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		responses.WriteError(writer, request, err)
 		return
 	}
 
 	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusCreated)
-	json.NewEncoder(writer).Encode(task)
+	json.NewEncoder(writer).Encode(dto.Task(utils.APIVersion(request.Context()), task))
 }
 
 func (h *TaskHandler) UpdateTask(writer http.ResponseWriter, request *http.Request) {
 	// Get User From Context
 	user, ok := request.Context().Value("user").(models.User)
 	if !ok {
-		http.Error(writer, "User not found in context", http.StatusInternalServerError)
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
 		return
 	}
 
 	vars := mux.Vars(request)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(writer, "Invalid task ID", http.StatusBadRequest)
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid task ID"))
 		return
 	}
 
 	var req models.UpdateTaskRequest
-	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
-		http.Error(writer, err.Error(), http.StatusBadRequest)
+	if err := utils.DecodeJSON(request, &req); err != nil {
+		responses.WriteError(writer, request, err)
 		return
 	}
 
-	task, err := h.taskService.UpdateTask(id, &req, user.ID)
+	if err := validator.Struct(&req); err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	task, err := h.taskService.UpdateTask(request.Context(), id, &req, user.ID)
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusNotFound)
+		h.auditDenied(request, user, err)
+		responses.WriteError(writer, request, err)
 		return
 	}
 
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(task)
+	json.NewEncoder(writer).Encode(dto.Task(utils.APIVersion(request.Context()), task))
 }
 
 func (h *TaskHandler) DeleteTask(writer http.ResponseWriter, request *http.Request) {
 	// Get User From Context
 	user, ok := request.Context().Value("user").(models.User)
 	if !ok {
-		http.Error(writer, "User not found in context", http.StatusInternalServerError)
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
 		return
 	}
 
 	vars := mux.Vars(request)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(writer, "Invalid task ID", http.StatusBadRequest)
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid task ID"))
 		return
 	}
 
-	if err := h.taskService.DeleteTask(id, user.ID); err != nil {
-		http.Error(writer, err.Error(), http.StatusNotFound)
+	if err := h.taskService.DeleteTask(request.Context(), id, user.ID); err != nil {
+		h.auditDenied(request, user, err)
+		responses.WriteError(writer, request, err)
 		return
 	}
 
 	writer.WriteHeader(http.StatusNoContent)
 }
+
+func (h *TaskHandler) RestoreTask(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	vars := mux.Vars(request)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid task ID"))
+		return
+	}
+
+	task, err := h.taskService.RestoreTask(request.Context(), id, user.ID)
+	if err != nil {
+		h.auditDenied(request, user, err)
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(dto.Task(utils.APIVersion(request.Context()), task))
+}
+
+// GetTaskHistory returns every recorded change of a task, oldest first
+func (h *TaskHandler) GetTaskHistory(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	vars := mux.Vars(request)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid task ID"))
+		return
+	}
+
+	events, err := h.taskService.GetTaskHistory(request.Context(), id, user.ID)
+	if err != nil {
+		h.auditDenied(request, user, err)
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(dto.NewTaskEvents(events))
+}
+
+// GetTaskSnapshot reconstructs a task as it was at the time given in the `at` query parameter
+func (h *TaskHandler) GetTaskSnapshot(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	vars := mux.Vars(request)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid task ID"))
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, request.URL.Query().Get("at"))
+	if err != nil {
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid or missing 'at' timestamp, expected RFC 3339"))
+		return
+	}
+
+	task, err := h.taskService.GetTaskAsOf(request.Context(), id, user.ID, at)
+	if err != nil {
+		h.auditDenied(request, user, err)
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(dto.Task(utils.APIVersion(request.Context()), task))
+}
+
+// auditDenied records attempts to act on a task owned by another user
+func (h *TaskHandler) auditDenied(request *http.Request, user models.User, err error) {
+	if !errors.Is(err, services.ErrPermissionDenied) {
+		return
+	}
+
+	h.auditService.Record(request.Context(), &models.AuditLog{
+		UserId:    &user.ID,
+		Event:     models.AuditEventPermissionDenied,
+		Email:     user.Email,
+		IPAddress: utils.ClientIP(request),
+		UserAgent: request.UserAgent(),
+		Details:   request.Method + " " + request.URL.Path + ": " + err.Error(),
+	})
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"task_API/internal/dto"
+	"task_API/internal/models"
+	"task_API/internal/services"
+	apperrors "task_API/pkg/errors"
+	"task_API/pkg/responses"
+	"task_API/pkg/utils"
+	"task_API/pkg/validator"
+
+	"github.com/gorilla/mux"
+)
+
+type WebhookHandler struct {
+	webhookService services.WebhookService
+}
+
+func NewWebhookHandler(webhookService services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+func (h *WebhookHandler) CreateWebhook(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := utils.DecodeJSON(request, &req); err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	if err := validator.Struct(&req); err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	subscription, err := h.webhookService.CreateSubscription(request.Context(), &req, user.ID)
+	if err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	json.NewEncoder(writer).Encode(dto.NewWebhookSubscription(subscription))
+}
+
+func (h *WebhookHandler) GetWebhooks(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	subscriptions, err := h.webhookService.GetSubscriptions(request.Context(), user.ID)
+	if err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(dto.NewWebhookSubscriptions(subscriptions))
+}
+
+func (h *WebhookHandler) DeleteWebhook(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	vars := mux.Vars(request)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid webhook ID"))
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(request.Context(), id, user.ID); err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries returns the most recent deliveries of a webhook, including dead-lettered ones
+func (h *WebhookHandler) GetWebhookDeliveries(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	vars := mux.Vars(request)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid webhook ID"))
+		return
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(request.Context(), id, user.ID)
+	if err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(dto.NewWebhookDeliveries(deliveries))
+}
+
+// RetryWebhookDelivery queues a delivery again, typically after it was dead-lettered
+func (h *WebhookHandler) RetryWebhookDelivery(writer http.ResponseWriter, request *http.Request) {
+	// Get User From Context
+	user, ok := request.Context().Value("user").(models.User)
+	if !ok {
+		responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+		return
+	}
+
+	vars := mux.Vars(request)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid webhook ID"))
+		return
+	}
+	deliveryId, err := strconv.Atoi(vars["deliveryId"])
+	if err != nil {
+		responses.WriteError(writer, request, apperrors.ErrBadRequest.New("Invalid delivery ID"))
+		return
+	}
+
+	delivery, err := h.webhookService.RetryDelivery(request.Context(), id, deliveryId, user.ID)
+	if err != nil {
+		responses.WriteError(writer, request, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(writer).Encode(dto.NewWebhookDelivery(delivery))
+}
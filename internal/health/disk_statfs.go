@@ -0,0 +1,26 @@
+//go:build linux || darwin
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+)
+
+// DiskSpace checks that the file system holding path has at least minFree bytes
+// available to the API
+func DiskSpace(path string, minFree uint64) Checker {
+	return func(ctx context.Context) error {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+
+		available := uint64(stat.Bavail) * uint64(stat.Bsize)
+		if available < minFree {
+			return fmt.Errorf("%d bytes free on %s, need %d", available, path, minFree)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,163 @@
+// Package health keeps the checks behind the liveness and readiness endpoints.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses of a check and of a report
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+// Checker returns nil when the dependency it checks is healthy. It must give up
+// when ctx is done.
+type Checker func(ctx context.Context) error
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every check of a probe
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Healthy reports whether the probe passed
+func (report Report) Healthy() bool {
+	return report.Status == StatusUp
+}
+
+type namedChecker struct {
+	name    string
+	checker Checker
+	live    bool
+}
+
+// Registry holds the checks of the API. Liveness checks fail when only a restart
+// helps, readiness checks when the API should not get traffic for now.
+type Registry struct {
+	mutex    sync.RWMutex
+	checkers []namedChecker
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// NewRegistry creates a registry whose checks each get timeout to answer
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// Live adds a liveness check. Liveness checks are part of readiness too.
+func (registry *Registry) Live(name string, checker Checker) {
+	registry.add(namedChecker{name: name, checker: checker, live: true})
+}
+
+// Ready adds a readiness check
+func (registry *Registry) Ready(name string, checker Checker) {
+	registry.add(namedChecker{name: name, checker: checker})
+}
+
+func (registry *Registry) add(checker namedChecker) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.checkers = append(registry.checkers, checker)
+}
+
+// SetDraining marks the API as shutting down, from then on it is not ready
+func (registry *Registry) SetDraining() {
+	registry.draining.Store(true)
+}
+
+// Liveness runs the liveness checks
+func (registry *Registry) Liveness(ctx context.Context) Report {
+	return registry.run(ctx, true)
+}
+
+// Readiness runs every check. A draining API is not ready whatever the checks say.
+func (registry *Registry) Readiness(ctx context.Context) Report {
+	report := registry.run(ctx, false)
+	if registry.draining.Load() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+// run runs the checks concurrently, so the slowest one bounds the probe
+func (registry *Registry) run(ctx context.Context, liveOnly bool) Report {
+	registry.mutex.RLock()
+	checkers := make([]namedChecker, 0, len(registry.checkers))
+	for _, checker := range registry.checkers {
+		if checker.live || !liveOnly {
+			checkers = append(checkers, checker)
+		}
+	}
+	registry.mutex.RUnlock()
+
+	results := make([]CheckResult, len(checkers))
+	var wait sync.WaitGroup
+	for index, checker := range checkers {
+		wait.Add(1)
+		go func(index int, checker Checker) {
+			defer wait.Done()
+			results[index] = registry.check(ctx, checker)
+		}(index, checker.checker)
+	}
+	wait.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checkers))}
+	for index, checker := range checkers {
+		report.Checks[checker.name] = results[index]
+		if results[index].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (registry *Registry) check(ctx context.Context, checker Checker) (result CheckResult) {
+	if registry.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, registry.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	defer func() {
+		result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+		// A broken check fails itself, not the probe
+		if recovered := recover(); recovered != nil {
+			result.Status = StatusDown
+			result.Error = fmt.Sprintf("check panicked: %v", recovered)
+		}
+	}()
+
+	if err := checker(ctx); err != nil {
+		return CheckResult{Status: StatusDown, Error: err.Error()}
+	}
+	return CheckResult{Status: StatusUp}
+}
+
+// SchemaVersion checks that the database schema is at the latest migration the API knows
+func SchemaVersion(version func(ctx context.Context) (int, int, error)) Checker {
+	return func(ctx context.Context) error {
+		current, latest, err := version(ctx)
+		if err != nil {
+			return err
+		}
+		if current != latest {
+			return fmt.Errorf("schema at version %d, expected %d", current, latest)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func up(ctx context.Context) error { return nil }
+
+func TestReadinessRunsEveryCheck(t *testing.T) {
+	registry := NewRegistry(time.Second)
+	registry.Live("workers", up)
+	registry.Ready("database", up)
+
+	report := registry.Readiness(context.Background())
+	if !report.Healthy() || len(report.Checks) != 2 {
+		t.Fatalf("report = %+v, want both checks up", report)
+	}
+
+	registry.Ready("cache", func(ctx context.Context) error { return errors.New("connection refused") })
+	report = registry.Readiness(context.Background())
+	if report.Status != StatusDown {
+		t.Fatalf("status = %s, want %s", report.Status, StatusDown)
+	}
+	if result := report.Checks["cache"]; result.Status != StatusDown || result.Error != "connection refused" {
+		t.Fatalf("cache = %+v", result)
+	}
+	if report.Checks["database"].Status != StatusUp {
+		t.Fatalf("database = %+v, want up", report.Checks["database"])
+	}
+}
+
+func TestLivenessIgnoresReadinessChecks(t *testing.T) {
+	registry := NewRegistry(time.Second)
+	registry.Live("workers", up)
+	registry.Ready("database", func(ctx context.Context) error { return errors.New("down") })
+
+	report := registry.Liveness(context.Background())
+	if !report.Healthy() || len(report.Checks) != 1 {
+		t.Fatalf("report = %+v, want only the live check", report)
+	}
+}
+
+func TestChecksAreBoundedByTheTimeout(t *testing.T) {
+	registry := NewRegistry(20 * time.Millisecond)
+	registry.Ready("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	registry.Ready("broken", func(ctx context.Context) error { panic("nil pool") })
+
+	start := time.Now()
+	report := registry.Readiness(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("readiness took %s", elapsed)
+	}
+	if result := report.Checks["slow"]; result.Status != StatusDown || result.LatencyMs < 20 {
+		t.Fatalf("slow = %+v, want down after the timeout", result)
+	}
+	if result := report.Checks["broken"]; result.Status != StatusDown || !strings.Contains(result.Error, "nil pool") {
+		t.Fatalf("broken = %+v, want the panic reported", result)
+	}
+}
+
+func TestDrainingIsNotReadyButStillLive(t *testing.T) {
+	registry := NewRegistry(time.Second)
+	registry.Live("workers", up)
+	registry.SetDraining()
+
+	if report := registry.Readiness(context.Background()); report.Status != StatusDraining || report.Healthy() {
+		t.Fatalf("readiness = %+v, want draining", report)
+	}
+	if report := registry.Liveness(context.Background()); !report.Healthy() {
+		t.Fatalf("liveness = %+v, want up", report)
+	}
+}
+
+func TestSchemaVersion(t *testing.T) {
+	check := SchemaVersion(func(ctx context.Context) (int, int, error) { return 4, 6, nil })
+	if err := check(context.Background()); err == nil || err.Error() != "schema at version 4, expected 6" {
+		t.Fatalf("err = %v", err)
+	}
+
+	check = SchemaVersion(func(ctx context.Context) (int, int, error) { return 6, 6, nil })
+	if err := check(context.Background()); err != nil {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	dir := os.TempDir()
+	if err := DiskSpace(dir, 1)(context.Background()); err != nil {
+		t.Skipf("disk space check unavailable: %v", err)
+	}
+	if err := DiskSpace(dir, 1<<62)(context.Background()); err == nil {
+		t.Fatal("expected a check asking for 4 EiB to fail")
+	}
+}
@@ -0,0 +1,169 @@
+// Package metrics holds the Prometheus metrics of the API, served at /metrics.
+// Everything is registered on Registry rather than the global default registry.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"task_API/internal/events"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "task_api"
+
+// ActiveUserWindow is how long a user counts as active after an authenticated request
+const ActiveUserWindow = 15 * time.Minute
+
+// Registry holds every metric of the API
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to answer HTTP requests by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Time to run database statements by kind of statement.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	taskEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_events_total",
+		Help:      "Task changes by event type, such as task.created and task.completed.",
+	}, []string{"type"})
+
+	rateLimitStoreErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ratelimit_store_errors_total",
+		Help:      "Rate limit checks that failed on the store and let the request through.",
+	})
+
+	activeUsers = newUserTracker(ActiveUserWindow)
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		queryDuration,
+		taskEvents,
+		rateLimitStoreErrors,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_users",
+			Help:      "Users that made an authenticated request in the last 15 minutes.",
+		}, activeUsers.count),
+	)
+
+	// Every event type is exported from the start, not only once it happened
+	for _, eventType := range events.TaskEventTypes {
+		taskEvents.WithLabelValues(eventType)
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDBStats exports the connection pool statistics of db, labelled with name
+func RegisterDBStats(name string, db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveRequest records an answered HTTP request. route is the route template, such
+// as /tasks/{id}, so that ids do not create a series each.
+func ObserveRequest(method string, route string, status int, elapsed time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// ObserveQuery records the duration of a database statement
+func ObserveQuery(query string, elapsed time.Duration) {
+	queryDuration.WithLabelValues(operation(query)).Observe(elapsed.Seconds())
+}
+
+// ObserveTaskEvent counts a task change. It is an events.Bus subscriber.
+func ObserveTaskEvent(event events.Event) {
+	taskEvents.WithLabelValues(event.Type).Inc()
+}
+
+// RateLimitStoreFailed counts a rate limit check the store could not answer
+func RateLimitStoreFailed() {
+	rateLimitStoreErrors.Inc()
+}
+
+// UserSeen marks the user as active
+func UserSeen(userId int) {
+	activeUsers.seen(userId, time.Now())
+}
+
+// operation returns the kind of a statement, its first keyword
+func operation(query string) string {
+	query = strings.TrimSpace(query)
+	keyword := query
+	if end := strings.IndexFunc(query, unicode.IsSpace); end >= 0 {
+		keyword = query[:end]
+	}
+	switch keyword = strings.ToLower(keyword); keyword {
+	case "select", "insert", "update", "delete", "with":
+		return keyword
+	default:
+		return "other"
+	}
+}
+
+// userTracker remembers when users were last seen, forgetting them after the window
+type userTracker struct {
+	mutex    sync.Mutex
+	window   time.Duration
+	lastSeen map[int]time.Time
+	now      func() time.Time
+}
+
+func newUserTracker(window time.Duration) *userTracker {
+	return &userTracker{window: window, lastSeen: make(map[int]time.Time), now: time.Now}
+}
+
+func (tracker *userTracker) seen(userId int, at time.Time) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.lastSeen[userId] = at
+}
+
+// count drops the users seen before the window and returns how many are left
+func (tracker *userTracker) count() float64 {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	cutoff := tracker.now().Add(-tracker.window)
+	for userId, at := range tracker.lastSeen {
+		if at.Before(cutoff) {
+			delete(tracker.lastSeen, userId)
+		}
+	}
+	return float64(len(tracker.lastSeen))
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"task_API/internal/events"
+)
+
+func TestOperation(t *testing.T) {
+	tests := map[string]string{
+		"SELECT id FROM tasks":                             "select",
+		"\n\t\tINSERT INTO tasks (title)\n\t\tVALUES ($1)": "insert",
+		"update tasks SET title = $1":                      "update",
+		"DELETE FROM reminders":                            "delete",
+		"WITH due AS (SELECT 1) SELECT * FROM due":         "with",
+		"PRAGMA journal_mode":                              "other",
+		"":                                                 "other",
+	}
+
+	for query, want := range tests {
+		if got := operation(query); got != want {
+			t.Errorf("operation(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestUserTrackerForgetsUsersAfterTheWindow(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newUserTracker(15 * time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	tracker.seen(1, now.Add(-20*time.Minute))
+	tracker.seen(2, now.Add(-5*time.Minute))
+	tracker.seen(3, now)
+	tracker.seen(2, now)
+
+	if got := tracker.count(); got != 2 {
+		t.Fatalf("count = %v, want 2", got)
+	}
+	if _, ok := tracker.lastSeen[1]; ok {
+		t.Fatal("expected the inactive user to be forgotten")
+	}
+}
+
+func TestHandlerExportsTheRecordedMetrics(t *testing.T) {
+	ObserveRequest(http.MethodGet, "/tasks/{id}", http.StatusNotFound, 12*time.Millisecond)
+	ObserveQuery("SELECT 1", time.Millisecond)
+	ObserveTaskEvent(events.Event{Type: events.TaskCompleted})
+	UserSeen(42)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+
+	for _, want := range []string{
+		`task_api_http_requests_total{method="GET",route="/tasks/{id}",status="404"} 1`,
+		`task_api_http_request_duration_seconds_count{method="GET",route="/tasks/{id}"} 1`,
+		`task_api_db_query_duration_seconds_count{operation="select"} 1`,
+		`task_api_task_events_total{type="task.completed"} 1`,
+		`task_api_task_events_total{type="task.created"} 0`,
+		`task_api_active_users 1`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics are missing %s", want)
+		}
+	}
+}
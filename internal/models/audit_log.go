@@ -0,0 +1,85 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Security events recorded in the audit log
+const (
+	AuditEventRegister         = "register"
+	AuditEventLoginSuccess     = "login_success"
+	AuditEventLoginFailure     = "login_failure"
+	AuditEventTokenInvalid     = "token_invalid"
+	AuditEventPasswordChange   = "password_change"
+	AuditEventPermissionDenied = "permission_denied"
+)
+
+// AuditGenesisHash is the previous hash of the first entry in the chain
+var AuditGenesisHash = strings.Repeat("0", 64)
+
+// Model: AuditLog, a security event. Every entry carries the hash of the entry
+// before it, so altering or removing a row breaks the chain.
+type AuditLog struct {
+	ID        int       `json:"id"`
+	UserId    *int      `json:"user_id"`
+	Event     string    `json:"event"`
+	Email     string    `json:"email,omitempty"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Details   string    `json:"details,omitempty"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ComputeHash returns the chain hash of the entry, covering its content and PrevHash
+func (entry *AuditLog) ComputeHash() string {
+	content, _ := json.Marshal(struct {
+		UserId    *int   `json:"user_id"`
+		Event     string `json:"event"`
+		Email     string `json:"email"`
+		IPAddress string `json:"ip_address"`
+		UserAgent string `json:"user_agent"`
+		Success   bool   `json:"success"`
+		Details   string `json:"details"`
+		CreatedAt string `json:"created_at"`
+	}{
+		UserId:    entry.UserId,
+		Event:     entry.Event,
+		Email:     entry.Email,
+		IPAddress: entry.IPAddress,
+		UserAgent: entry.UserAgent,
+		Success:   entry.Success,
+		Details:   entry.Details,
+		CreatedAt: entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	sum := sha256.Sum256(append([]byte(entry.PrevHash), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// Model: AuditLogFilter, the criteria of an audit log query. The json names are the
+// query parameters they are read from.
+type AuditLogFilter struct {
+	UserId    *int       `json:"user_id"`
+	Event     string     `json:"event" validate:"omitempty,oneof=register login_success login_failure token_invalid password_change permission_denied"`
+	Success   *bool      `json:"success"`
+	IPAddress string     `json:"ip"`
+	Since     *time.Time `json:"since"`
+	Until     *time.Time `json:"until" validate:"omitempty,gtfield=Since"`
+	BeforeId  int        `json:"before_id" validate:"min=0"`
+	Limit     int        `json:"limit" validate:"min=0"`
+}
+
+// Model: AuditChainVerification, the result of walking the audit hash chain
+type AuditChainVerification struct {
+	Valid    bool   `json:"valid"`
+	Entries  int    `json:"entries"`
+	BrokenAt *int   `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
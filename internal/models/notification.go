@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Notification types
+const (
+	NotificationTaskReminder = "task_reminder"
+)
+
+// Model: Notification, an in-app message for a user
+type Notification struct {
+	ID        int        `json:"id"`
+	UserId    int        `json:"user_id"`
+	TaskId    *int       `json:"task_id,omitempty"`
+	Type      string     `json:"type"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Model: Reminder, a notification scheduled ahead of the due date of a task
+type Reminder struct {
+	ID            int        `json:"id"`
+	TaskId        int        `json:"task_id"`
+	UserId        int        `json:"user_id"`
+	OffsetMinutes int        `json:"offset_minutes"`
+	FireAt        time.Time  `json:"fire_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+// Model: DueReminder, a reminder that has to fire together with the task it belongs to
+type DueReminder struct {
+	Reminder Reminder
+	Task     Task
+}
@@ -2,23 +2,31 @@ package models
 
 import "time"
 
-// Model: Task, respsent the task entity
+// Model: Task, respsent the task entity. ReminderOffsets are the minutes before
+// the due date at which reminders are sent.
 type Task struct {
-	ID        int       `json:"id"`
-	Title     string    `json:"title"`
-	Completed bool      `json:"completed"`
-	UserId    int       `json:"user_id"`
-	CreatedAt time.Time `json:"creation_time"`
-	UpdatedAt time.Time `json:"updatation_time"`
+	ID              int        `json:"id"`
+	Title           string     `json:"title"`
+	Completed       bool       `json:"completed"`
+	UserId          int        `json:"user_id"`
+	DueDate         *time.Time `json:"due_date,omitempty"`
+	ReminderOffsets []int      `json:"reminder_offsets,omitempty"`
+	CreatedAt       time.Time  `json:"creation_time"`
+	UpdatedAt       time.Time  `json:"updatation_time"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
 }
 
 // Model: CreateTaskRequest, represent the data required to create a task
 type CreateTaskRequest struct {
-	Title string `json:"title"`
+	Title           string     `json:"title" validate:"required,max=255"`
+	DueDate         *time.Time `json:"due_date"`
+	ReminderOffsets []int      `json:"reminder_offsets" validate:"requires=DueDate,dive,min=0"`
 }
 
 type UpdateTaskRequest struct {
-	Title     string    `json:"title"`
-	Completed bool      `json:"completed"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Title           string     `json:"title" validate:"required,max=255"`
+	Completed       bool       `json:"completed"`
+	DueDate         *time.Time `json:"due_date"`
+	ReminderOffsets []int      `json:"reminder_offsets" validate:"requires=DueDate,dive,min=0"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Actions recorded in the task history
+const (
+	TaskActionCreated  = "created"
+	TaskActionUpdated  = "updated"
+	TaskActionDeleted  = "deleted"
+	TaskActionRestored = "restored"
+)
+
+// Model: FieldChange, the value of a task field before and after an event
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Model: TaskEvent, an append-only history entry describing a change to a task
+type TaskEvent struct {
+	ID        int                    `json:"id"`
+	TaskId    int                    `json:"task_id"`
+	UserId    int                    `json:"user_id"`
+	ActorId   int                    `json:"actor_id"`
+	Action    string                 `json:"action"`
+	Changes   map[string]FieldChange `json:"changes"`
+	CreatedAt time.Time              `json:"created_at"`
+}
@@ -4,26 +4,40 @@ import (
 	"time"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID           int       `json:"id"`
 	Email        string    `json:"email"`
 	PasswordHash string    `json:"-"`
 	Name         string    `json:"name"`
+	Role         string    `json:"role"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// Passwords are capped at 72 bytes, bcrypt refuses longer ones. Characters outside
+// ASCII take up to 4 bytes, so the cap can be less than 72 characters.
 type RegisterUserRequest struct {
-	Email    string `json:"email" validate:"required, email"`
-	Password string `json:"password" validate:"required, min=8"`
-	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,maxbytes=72"`
+	Name     string `json:"name" validate:"required,max=100"`
 }
 
 type LoginUserRequest struct {
-	Email    string `json:"email" validate:"required, email"`
+	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,maxbytes=72"`
+}
+
 type AuthResponse struct {
 	User  User   `json:"user"`
 	Token string `json:"token"`
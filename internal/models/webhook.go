@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Delivery statuses. Dead deliveries exhausted their retries and are kept for inspection.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+// Model: WebhookSubscription, an endpoint that receives the task events of a user
+type WebhookSubscription struct {
+	ID         int       `json:"id"`
+	UserId     int       `json:"user_id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Model: CreateWebhookRequest, an empty event type list subscribes to every event
+// and an empty secret is generated by the server
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"dive,oneof=task.created task.updated task.completed task.deleted task.restored"`
+	Secret     string   `json:"secret" validate:"omitempty,max=255"`
+}
+
+// Model: WebhookDelivery, one queued attempt to send an event to a subscription
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	SubscriptionId int             `json:"subscription_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      string          `json:"last_error,omitempty"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,156 @@
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"task_API/internal/dto"
+	"task_API/internal/health"
+	"task_API/pkg/responses"
+)
+
+// unversioned lists the operations served without a version prefix
+var unversioned = []route{
+	{method: http.MethodGet, path: "/livez", id: "Livez", tag: "Health", summary: "Liveness probe, 503 when only a restart helps",
+		status: http.StatusOK, body: fixed(health.Report{}), failureStatus: http.StatusServiceUnavailable},
+	{method: http.MethodGet, path: "/readyz", id: "Readyz", tag: "Health", summary: "Readiness probe, 503 while a dependency is down or the API shuts down",
+		status: http.StatusOK, body: fixed(health.Report{}), failureStatus: http.StatusServiceUnavailable},
+	{method: http.MethodGet, path: "/health", id: "Health", tag: "Health", summary: "Alias of /readyz",
+		status: http.StatusOK, body: fixed(health.Report{}), failureStatus: http.StatusServiceUnavailable},
+	{method: http.MethodGet, path: "/metrics", id: "Metrics", tag: "Health", summary: "Prometheus metrics",
+		status: http.StatusOK, body: fixed(""), mediaType: "text/plain"},
+	{method: http.MethodGet, path: "/openapi.json", id: "OpenAPI", tag: "Docs", summary: "This document",
+		status: http.StatusOK, body: fixed(map[string]interface{}{})},
+	{method: http.MethodGet, path: "/docs", id: "Docs", tag: "Docs", summary: "Browsable documentation of this document",
+		status: http.StatusOK, body: fixed(""), mediaType: "text/html"},
+}
+
+var tags = []Tag{
+	{Name: "Auth", Description: "Registration and sign in, limited per client IP"},
+	{Name: "Profile", Description: "The signed in user"},
+	{Name: "Tasks", Description: "Tasks of the user and their history"},
+	{Name: "Events", Description: "Task events pushed as they happen"},
+	{Name: "Notifications", Description: "Reminders of due tasks"},
+	{Name: "Webhooks", Description: "Task events delivered to other services"},
+	{Name: "Admin", Description: "Requires the admin role"},
+	{Name: "Health", Description: "Probes and metrics"},
+	{Name: "Docs", Description: "Documentation of the API"},
+}
+
+var pathParameter = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Build returns the document of the API: every route under each version prefix, the
+// unversioned routes that answer like v1, deprecated, and the probes and docs
+func Build() *Document {
+	generator := newSchemas()
+	document := &Document{
+		OpenAPI: "3.1.0",
+		Info: Info{
+			Title:   "Task API",
+			Version: dto.Versions[len(dto.Versions)-1],
+			Description: "Tasks with history, reminders, realtime events and webhooks. Every route is served under " +
+				"/v1 and /v2, which differ in the timestamps of tasks; the unversioned routes answer like /v1 and " +
+				"are deprecated. Errors are problem details (RFC 7807) for clients that accept application/problem+json.",
+		},
+		Tags:  tags,
+		Paths: map[string]PathItem{},
+		Components: Components{
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Token returned by /register and /login"},
+				"mutualTLS":  {Type: "mutualTLS", Description: "Client certificate of a service account, see SERVER_TLS_CLIENT_ACCOUNTS"},
+			},
+		},
+	}
+
+	errorResponse := &Response{
+		Description: "Error",
+		Content: map[string]*MediaType{
+			"application/json":           {Schema: generator.response(responses.Response{})},
+			responses.ProblemContentType: {Schema: generator.response(responses.Problem{})},
+		},
+	}
+
+	for _, version := range dto.Versions {
+		for _, route := range routes {
+			document.add("/"+version+route.path, route.method, generator.operation(route, version, version, false, errorResponse))
+		}
+	}
+	for _, route := range routes {
+		document.add(route.path, route.method, generator.operation(route, "legacy", dto.V1, true, errorResponse))
+	}
+	for _, route := range unversioned {
+		document.add(route.path, route.method, generator.operation(route, "", "", false, nil))
+	}
+
+	document.Components.Schemas = generator.components
+	return document
+}
+
+func (document *Document) add(path string, method string, operation *Operation) {
+	item, ok := document.Paths[path]
+	if !ok {
+		item = PathItem{}
+		document.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = operation
+}
+
+// operation describes route as served for version. The operation id starts with prefix,
+// and deprecated operations announce their successor the way middleware.Deprecated does.
+func (generator *schemas) operation(route route, prefix string, version string, deprecated bool, errorResponse *Response) *Operation {
+	operation := &Operation{
+		OperationId: prefix + route.id,
+		Summary:     route.summary,
+		Tags:        []string{route.tag},
+		Deprecated:  deprecated,
+		Responses:   map[string]*Response{},
+	}
+
+	for _, match := range pathParameter.FindAllStringSubmatch(route.path, -1) {
+		operation.Parameters = append(operation.Parameters, &Parameter{Name: match[1], In: "path", Required: true, Schema: integerSchema})
+	}
+	operation.Parameters = append(operation.Parameters, route.params...)
+
+	if route.request != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: generator.request(route.request)}},
+		}
+	}
+
+	success := &Response{Description: http.StatusText(route.status)}
+	if route.body != nil {
+		mediaType := route.mediaType
+		if mediaType == "" {
+			mediaType = "application/json"
+		}
+		success.Content = map[string]*MediaType{mediaType: {Schema: route.body(generator, version)}}
+	}
+	if deprecated {
+		success.Headers = map[string]*Header{
+			"Deprecation": {Description: "When the route was deprecated, as @ and a Unix time", Schema: stringSchema},
+			"Sunset":      {Description: "When the route goes away", Schema: stringSchema},
+			"Link":        {Description: "The same route under /" + dto.V1, Schema: stringSchema},
+		}
+	}
+	operation.Responses[strconv.Itoa(route.status)] = success
+	if route.failureStatus != 0 {
+		operation.Responses[strconv.Itoa(route.failureStatus)] = &Response{
+			Description: http.StatusText(route.failureStatus),
+			Content:     success.Content,
+		}
+	}
+	if errorResponse != nil {
+		operation.Responses["default"] = errorResponse
+	}
+
+	if route.access != public {
+		operation.Security = []map[string][]string{{"bearerAuth": {}}, {"mutualTLS": {}}}
+	}
+	if route.access == admin {
+		operation.Description = "Requires the admin role."
+	}
+	return operation
+}
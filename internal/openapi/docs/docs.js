@@ -0,0 +1,205 @@
+"use strict";
+
+// Renders /openapi.json. Everything is built with DOM nodes and textContent, the page
+// runs under a policy that only allows this script.
+(function () {
+  var state = { document: null, version: "", search: "" };
+
+  function element(tag, className, children) {
+    var node = document.createElement(tag);
+    if (className) node.className = className;
+    (children || []).forEach(function (child) {
+      node.appendChild(typeof child === "string" ? document.createTextNode(child) : child);
+    });
+    return node;
+  }
+
+  function resolve(schema) {
+    var seen = 0;
+    while (schema && schema.$ref && seen++ < 10) {
+      schema = state.document.components.schemas[schema.$ref.split("/").pop()];
+    }
+    return schema || {};
+  }
+
+  function typeName(schema) {
+    if (schema.$ref) return schema.$ref.split("/").pop();
+    if (schema.anyOf) return schema.anyOf.map(typeName).join(" | ");
+    var type = Array.isArray(schema.type) ? schema.type.join(" | ") : schema.type || "any";
+    if (type === "array" && schema.items) return typeName(schema.items) + "[]";
+    if (schema.format) type += " (" + schema.format + ")";
+    return type;
+  }
+
+  function constraints(schema) {
+    var parts = [];
+    if (schema.enum) parts.push("one of " + schema.enum.join(", "));
+    if (schema.minLength != null) parts.push("min length " + schema.minLength);
+    if (schema.maxLength != null) parts.push("max length " + schema.maxLength);
+    if (schema.minimum != null) parts.push("≥ " + schema.minimum);
+    if (schema.maximum != null) parts.push("≤ " + schema.maximum);
+    if (schema.items && schema.items.minimum != null) parts.push("items ≥ " + schema.items.minimum);
+    if (schema.items && schema.items.enum) parts.push("items one of " + schema.items.enum.join(", "));
+    return parts.join("; ");
+  }
+
+  // renderSchema lists the properties of an object, and those of the objects within it
+  function renderSchema(schema, depth) {
+    var resolved = resolve(schema);
+    if (resolved.type === "array" && resolved.items) {
+      return element("div", "", [element("span", "muted", ["array of " + typeName(resolved.items)]), renderSchema(resolved.items, depth)]);
+    }
+    if (resolved.anyOf) {
+      return renderSchema(resolved.anyOf[0], depth);
+    }
+    if (!resolved.properties || depth > 4) {
+      return element("span", "muted", [typeName(schema)]);
+    }
+    var list = element("ul", "schema");
+    var required = resolved.required || [];
+    Object.keys(resolved.properties).forEach(function (name) {
+      var property = resolved.properties[name];
+      var item = element("li", "", [
+        element("strong", "", [name]),
+        required.indexOf(name) >= 0 ? element("span", "required", ["*"]) : "",
+        ": " + typeName(property),
+      ]);
+      var notes = [constraints(resolve(property)), property.description].filter(Boolean).join("; ");
+      if (notes) item.appendChild(element("span", "muted", [" — " + notes]));
+      var nested = resolve(property.items || (property.anyOf && property.anyOf[0]) || property);
+      if (nested.properties) item.appendChild(renderSchema(nested, depth + 1));
+      list.appendChild(item);
+    });
+    return list;
+  }
+
+  function renderContent(content) {
+    var wrapper = element("div");
+    Object.keys(content || {}).forEach(function (mediaType) {
+      wrapper.appendChild(element("div", "muted", [mediaType]));
+      if (content[mediaType].schema) wrapper.appendChild(renderSchema(content[mediaType].schema, 0));
+    });
+    return wrapper;
+  }
+
+  function renderOperation(path, method, operation) {
+    var summary = element("summary", "", [
+      element("span", "method " + method, [method]),
+      element("span", "path", [path]),
+      element("span", "muted", [operation.summary || ""]),
+    ]);
+    if (operation.deprecated) summary.appendChild(element("span", "badge", ["deprecated"]));
+    if (operation.security) summary.appendChild(element("span", "badge", ["auth"]));
+
+    var body = element("div", "body");
+    if (operation.description) body.appendChild(element("p", "", [operation.description]));
+
+    if (operation.parameters && operation.parameters.length) {
+      var table = element("table", "", [element("tr", "", [
+        element("th", "", ["Name"]), element("th", "", ["In"]), element("th", "", ["Type"]), element("th", "", ["Description"]),
+      ])]);
+      operation.parameters.forEach(function (parameter) {
+        table.appendChild(element("tr", "", [
+          element("td", "", [parameter.name, parameter.required ? element("span", "required", ["*"]) : ""]),
+          element("td", "", [parameter.in]),
+          element("td", "", [typeName(parameter.schema || {})]),
+          element("td", "", [[parameter.description, constraints(parameter.schema || {})].filter(Boolean).join("; ")]),
+        ]));
+      });
+      body.appendChild(element("h3", "", ["Parameters"]));
+      body.appendChild(table);
+    }
+
+    if (operation.requestBody) {
+      body.appendChild(element("h3", "", ["Request body"]));
+      body.appendChild(renderContent(operation.requestBody.content));
+    }
+
+    Object.keys(operation.responses).forEach(function (status) {
+      var response = operation.responses[status];
+      body.appendChild(element("h3", "", [status + " " + response.description]));
+      if (response.headers) {
+        body.appendChild(element("div", "muted", ["Headers: " + Object.keys(response.headers).join(", ")]));
+      }
+      body.appendChild(renderContent(response.content));
+    });
+
+    var details = element("details", operation.deprecated ? "deprecated" : "", [summary, body]);
+    details.id = operation.operationId;
+    return details;
+  }
+
+  // versionOf names the group of a path: its version prefix, or legacy and other for the
+  // unversioned routes
+  function versionOf(path, operation) {
+    var match = /^\/(v\d+)\//.exec(path);
+    if (match) return match[1];
+    return operation.deprecated ? "legacy" : "other";
+  }
+
+  function render() {
+    var spec = state.document;
+    var main = document.getElementById("operations");
+    main.textContent = "";
+
+    var byTag = {};
+    Object.keys(spec.paths).sort().forEach(function (path) {
+      Object.keys(spec.paths[path]).forEach(function (method) {
+        var operation = spec.paths[path][method];
+        var group = versionOf(path, operation);
+        if (group !== state.version && group !== "other") return;
+        var text = (path + " " + (operation.summary || "")).toLowerCase();
+        if (state.search && text.indexOf(state.search) < 0) return;
+        var tag = (operation.tags || ["Other"])[0];
+        (byTag[tag] = byTag[tag] || []).push(renderOperation(path, method, operation));
+      });
+    });
+
+    (spec.tags || []).map(function (tag) { return tag.name; }).forEach(function (tag) {
+      if (!byTag[tag]) return;
+      main.appendChild(element("h2", "", [tag]));
+      byTag[tag].forEach(function (node) { main.appendChild(node); });
+    });
+    if (!main.firstChild) main.appendChild(element("p", "muted", ["No operation matches."]));
+  }
+
+  function start(spec) {
+    state.document = spec;
+    document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+    document.getElementById("description").textContent = spec.info.description || "";
+
+    var versions = {};
+    Object.keys(spec.paths).forEach(function (path) {
+      Object.keys(spec.paths[path]).forEach(function (method) {
+        var group = versionOf(path, spec.paths[path][method]);
+        if (group !== "other") versions[group] = true;
+      });
+    });
+    var select = document.getElementById("version");
+    Object.keys(versions).sort().reverse().forEach(function (version) {
+      var option = element("option", "", [version === "legacy" ? "unversioned (deprecated)" : version]);
+      option.value = version;
+      select.appendChild(option);
+    });
+    state.version = select.value;
+
+    select.addEventListener("change", function () { state.version = select.value; render(); });
+    document.getElementById("search").addEventListener("input", function (event) {
+      state.search = event.target.value.trim().toLowerCase();
+      render();
+    });
+    render();
+  }
+
+  fetch("/openapi.json")
+    .then(function (response) {
+      if (!response.ok) throw new Error("openapi.json answered " + response.status);
+      return response.json();
+    })
+    .then(start)
+    .catch(function (error) {
+      var main = document.getElementById("operations");
+      main.textContent = "";
+      main.appendChild(element("p", "required", ["Failed to load the document: " + error.message]));
+    });
+})();
@@ -0,0 +1,95 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"task_API/internal/models"
+)
+
+func TestRequestSchemaFollowsValidateTags(t *testing.T) {
+	generator := newSchemas()
+	ref := generator.request(models.CreateWebhookRequest{})
+	if ref.Ref != "#/components/schemas/CreateWebhookRequest" {
+		t.Fatalf("ref = %q", ref.Ref)
+	}
+
+	schema := generator.components["CreateWebhookRequest"]
+	if !reflect.DeepEqual(schema.Required, []string{"url"}) {
+		t.Fatalf("required = %v, want [url]", schema.Required)
+	}
+	if got := schema.Properties["url"].Format; got != "uri" {
+		t.Fatalf("url format = %q, want uri", got)
+	}
+	if max := schema.Properties["secret"].MaxLength; max == nil || *max != 255 {
+		t.Fatalf("secret maxLength = %v, want 255", max)
+	}
+	// Rules after dive constrain the items
+	items := schema.Properties["event_types"].Items
+	if items == nil || len(items.Enum) != 5 || items.Enum[0] != "task.created" {
+		t.Fatalf("event_types items = %+v", items)
+	}
+}
+
+func TestResponseSchemaRequiresFieldsAlwaysEncoded(t *testing.T) {
+	generator := newSchemas()
+	generator.response(struct {
+		Entry models.AuditLog `json:"entry"`
+	}{})
+
+	schema := generator.components["AuditLog"]
+	required := strings.Join(schema.Required, ",")
+	if !strings.Contains(required, "user_id") || strings.Contains(required, "email") {
+		t.Fatalf("required = %v, want user_id but not the omitempty email", schema.Required)
+	}
+	// A pointer encoded without omitempty may be null
+	if got := schema.Properties["user_id"].Type; !reflect.DeepEqual(got, []string{"integer", "null"}) {
+		t.Fatalf("user_id type = %v", got)
+	}
+	if got := schema.Properties["created_at"]; got.Type != "string" || got.Format != "date-time" {
+		t.Fatalf("created_at = %+v", got)
+	}
+}
+
+func TestBuildResolvesEveryReference(t *testing.T) {
+	document := Build()
+	data, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, match := range strings.Split(string(data), `"$ref":"#/components/schemas/`)[1:] {
+		name := match[:strings.Index(match, `"`)]
+		if _, ok := document.Components.Schemas[name]; !ok {
+			t.Errorf("$ref to missing schema %s", name)
+		}
+	}
+
+	ids := map[string]bool{}
+	for path, item := range document.Paths {
+		for method, operation := range item {
+			if ids[operation.OperationId] {
+				t.Errorf("%s %s reuses operation id %s", method, path, operation.OperationId)
+			}
+			ids[operation.OperationId] = true
+		}
+	}
+}
+
+func TestDocsPagePolicyAllowsItsInlineCode(t *testing.T) {
+	page, policy := DocsPage()
+	html := string(page)
+
+	if !strings.Contains(html, "<script>"+docsScript+"</script>") || !strings.Contains(html, "<style>"+docsStyle+"</style>") {
+		t.Fatal("the script and the style are not inlined")
+	}
+	if !strings.Contains(policy, "script-src '"+inlineHash(docsScript)+"'") ||
+		!strings.Contains(policy, "style-src '"+inlineHash(docsStyle)+"'") {
+		t.Fatalf("policy = %q", policy)
+	}
+	if !strings.HasPrefix(policy, "default-src 'none'") {
+		t.Fatalf("policy = %q, want default-src 'none'", policy)
+	}
+}
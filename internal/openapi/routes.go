@@ -0,0 +1,163 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"task_API/internal/dto"
+	"task_API/internal/models"
+)
+
+type access int
+
+const (
+	public access = iota
+	signedIn
+	admin
+)
+
+// body returns the schema of the success response of a route for an API version
+type body func(generator *schemas, version string) *Schema
+
+// route describes an operation of the API, served under every version prefix
+type route struct {
+	method  string
+	path    string
+	id      string
+	tag     string
+	summary string
+	access  access
+	request interface{}
+	params  []*Parameter
+	status  int
+	body    body
+	// failureStatus is answered with the same body when the operation fails, if set
+	failureStatus int
+	// mediaType of the success response, application/json when empty
+	mediaType string
+}
+
+func fixed(value interface{}) body {
+	return func(generator *schemas, version string) *Schema {
+		return generator.response(value)
+	}
+}
+
+func taskBody(generator *schemas, version string) *Schema {
+	if version == dto.V2 {
+		return generator.response(dto.TaskV2{})
+	}
+	return generator.response(dto.TaskV1{})
+}
+
+func tasksBody(generator *schemas, version string) *Schema {
+	return &Schema{Type: "array", Items: taskBody(generator, version)}
+}
+
+// eventBody describes dto.Event, whose task follows the version and is null in reset
+// events
+func eventBody(generator *schemas, version string) *Schema {
+	name := "Event" + strings.ToUpper(version)
+	if _, ok := generator.components[name]; !ok {
+		event := generator.object(reflect.TypeOf(dto.Event{}), true)
+		event.Properties["task"] = &Schema{
+			Description: "The task after the change, null in reset events",
+			AnyOf:       []*Schema{taskBody(generator, version), {Type: "null"}},
+		}
+		generator.components[name] = event
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func query(name string, schema *Schema, description string) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+var (
+	integerSchema  = &Schema{Type: "integer"}
+	booleanSchema  = &Schema{Type: "boolean"}
+	stringSchema   = &Schema{Type: "string"}
+	dateTimeSchema = &Schema{Type: "string", Format: "date-time"}
+)
+
+// routes lists the operations of the API, in the order they are documented
+var routes = []route{
+	{method: http.MethodPost, path: "/register", id: "Register", tag: "Auth", summary: "Register a new user",
+		request: models.RegisterUserRequest{}, status: http.StatusCreated, body: fixed(dto.AuthResponse{})},
+	{method: http.MethodPost, path: "/login", id: "Login", tag: "Auth", summary: "Sign in and get a token",
+		request: models.LoginUserRequest{}, status: http.StatusOK, body: fixed(dto.AuthResponse{})},
+
+	{method: http.MethodGet, path: "/profile", id: "GetProfile", tag: "Profile", summary: "Get the profile of the user",
+		access: signedIn, status: http.StatusOK, body: fixed(dto.User{})},
+	{method: http.MethodPut, path: "/profile/password", id: "ChangePassword", tag: "Profile", summary: "Change the password of the user",
+		access: signedIn, request: models.ChangePasswordRequest{}, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/tasks", id: "GetAllTasks", tag: "Tasks", summary: "List the tasks of the user",
+		access: signedIn, status: http.StatusOK, body: tasksBody},
+	{method: http.MethodGet, path: "/tasks/{id}", id: "GetTask", tag: "Tasks", summary: "Get a task",
+		access: signedIn, status: http.StatusOK, body: taskBody},
+	{method: http.MethodPost, path: "/tasks", id: "CreateTask", tag: "Tasks", summary: "Create a task",
+		access: signedIn, request: models.CreateTaskRequest{}, status: http.StatusCreated, body: taskBody},
+	{method: http.MethodPut, path: "/tasks/{id}", id: "UpdateTask", tag: "Tasks", summary: "Update a task",
+		access: signedIn, request: models.UpdateTaskRequest{}, status: http.StatusOK, body: taskBody},
+	{method: http.MethodDelete, path: "/tasks/{id}", id: "DeleteTask", tag: "Tasks", summary: "Delete a task, it can be restored",
+		access: signedIn, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/tasks/{id}/restore", id: "RestoreTask", tag: "Tasks", summary: "Restore a deleted task",
+		access: signedIn, status: http.StatusOK, body: taskBody},
+	{method: http.MethodGet, path: "/tasks/{id}/history", id: "GetTaskHistory", tag: "Tasks", summary: "List the changes of a task",
+		access: signedIn, status: http.StatusOK, body: fixed([]dto.TaskEvent{})},
+	{method: http.MethodGet, path: "/tasks/{id}/snapshot", id: "GetTaskSnapshot", tag: "Tasks", summary: "Get a task as it was at a given time",
+		access: signedIn, status: http.StatusOK, body: taskBody,
+		params: []*Parameter{{Name: "at", In: "query", Required: true, Description: "RFC 3339 time", Schema: dateTimeSchema}}},
+
+	{method: http.MethodGet, path: "/events", id: "StreamEvents", tag: "Events", summary: "Stream the task events of the user as Server-Sent Events",
+		access: signedIn, status: http.StatusOK, body: eventBody, mediaType: "text/event-stream",
+		params: []*Parameter{
+			{Name: "Last-Event-ID", In: "header", Description: "Id of the last event received, to resume", Schema: integerSchema},
+			query("last_event_id", integerSchema, "Id of the last event received, for clients that cannot send headers"),
+		}},
+	{method: http.MethodGet, path: "/ws", id: "WebSocket", tag: "Events", summary: "Receive the task events of the user over a WebSocket",
+		access: signedIn, status: http.StatusSwitchingProtocols,
+		params: []*Parameter{query("last_event_id", integerSchema, "Id of the last event received, to resume")}},
+
+	{method: http.MethodGet, path: "/notifications", id: "GetNotifications", tag: "Notifications", summary: "List the notifications of the user",
+		access: signedIn, status: http.StatusOK, body: fixed([]dto.Notification{}),
+		params: []*Parameter{
+			query("unread", booleanSchema, "Only list unread notifications"),
+			query("limit", integerSchema, "Most notifications returned"),
+		}},
+	{method: http.MethodPost, path: "/notifications/read-all", id: "MarkAllRead", tag: "Notifications", summary: "Mark every notification as read",
+		access: signedIn, status: http.StatusOK, body: fixed(dto.MarkedNotifications{})},
+	{method: http.MethodPost, path: "/notifications/{id}/read", id: "MarkRead", tag: "Notifications", summary: "Mark a notification as read",
+		access: signedIn, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/webhooks", id: "GetWebhooks", tag: "Webhooks", summary: "List the webhook subscriptions of the user",
+		access: signedIn, status: http.StatusOK, body: fixed([]dto.WebhookSubscription{})},
+	{method: http.MethodPost, path: "/webhooks", id: "CreateWebhook", tag: "Webhooks", summary: "Subscribe a URL to task events",
+		access: signedIn, request: models.CreateWebhookRequest{}, status: http.StatusCreated, body: fixed(dto.WebhookSubscription{})},
+	{method: http.MethodDelete, path: "/webhooks/{id}", id: "DeleteWebhook", tag: "Webhooks", summary: "Delete a webhook subscription",
+		access: signedIn, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/webhooks/{id}/deliveries", id: "GetWebhookDeliveries", tag: "Webhooks", summary: "List the recent deliveries of a webhook",
+		access: signedIn, status: http.StatusOK, body: fixed([]dto.WebhookDelivery{})},
+	{method: http.MethodPost, path: "/webhooks/{id}/deliveries/{deliveryId}/retry", id: "RetryWebhookDelivery", tag: "Webhooks", summary: "Queue a dead delivery again, others are a conflict",
+		access: signedIn, status: http.StatusAccepted, body: fixed(dto.WebhookDelivery{})},
+
+	{method: http.MethodGet, path: "/admin/audit-logs", id: "GetAuditLogs", tag: "Admin", summary: "Search the audit log",
+		access: admin, status: http.StatusOK, body: fixed([]dto.AuditLog{}),
+		params: []*Parameter{
+			query("user_id", integerSchema, "Entries of a user"),
+			query("event", &Schema{Type: "string", Enum: []string{
+				models.AuditEventRegister, models.AuditEventLoginSuccess, models.AuditEventLoginFailure,
+				models.AuditEventTokenInvalid, models.AuditEventPasswordChange, models.AuditEventPermissionDenied,
+			}}, "Entries of an event"),
+			query("success", booleanSchema, "Successful or failed entries"),
+			query("ip", stringSchema, "Entries from a client address"),
+			query("since", dateTimeSchema, "Entries from this time"),
+			query("until", dateTimeSchema, "Entries before this time"),
+			query("before_id", integerSchema, "Entries before this id, to page backwards"),
+			query("limit", integerSchema, "Most entries returned"),
+		}},
+	{method: http.MethodGet, path: "/admin/audit-logs/verify", id: "VerifyAuditChain", tag: "Admin", summary: "Check the hash chain of the audit log",
+		access: admin, status: http.StatusOK, body: fixed(dto.AuditChainVerification{})},
+}
@@ -0,0 +1,191 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas derives schemas from Go types through their json and validate tags. Named
+// structs become components and are referenced with $ref.
+type schemas struct {
+	components map[string]*Schema
+	types      map[string]reflect.Type
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]*Schema{}, types: map[string]reflect.Type{}}
+}
+
+// request returns the schema of a request body, whose required fields are those the
+// validator requires
+func (generator *schemas) request(value interface{}) *Schema {
+	return generator.of(reflect.TypeOf(value), false)
+}
+
+// response returns the schema of a response body, whose required fields are those
+// always encoded, without omitempty
+func (generator *schemas) response(value interface{}) *Schema {
+	return generator.of(reflect.TypeOf(value), true)
+}
+
+func (generator *schemas) of(valueType reflect.Type, response bool) *Schema {
+	switch {
+	case valueType == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case valueType == rawMessageType:
+		// Any JSON value
+		return &Schema{}
+	}
+
+	switch valueType.Kind() {
+	case reflect.Pointer:
+		return generator.of(valueType.Elem(), response)
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: generator.of(valueType.Elem(), response)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: generator.of(valueType.Elem(), response)}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if valueType.Name() == "" {
+			return generator.object(valueType, response)
+		}
+		return generator.component(valueType, response)
+	}
+	panic(fmt.Sprintf("openapi: no schema for %s", valueType))
+}
+
+// component registers the schema of a named struct once and returns a reference to it
+func (generator *schemas) component(valueType reflect.Type, response bool) *Schema {
+	name := valueType.Name()
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if known, ok := generator.types[name]; ok {
+		if known != valueType {
+			panic(fmt.Sprintf("openapi: %s and %s are both named %s", known, valueType, name))
+		}
+		return ref
+	}
+
+	// Registered before its fields, a type may refer to itself
+	generator.types[name] = valueType
+	generator.components[name] = generator.object(valueType, response)
+	return ref
+}
+
+func (generator *schemas) object(valueType reflect.Type, response bool) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for index := 0; index < valueType.NumField(); index++ {
+		field := valueType.Field(index)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		omitempty := strings.Contains(options, "omitempty")
+
+		property := generator.of(field.Type, response)
+		required := applyRules(property, field.Type, field.Tag.Get("validate"))
+		if response {
+			required = !omitempty
+			// Pointers without omitempty are encoded as null
+			if field.Type.Kind() == reflect.Pointer && !omitempty {
+				nullable(property)
+			}
+		}
+
+		schema.Properties[name] = property
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// nullable lets schema also be null
+func nullable(schema *Schema) {
+	if kind, ok := schema.Type.(string); ok {
+		schema.Type = []string{kind, "null"}
+	}
+}
+
+// applyRules adds the constraints of a validate tag to schema and reports whether the
+// field is required. Rules after dive apply to the items of a list.
+func applyRules(schema *Schema, valueType reflect.Type, tag string) bool {
+	required := false
+	for valueType.Kind() == reflect.Pointer {
+		valueType = valueType.Elem()
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		rule, param, _ := strings.Cut(rule, "=")
+		switch rule {
+		case "required":
+			required = true
+		case "dive":
+			if schema.Items == nil {
+				return required
+			}
+			schema, valueType = schema.Items, valueType.Elem()
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "min", "max":
+			bound, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			applyBound(schema, valueType, rule == "min", bound)
+		}
+	}
+	return required
+}
+
+func applyBound(schema *Schema, valueType reflect.Type, min bool, bound int) {
+	switch valueType.Kind() {
+	case reflect.String:
+		if min {
+			schema.MinLength = &bound
+		} else {
+			schema.MaxLength = &bound
+		}
+	case reflect.Slice, reflect.Array:
+		if min {
+			schema.MinItems = &bound
+		} else {
+			schema.MaxItems = &bound
+		}
+	default:
+		value := float64(bound)
+		if min {
+			schema.Minimum = &value
+		} else {
+			schema.Maximum = &value
+		}
+	}
+}
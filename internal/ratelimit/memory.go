@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the memory store forgets the buckets that are full
+const sweepInterval = time.Minute
+
+// MemoryStore keeps the buckets of a single instance
+type MemoryStore struct {
+	mutex     sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tats: make(map[string]time.Time)}
+}
+
+func (store *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.sweep(now)
+	tat, result := limit.take(store.tats[key], now)
+	store.tats[key] = tat
+	return result, nil
+}
+
+// sweep drops the buckets whose TAT has passed, they are as good as new
+func (store *MemoryStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < sweepInterval {
+		return
+	}
+	store.lastSweep = now
+
+	for key, tat := range store.tats {
+		if tat.Before(now) {
+			delete(store.tats, key)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// PostgresStore keeps the buckets in the rate_limits table, shared by every instance
+type PostgresStore struct {
+	database *sql.DB
+	// lastSweep is when this instance last swept, in Unix nanoseconds
+	lastSweep atomic.Int64
+}
+
+func NewPostgresStore(database *sql.DB) *PostgresStore {
+	return &PostgresStore{database: database}
+}
+
+func (store *PostgresStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (result Result, err error) {
+	tx, err := store.database.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// The no-op update locks the row, so concurrent requests of the client queue up
+	// here, and creates it on the first request
+	var tat time.Time
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO rate_limits (key, tat) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET key = EXCLUDED.key
+		RETURNING tat`, key, now).Scan(&tat)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to load rate limit: %w", err)
+	}
+
+	next, result := limit.take(tat, now)
+	if result.Allowed {
+		if _, err = tx.ExecContext(ctx, `UPDATE rate_limits SET tat = $2 WHERE key = $1`, key, next); err != nil {
+			return Result{}, fmt.Errorf("failed to update rate limit: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return Result{}, fmt.Errorf("failed to commit rate limit: %w", err)
+	}
+
+	store.sweep(ctx, now)
+	return result, nil
+}
+
+// sweep deletes the buckets whose TAT has passed. A failure is left to the next sweep.
+func (store *PostgresStore) sweep(ctx context.Context, now time.Time) {
+	last := store.lastSweep.Load()
+	if now.UnixNano()-last < int64(sweepInterval) || !store.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	store.database.ExecContext(ctx, `DELETE FROM rate_limits WHERE tat < $1`, now)
+}
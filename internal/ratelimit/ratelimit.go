@@ -0,0 +1,112 @@
+// Package ratelimit limits how often a client may call the API. Every client has a
+// token bucket per route class, kept by a Store: in memory for a single instance, or
+// in Postgres so that instances share their buckets.
+//
+// Buckets are implemented with the generic cell rate algorithm, which keeps a single
+// timestamp per bucket, the theoretical arrival time (TAT): the moment the bucket will
+// be full again. A request is allowed when taking its token leaves the TAT no further
+// than one period ahead.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Route classes
+const (
+	// ClassAuth holds the registration and login routes, and the requests turned away
+	// for bad credentials, which are keyed by client IP
+	ClassAuth = "auth"
+	// ClassRead holds the GET routes
+	ClassRead = "read"
+	// ClassWrite holds the routes that change data
+	ClassWrite = "write"
+)
+
+// Limit allows Requests per Period, all at once at most
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Unlimited reports whether the limit lets every request through
+func (limit Limit) Unlimited() bool {
+	return limit.Requests <= 0 || limit.Period <= 0
+}
+
+// interval is the time it takes to get a token back
+func (limit Limit) interval() time.Duration {
+	return limit.Period / time.Duration(limit.Requests)
+}
+
+// take takes a token of the bucket whose TAT is tat and returns the new TAT, which is
+// tat itself when the request is denied
+func (limit Limit) take(tat time.Time, now time.Time) (time.Time, Result) {
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(limit.interval())
+
+	if ahead := next.Sub(now); ahead > limit.Period {
+		return tat, Result{
+			Limit:      limit,
+			Allowed:    false,
+			Remaining:  0,
+			Reset:      tat.Sub(now),
+			RetryAfter: ahead - limit.Period,
+		}
+	}
+
+	return next, Result{
+		Limit:     limit,
+		Allowed:   true,
+		Remaining: int((limit.Period - next.Sub(now)) / limit.interval()),
+		Reset:     next.Sub(now),
+	}
+}
+
+// Result is the state of a bucket after a request
+type Result struct {
+	Limit   Limit
+	Allowed bool
+	// Remaining is how many more requests the bucket allows right now
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until a denied request would be allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. Take takes a token of the bucket of key, which is created
+// full, and has to be atomic for concurrent requests with the same key.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// Limiter applies the limit of a route class to the requests of a client
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+	now    func() time.Time
+}
+
+func NewLimiter(store Store, limits map[string]Limit) *Limiter {
+	return &Limiter{store: store, limits: limits, now: time.Now}
+}
+
+// Allow takes a token of the bucket the client has for the class. It returns false
+// for ok when the class is unlimited.
+func (limiter *Limiter) Allow(ctx context.Context, class string, client string) (result Result, ok bool, err error) {
+	limit, found := limiter.limits[class]
+	if !found || limit.Unlimited() {
+		return Result{}, false, nil
+	}
+
+	result, err = limiter.store.Take(ctx, class+":"+client, limit, limiter.now())
+	if err != nil {
+		return Result{}, false, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	return result, true, nil
+}
@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	dbmigrations "task_API/db/migrations"
+	"task_API/internal/storage/migrate"
+	"task_API/pkg/logger"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+var start = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func takeAll(t *testing.T, store Store, key string, limit Limit, now time.Time, count int) []Result {
+	t.Helper()
+	results := make([]Result, count)
+	for i := range results {
+		result, err := store.Take(context.Background(), key, limit, now)
+		if err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+		results[i] = result
+	}
+	return results
+}
+
+// testStore checks the bucket behaviour every store has to implement
+func testStore(t *testing.T, store Store) {
+	limit := Limit{Requests: 3, Period: time.Minute}
+
+	results := takeAll(t, store, "user:1", limit, start, 4)
+	for i, want := range []int{2, 1, 0} {
+		if !results[i].Allowed || results[i].Remaining != want {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i+1, results[i], want)
+		}
+	}
+	denied := results[3]
+	if denied.Allowed || denied.RetryAfter != 20*time.Second || denied.Reset != time.Minute {
+		t.Fatalf("request 4 = %+v, want denied for 20s", denied)
+	}
+
+	// Another client has its own bucket
+	if other := takeAll(t, store, "user:2", limit, start, 1)[0]; !other.Allowed || other.Remaining != 2 {
+		t.Fatalf("other client = %+v, want a full bucket", other)
+	}
+
+	// A token comes back every 20 seconds
+	if later := takeAll(t, store, "user:1", limit, start.Add(20*time.Second), 2); !later[0].Allowed || later[1].Allowed {
+		t.Fatalf("after 20s = %+v, want a single request allowed", later)
+	}
+	if full := takeAll(t, store, "user:1", limit, start.Add(5*time.Minute), 1)[0]; full.Remaining != 2 {
+		t.Fatalf("after 5m = %+v, want a full bucket", full)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestMemoryStoreForgetsFullBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 10, Period: time.Second}
+	takeAll(t, store, "ip:10.0.0.1", limit, start, 1)
+	takeAll(t, store, "ip:10.0.0.2", limit, start.Add(sweepInterval), 1)
+
+	if _, ok := store.tats["ip:10.0.0.1"]; ok || len(store.tats) != 1 {
+		t.Fatalf("buckets = %v, want the full one dropped", store.tats)
+	}
+}
+
+func TestMemoryStoreIsAtomic(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 50, Period: time.Hour}
+
+	var wait sync.WaitGroup
+	var mutex sync.Mutex
+	allowed := 0
+	for i := 0; i < 100; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			result, _ := store.Take(context.Background(), "user:1", limit, start)
+			if result.Allowed {
+				mutex.Lock()
+				allowed++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wait.Wait()
+
+	if allowed != 50 {
+		t.Fatalf("allowed = %d, want 50", allowed)
+	}
+}
+
+func TestLimiterSkipsUnlimitedClasses(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), map[string]Limit{
+		ClassAuth: {Requests: 1, Period: time.Minute},
+		ClassRead: {Requests: 0, Period: time.Minute},
+	})
+
+	if _, limited, _ := limiter.Allow(context.Background(), ClassRead, "user:1"); limited {
+		t.Fatal("expected the read class to be unlimited")
+	}
+	if _, limited, _ := limiter.Allow(context.Background(), ClassWrite, "user:1"); limited {
+		t.Fatal("expected a class without a limit to be unlimited")
+	}
+
+	first, _, _ := limiter.Allow(context.Background(), ClassAuth, "ip:10.0.0.1")
+	second, _, _ := limiter.Allow(context.Background(), ClassAuth, "ip:10.0.0.1")
+	if !first.Allowed || second.Allowed {
+		t.Fatalf("auth = %+v, %+v, want the second request denied", first, second)
+	}
+}
+
+// The Postgres store needs a disposable database, see TEST_POSTGRES_DSN in
+// internal/storage/postgres
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	database, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	migrations, err := migrate.Load(dbmigrations.Postgres())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, err := migrate.NewMigrator(database, migrate.Postgres, migrations, logger.NewLogger("disabled", "json")).Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if _, err := database.Exec(`TRUNCATE rate_limits`); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+
+	testStore(t, NewPostgresStore(database))
+}
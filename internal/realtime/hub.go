@@ -0,0 +1,133 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+
+	"task_API/internal/events"
+	"task_API/pkg/logger"
+)
+
+// ResetEvent tells a client that it missed too many events to catch up and has to reload its tasks
+const ResetEvent = "reset"
+
+// Broker forwards events between instances of the API
+type Broker interface {
+	Publish(event events.Event) error
+	Listen(ctx context.Context, deliver func(event events.Event))
+}
+
+// Subscriber receives the live events of one user on one connection. A subscriber that
+// does not keep up is dropped instead of slowing down everybody else.
+type Subscriber struct {
+	userId  int
+	events  chan events.Event
+	dropped chan struct{}
+	once    sync.Once
+}
+
+func (subscriber *Subscriber) Events() <-chan events.Event {
+	return subscriber.events
+}
+
+// Dropped is closed when the subscriber's buffer overflowed
+func (subscriber *Subscriber) Dropped() <-chan struct{} {
+	return subscriber.dropped
+}
+
+func (subscriber *Subscriber) drop() {
+	subscriber.once.Do(func() { close(subscriber.dropped) })
+}
+
+// Hub fans task events out to the connections of their owner
+type Hub struct {
+	mutex       sync.RWMutex
+	subscribers map[int]map[*Subscriber]struct{}
+	bufferSize  int
+	broker      Broker
+	logger      *logger.Logger
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewHub creates a hub. broker may be nil when a single instance is running.
+func NewHub(bufferSize int, broker Broker, appLogger *logger.Logger) *Hub {
+	return &Hub{
+		subscribers: make(map[int]map[*Subscriber]struct{}),
+		bufferSize:  bufferSize,
+		broker:      broker,
+		logger:      appLogger,
+		done:        make(chan struct{}),
+	}
+}
+
+// Close tells every connection to end, for the API is shutting down. Clients reconnect
+// to another instance and resume from their last event.
+func (hub *Hub) Close() {
+	hub.closeOnce.Do(func() { close(hub.done) })
+}
+
+// Done is closed when the hub is closed
+func (hub *Hub) Done() <-chan struct{} {
+	return hub.done
+}
+
+func (hub *Hub) Subscribe(userId int) *Subscriber {
+	subscriber := &Subscriber{
+		userId:  userId,
+		events:  make(chan events.Event, hub.bufferSize),
+		dropped: make(chan struct{}),
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	if hub.subscribers[userId] == nil {
+		hub.subscribers[userId] = make(map[*Subscriber]struct{})
+	}
+	hub.subscribers[userId][subscriber] = struct{}{}
+	return subscriber
+}
+
+func (hub *Hub) Unsubscribe(subscriber *Subscriber) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	delete(hub.subscribers[subscriber.userId], subscriber)
+	if len(hub.subscribers[subscriber.userId]) == 0 {
+		delete(hub.subscribers, subscriber.userId)
+	}
+}
+
+// Publish delivers the event to local connections and forwards it to the other instances
+func (hub *Hub) Publish(event events.Event) {
+	hub.deliver(event)
+
+	if hub.broker != nil {
+		if err := hub.broker.Publish(event); err != nil {
+			hub.logger.Error().Err(err).Int("event_id", event.ID).Msg("failed to forward realtime event")
+		}
+	}
+}
+
+// Run receives the events published by other instances until the context is cancelled
+func (hub *Hub) Run(ctx context.Context) {
+	if hub.broker == nil {
+		<-ctx.Done()
+		return
+	}
+	hub.broker.Listen(ctx, hub.deliver)
+}
+
+func (hub *Hub) deliver(event events.Event) {
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+
+	for subscriber := range hub.subscribers[event.UserId] {
+		select {
+		case subscriber.events <- event:
+		default:
+			subscriber.drop()
+		}
+	}
+}
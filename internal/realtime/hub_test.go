@@ -0,0 +1,51 @@
+package realtime
+
+import (
+	"testing"
+
+	"task_API/internal/events"
+	"task_API/pkg/logger"
+)
+
+func TestHubDeliversOnlyToOwner(t *testing.T) {
+	hub := NewHub(4, nil, logger.NewLogger("disabled", "json"))
+	owner := hub.Subscribe(1)
+	other := hub.Subscribe(2)
+
+	hub.Publish(events.Event{ID: 7, Type: events.TaskCreated, UserId: 1})
+
+	select {
+	case event := <-owner.Events():
+		if event.ID != 7 {
+			t.Errorf("event id = %d, want 7", event.ID)
+		}
+	default:
+		t.Fatal("owner did not receive the event")
+	}
+
+	select {
+	case event := <-other.Events():
+		t.Errorf("other user received event %d", event.ID)
+	default:
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub(2, nil, logger.NewLogger("disabled", "json"))
+	slow := hub.Subscribe(1)
+
+	for id := 1; id <= 3; id++ {
+		hub.Publish(events.Event{ID: id, Type: events.TaskUpdated, UserId: 1})
+	}
+
+	select {
+	case <-slow.Dropped():
+	default:
+		t.Fatal("subscriber with a full buffer was not dropped")
+	}
+
+	hub.Unsubscribe(slow)
+	if len(hub.subscribers) != 0 {
+		t.Errorf("hub still tracks %d users after unsubscribe", len(hub.subscribers))
+	}
+}
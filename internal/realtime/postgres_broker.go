@@ -0,0 +1,106 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"task_API/internal/events"
+	"task_API/pkg/logger"
+	"task_API/pkg/utils"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	notifyChannel       = "task_events"
+	listenRetryInterval = 5 * time.Second
+)
+
+// notification is the NOTIFY payload. Origin lets an instance skip its own events,
+// which it already delivered locally.
+type notification struct {
+	Origin string       `json:"origin"`
+	Event  events.Event `json:"event"`
+}
+
+// PostgresBroker shares events between instances with Postgres LISTEN/NOTIFY
+type PostgresBroker struct {
+	database   *sql.DB
+	connString string
+	origin     string
+	logger     *logger.Logger
+}
+
+func NewPostgresBroker(database *sql.DB, connString string, appLogger *logger.Logger) (*PostgresBroker, error) {
+	origin, err := utils.GenerateSecret(8)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate instance id: %w", err)
+	}
+
+	return &PostgresBroker{
+		database:   database,
+		connString: connString,
+		origin:     origin,
+		logger:     appLogger,
+	}, nil
+}
+
+func (broker *PostgresBroker) Publish(event events.Event) error {
+	payload, err := json.Marshal(notification{Origin: broker.origin, Event: event})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	if _, err := broker.database.Exec(`SELECT pg_notify($1, $2)`, notifyChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to notify: %w", err)
+	}
+	return nil
+}
+
+// Listen holds a dedicated connection for LISTEN and reconnects when it is lost
+func (broker *PostgresBroker) Listen(ctx context.Context, deliver func(event events.Event)) {
+	for {
+		err := broker.listen(ctx, deliver)
+		if ctx.Err() != nil {
+			return
+		}
+		broker.logger.Error().Err(err).Msg("realtime listener disconnected, reconnecting")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryInterval):
+		}
+	}
+}
+
+func (broker *PostgresBroker) listen(ctx context.Context, deliver func(event events.Event)) error {
+	conn, err := pgx.Connect(ctx, broker.connString)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{notifyChannel}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	for {
+		received, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var message notification
+		if err := json.Unmarshal([]byte(received.Payload), &message); err != nil {
+			broker.logger.Error().Err(err).Msg("invalid realtime notification")
+			continue
+		}
+		if message.Origin != broker.origin {
+			deliver(message.Event)
+		}
+	}
+}
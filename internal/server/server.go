@@ -0,0 +1,220 @@
+// Package server runs the HTTP server of the API together with its background workers,
+// and stops them in order when the API shuts down.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"task_API/internal/config"
+	"task_API/pkg/logger"
+)
+
+type worker struct {
+	name   string
+	run    func(ctx context.Context)
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type closer struct {
+	name  string
+	close func() error
+}
+
+// Server owns the http.Server, the background workers and the resources they share
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+	beforeShutdown  []func()
+	workers         []*worker
+	closers         []closer
+	logger          *logger.Logger
+	addr            atomic.Value
+	// cfg holds the certificate settings, HTTPS is served when it names a certificate
+	cfg config.ServerConfig
+}
+
+// New builds a server for handler from the server config
+func New(cfg config.ServerConfig, handler http.Handler, appLogger *logger.Logger) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              ":" + cfg.Port,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.ReadTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+		shutdownDelay:   cfg.ShutdownDelay,
+		logger:          appLogger,
+		cfg:             cfg,
+	}
+}
+
+// Go adds a background worker, run until shutdown once the server starts. Workers
+// are stopped one after another in the order they were added, after the last request
+// was answered.
+func (server *Server) Go(name string, run func(ctx context.Context)) {
+	server.workers = append(server.workers, &worker{name: name, run: run})
+}
+
+// BeforeShutdown registers fn to run when the shutdown signal arrives, the shutdown
+// delay before the server stops accepting connections. Readiness turns false there,
+// so load balancers stop sending traffic first.
+func (server *Server) BeforeShutdown(fn func()) {
+	server.beforeShutdown = append(server.beforeShutdown, fn)
+}
+
+// OnShutdown registers fn to run as soon as shutdown begins. Connections that stay
+// open, such as event streams, use it to end themselves instead of holding the drain.
+func (server *Server) OnShutdown(fn func()) {
+	server.httpServer.RegisterOnShutdown(fn)
+}
+
+// AddCloser registers a resource closed once every worker stopped. Closers run in
+// reverse order, so what was opened first is closed last.
+func (server *Server) AddCloser(name string, close func() error) {
+	server.closers = append(server.closers, closer{name: name, close: close})
+}
+
+// Run serves requests until ctx is cancelled or the server fails, then shuts down:
+// it stops accepting connections, waits for the requests in flight, stops the workers
+// and closes the resources, all within the shutdown timeout. With a certificate it
+// serves HTTPS and HTTP/2, and reloads the certificate files when they change.
+func (server *Server) Run(ctx context.Context) error {
+	useTLS := server.cfg.TLSCertFile != "" || server.cfg.TLSKeyFile != ""
+	if useTLS {
+		if err := server.setupTLS(); err != nil {
+			server.close()
+			return err
+		}
+	}
+
+	listener, err := net.Listen("tcp", server.httpServer.Addr)
+	if err != nil {
+		server.close()
+		return fmt.Errorf("failed to listen on %s: %w", server.httpServer.Addr, err)
+	}
+
+	server.addr.Store(listener.Addr().String())
+
+	for _, worker := range server.workers {
+		server.start(worker)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		if useTLS {
+			// The certificate comes from TLSConfig, ServeTLS adds HTTP/2 to it
+			served <- server.httpServer.ServeTLS(listener, "", "")
+			return
+		}
+		served <- server.httpServer.Serve(listener)
+	}()
+	server.logger.Info().Str("addr", listener.Addr().String()).Bool("tls", useTLS).Msg("Server running")
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		server.logger.Info().Dur("delay", server.shutdownDelay).Dur("timeout", server.shutdownTimeout).Msg("Shutting down")
+		for _, fn := range server.beforeShutdown {
+			fn()
+		}
+		time.Sleep(server.shutdownDelay)
+	case err := <-served:
+		serveErr = fmt.Errorf("server failed: %w", err)
+	}
+
+	return errors.Join(serveErr, server.shutdown())
+}
+
+func (server *Server) setupTLS() error {
+	reloader, err := newCertReloader(server.cfg, server.logger)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := reloader.tlsConfig(server.cfg.TLSClientAuth)
+	if err != nil {
+		return err
+	}
+
+	server.httpServer.TLSConfig = tlsConfig
+	if interval := server.cfg.TLSReloadInterval; interval > 0 {
+		server.Go("tls reloader", func(ctx context.Context) { reloader.run(ctx, interval) })
+	}
+	return nil
+}
+
+// Addr returns the address the server listens on, empty until it listens
+func (server *Server) Addr() string {
+	addr, _ := server.addr.Load().(string)
+	return addr
+}
+
+// CheckWorkers fails when a worker stopped while the server is running. It is a
+// health.Checker.
+func (server *Server) CheckWorkers(ctx context.Context) error {
+	for _, worker := range server.workers {
+		if worker.done == nil {
+			continue
+		}
+		select {
+		case <-worker.done:
+			return fmt.Errorf("worker %s stopped", worker.name)
+		default:
+		}
+	}
+	return nil
+}
+
+func (server *Server) start(worker *worker) {
+	ctx, cancel := context.WithCancel(context.Background())
+	worker.cancel = cancel
+	worker.done = make(chan struct{})
+	go func() {
+		defer close(worker.done)
+		worker.run(ctx)
+	}()
+}
+
+func (server *Server) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), server.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	if err := server.httpServer.Shutdown(ctx); err != nil {
+		// Requests still running past the deadline are cut off
+		server.httpServer.Close()
+		errs = append(errs, fmt.Errorf("failed to drain connections: %w", err))
+	}
+
+	for _, worker := range server.workers {
+		worker.cancel()
+		select {
+		case <-worker.done:
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("worker %s did not stop in time", worker.name))
+		}
+	}
+
+	return errors.Join(append(errs, server.close())...)
+}
+
+func (server *Server) close() error {
+	var errs []error
+	for index := len(server.closers) - 1; index >= 0; index-- {
+		closer := server.closers[index]
+		if err := closer.close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s: %w", closer.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"task_API/internal/config"
+	"task_API/pkg/logger"
+)
+
+// recorder collects the steps of a shutdown in the order they happen
+type recorder struct {
+	mutex sync.Mutex
+	steps []string
+}
+
+func (rec *recorder) add(step string) {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	rec.steps = append(rec.steps, step)
+}
+
+func (rec *recorder) get() []string {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	return append([]string(nil), rec.steps...)
+}
+
+func newTestServer(handler http.Handler, shutdownTimeout time.Duration) *Server {
+	cfg := config.ServerConfig{
+		Port:            "0",
+		ReadTimeout:     time.Second,
+		WriteTimeout:    time.Second,
+		IdleTimeout:     time.Second,
+		ShutdownTimeout: shutdownTimeout,
+	}
+	return New(cfg, handler, logger.NewLogger("error", "json"))
+}
+
+func TestRunDrainsRequestsThenStopsWorkersAndClosesInOrder(t *testing.T) {
+	rec := &recorder{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		close(started)
+		<-release
+		rec.add("request answered")
+		io.WriteString(writer, "done")
+	})
+
+	srv := newTestServer(handler, 5*time.Second)
+	for _, name := range []string{"first", "second"} {
+		srv.Go(name, func(ctx context.Context) {
+			<-ctx.Done()
+			rec.add(name + " stopped")
+		})
+	}
+	srv.OnShutdown(func() { rec.add("shutdown began") })
+	srv.AddCloser("database", func() error { rec.add("database closed"); return nil })
+	srv.AddCloser("cache", func() error { rec.add("cache closed"); return nil })
+
+	// The listener address is only known once Run listens
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan error, 1)
+	go func() { ran <- srv.Run(ctx) }()
+	addr := waitForAddr(t, srv)
+
+	response := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		response <- string(body)
+	}()
+
+	<-started
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if body := <-response; body != "done" {
+		t.Fatalf("in-flight request got %q, want it answered", body)
+	}
+	if err := <-ran; err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+
+	want := []string{"shutdown began", "request answered", "first stopped", "second stopped", "cache closed", "database closed"}
+	if got := rec.get(); strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("steps = %v, want %v", got, want)
+	}
+}
+
+func TestRunReportsWorkersThatOutliveTheDeadline(t *testing.T) {
+	srv := newTestServer(http.NotFoundHandler(), 50*time.Millisecond)
+	stuck := make(chan struct{})
+	defer close(stuck)
+	srv.Go("stuck", func(ctx context.Context) { <-stuck })
+
+	closed := false
+	srv.AddCloser("database", func() error { closed = true; return errors.New("already closed") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := srv.Run(ctx)
+
+	if err == nil || !strings.Contains(err.Error(), "worker stuck did not stop in time") || !strings.Contains(err.Error(), "failed to close database") {
+		t.Fatalf("Run returned %v", err)
+	}
+	if !closed {
+		t.Fatal("expected the database to be closed even after a worker timed out")
+	}
+}
+
+func waitForAddr(t *testing.T, srv *Server) string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if addr := srv.Addr(); addr != "" {
+			return addr
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("server did not start listening")
+	return ""
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"task_API/internal/config"
+	"task_API/pkg/logger"
+)
+
+// Client certificate modes
+const (
+	// ClientAuthRequest verifies a client certificate when the client sends one
+	ClientAuthRequest = "request"
+	// ClientAuthRequire refuses clients without a valid certificate
+	ClientAuthRequire = "require"
+)
+
+// certReloader serves the certificate and client CA bundle of the files, loading them
+// again when they change so that renewed certificates are picked up without a restart.
+// A change that fails to load keeps the previous files in use.
+type certReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	logger       *logger.Logger
+
+	certificate atomic.Pointer[tls.Certificate]
+	clientCAs   atomic.Pointer[x509.CertPool]
+	// modTimes are those of the files last loaded, only touched by load
+	modTimes map[string]time.Time
+}
+
+func newCertReloader(cfg config.ServerConfig, appLogger *logger.Logger) (*certReloader, error) {
+	if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+		return nil, errors.New("TLS needs both a certificate and a key file")
+	}
+
+	reloader := &certReloader{
+		certFile:     cfg.TLSCertFile,
+		keyFile:      cfg.TLSKeyFile,
+		clientCAFile: cfg.TLSClientCAFile,
+		logger:       appLogger,
+		modTimes:     make(map[string]time.Time),
+	}
+	if _, err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// load reads the files again when one of them changed, reporting whether it did
+func (reloader *certReloader) load() (bool, error) {
+	files := []string{reloader.certFile, reloader.keyFile}
+	if reloader.clientCAFile != "" {
+		files = append(files, reloader.clientCAFile)
+	}
+
+	modTimes := make(map[string]time.Time, len(files))
+	changed := false
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		modTimes[file] = info.ModTime()
+		changed = changed || !info.ModTime().Equal(reloader.modTimes[file])
+	}
+	if !changed {
+		return false, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if reloader.clientCAFile != "" {
+		bundle, err := os.ReadFile(reloader.clientCAFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to read client CA bundle: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(bundle) {
+			return false, fmt.Errorf("no certificates in client CA bundle %s", reloader.clientCAFile)
+		}
+	}
+
+	reloader.certificate.Store(&certificate)
+	reloader.clientCAs.Store(clientCAs)
+	reloader.modTimes = modTimes
+	return true, nil
+}
+
+// run checks the files every interval until ctx is cancelled
+func (reloader *certReloader) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := reloader.load()
+			if err != nil {
+				reloader.logger.Error().Err(err).Msg("failed to reload TLS certificates, keeping the previous ones")
+			} else if reloaded {
+				reloader.logger.Info().Str("cert", reloader.certFile).Msg("TLS certificates reloaded")
+			}
+		}
+	}
+}
+
+// tlsConfig serves the current certificate. Client certificates are checked against
+// the current CA bundle here rather than by crypto/tls, which would keep the bundle
+// it was configured with.
+func (reloader *certReloader) tlsConfig(clientAuth string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return reloader.certificate.Load(), nil
+		},
+	}
+	if reloader.clientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	switch clientAuth {
+	case ClientAuthRequest:
+		tlsConfig.ClientAuth = tls.RequestClientCert
+	case ClientAuthRequire:
+		tlsConfig.ClientAuth = tls.RequireAnyClientCert
+	default:
+		return nil, fmt.Errorf("unknown client certificate mode %q", clientAuth)
+	}
+	tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			// Only reached in request mode, require refuses the handshake before
+			return nil
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, certificate := range state.PeerCertificates[1:] {
+			intermediates.AddCert(certificate)
+		}
+		_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+			Roots:         reloader.clientCAs.Load(),
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		if err != nil {
+			return fmt.Errorf("invalid client certificate: %w", err)
+		}
+		return nil
+	}
+	return tlsConfig, nil
+}
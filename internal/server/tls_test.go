@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"task_API/internal/config"
+	"task_API/pkg/logger"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+// issue creates a certificate signed by parent, or a self-signed CA without parent
+func issue(t *testing.T, template *x509.Certificate, parent *testCertificate) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.certificate, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return &testCertificate{certificate: certificate, key: key}
+}
+
+func newTestCA(t *testing.T) *testCertificate {
+	return issue(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+}
+
+func newServerCertificate(t *testing.T, ca *testCertificate) *testCertificate {
+	return issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+}
+
+func writePEM(t *testing.T, path string, blockType string, data []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("chtimes %s: %v", path, err)
+	}
+}
+
+func writeKeyPair(t *testing.T, dir string, certificate *testCertificate, modTime time.Time) (string, string) {
+	t.Helper()
+	key, err := x509.MarshalECPrivateKey(certificate.key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	certFile, keyFile := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", certificate.certificate.Raw, modTime)
+	writePEM(t, keyFile, "EC PRIVATE KEY", key, modTime)
+	return certFile, keyFile
+}
+
+func TestRunServesHTTP2WithClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile := writeKeyPair(t, dir, newServerCertificate(t, ca), time.Now())
+	caFile := filepath.Join(dir, "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", ca.certificate.Raw, time.Now())
+
+	client := issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "billing"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+	stranger := issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "billing"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, newTestCA(t))
+
+	srv := New(config.ServerConfig{
+		Port:              "0",
+		ShutdownTimeout:   time.Second,
+		TLSCertFile:       certFile,
+		TLSKeyFile:        keyFile,
+		TLSClientCAFile:   caFile,
+		TLSClientAuth:     ClientAuthRequire,
+		TLSReloadInterval: time.Minute,
+	}, http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		io.WriteString(writer, request.TLS.PeerCertificates[0].Subject.CommonName)
+	}), logger.NewLogger("error", "json"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	}()
+	_, port, _ := net.SplitHostPort(waitForAddr(t, srv))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+	get := func(certificate *testCertificate) (*http.Response, error) {
+		tlsConfig := &tls.Config{RootCAs: roots}
+		if certificate != nil {
+			tlsConfig.Certificates = []tls.Certificate{{Certificate: [][]byte{certificate.certificate.Raw}, PrivateKey: certificate.key}}
+		}
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, ForceAttemptHTTP2: true}}
+		return httpClient.Get("https://127.0.0.1:" + port)
+	}
+
+	response, err := get(client)
+	if err != nil {
+		t.Fatalf("GET with a client certificate: %v", err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if response.ProtoMajor != 2 || string(body) != "billing" {
+		t.Fatalf("response = %s %q, want HTTP/2 from billing", response.Proto, body)
+	}
+
+	if _, err := get(nil); err == nil {
+		t.Fatal("expected a client without a certificate to be refused")
+	}
+	if _, err := get(stranger); err == nil {
+		t.Fatal("expected a certificate of another CA to be refused")
+	}
+}
+
+func TestCertReloaderPicksUpRenewedCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	first := newServerCertificate(t, ca)
+	certFile, keyFile := writeKeyPair(t, dir, first, time.Now().Add(-time.Minute))
+
+	reloader, err := newCertReloader(config.ServerConfig{TLSCertFile: certFile, TLSKeyFile: keyFile}, logger.NewLogger("error", "json"))
+	if err != nil {
+		t.Fatalf("newCertReloader: %v", err)
+	}
+	serial := func() *big.Int {
+		leaf, err := x509.ParseCertificate(reloader.certificate.Load().Certificate[0])
+		if err != nil {
+			t.Fatalf("ParseCertificate: %v", err)
+		}
+		return leaf.SerialNumber
+	}
+
+	if reloaded, err := reloader.load(); reloaded || err != nil {
+		t.Fatalf("load = %v, %v, want nothing to reload", reloaded, err)
+	}
+
+	second := newServerCertificate(t, ca)
+	writeKeyPair(t, dir, second, time.Now())
+	if reloaded, err := reloader.load(); !reloaded || err != nil {
+		t.Fatalf("load = %v, %v, want the renewed certificate", reloaded, err)
+	}
+	if serial().Cmp(second.certificate.SerialNumber) != 0 {
+		t.Fatal("expected the renewed certificate to be served")
+	}
+
+	// A broken renewal keeps the certificate in use
+	writePEM(t, certFile, "CERTIFICATE", []byte("garbage"), time.Now().Add(time.Minute))
+	if _, err := reloader.load(); err == nil {
+		t.Fatal("expected the broken certificate to fail")
+	}
+	if serial().Cmp(second.certificate.SerialNumber) != 0 {
+		t.Fatal("expected the previous certificate to stay in use")
+	}
+}
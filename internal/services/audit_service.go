@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+	"task_API/pkg/logger"
+)
+
+const (
+	defaultAuditLogLimit = 100
+	maxAuditLogLimit     = 1000
+	auditVerifyBatchSize = 500
+)
+
+type auditService struct {
+	auditRepo repositories.AuditLogRepository
+	logger    *logger.Logger
+}
+
+func NewAuditService(auditRepo repositories.AuditLogRepository, appLogger *logger.Logger) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+		logger:    appLogger,
+	}
+}
+
+// Record stores a security event. Failures are logged rather than returned so
+// that auditing never breaks the request being audited, and the entry is stored
+// even when the request is cancelled.
+func (audit *auditService) Record(ctx context.Context, entry *models.AuditLog) {
+	ctx = context.WithoutCancel(ctx)
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	userId := 0
+	if entry.UserId != nil {
+		userId = *entry.UserId
+	}
+	audit.logger.AuthEvent(ctx, userId, entry.Event)
+
+	if err := audit.auditRepo.AppendAuditLog(ctx, entry); err != nil {
+		audit.logger.Error().Ctx(ctx).Err(err).Str("event", entry.Event).Msg("failed to persist audit log")
+	}
+}
+
+func (audit *auditService) GetAuditLogs(ctx context.Context, filter *models.AuditLogFilter) ([]*models.AuditLog, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLogLimit
+	}
+	if filter.Limit > maxAuditLogLimit {
+		filter.Limit = maxAuditLogLimit
+	}
+	return audit.auditRepo.GetAuditLogs(ctx, filter)
+}
+
+// VerifyChain walks the whole log and checks every link of the hash chain
+func (audit *auditService) VerifyChain(ctx context.Context) (*models.AuditChainVerification, error) {
+	result := &models.AuditChainVerification{Valid: true}
+	prevHash := models.AuditGenesisHash
+	lastId := 0
+
+	for {
+		entries, err := audit.auditRepo.GetAuditChain(ctx, lastId, auditVerifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.PrevHash != prevHash {
+				return brokenChain(result, entry.ID, "previous hash does not match the preceding entry"), nil
+			}
+			if entry.ComputeHash() != entry.Hash {
+				return brokenChain(result, entry.ID, "entry content does not match its hash"), nil
+			}
+			prevHash = entry.Hash
+			lastId = entry.ID
+			result.Entries++
+		}
+
+		if len(entries) < auditVerifyBatchSize {
+			return result, nil
+		}
+	}
+}
+
+func brokenChain(result *models.AuditChainVerification, id int, reason string) *models.AuditChainVerification {
+	result.Valid = false
+	result.BrokenAt = &id
+	result.Reason = fmt.Sprintf("entry %d: %s", id, reason)
+	return result
+}
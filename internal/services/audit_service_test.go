@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/memory"
+	"task_API/internal/storage/repositories"
+	"task_API/pkg/logger"
+)
+
+// tamperedChain alters the stored chain as an attacker with database access would
+type tamperedChain struct {
+	repositories.AuditLogRepository
+	tamper func(entries []*models.AuditLog) []*models.AuditLog
+}
+
+func (repo tamperedChain) GetAuditChain(ctx context.Context, afterId int, limit int) ([]*models.AuditLog, error) {
+	entries, err := repo.AuditLogRepository.GetAuditChain(ctx, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return repo.tamper(entries), nil
+}
+
+// recordAuditEntries records one entry per event and returns the stored chain
+func recordAuditEntries(t *testing.T, store *memory.Storage, events ...string) []*models.AuditLog {
+	t.Helper()
+	service := NewAuditService(store.AuditLogs(), logger.NewLogger("disabled", "json"))
+	for _, event := range events {
+		service.Record(context.Background(), &models.AuditLog{Event: event, Email: "owner@example.com", IPAddress: "203.0.113.7", Success: true})
+	}
+
+	chain, err := store.AuditLogs().GetAuditChain(context.Background(), 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != len(events) {
+		t.Fatalf("chain = %+v, want %d entries", chain, len(events))
+	}
+	return chain
+}
+
+func TestAuditEntriesLinkToThePrecedingOne(t *testing.T) {
+	store := memory.NewStorage()
+	chain := recordAuditEntries(t, store, models.AuditEventRegister, models.AuditEventLoginSuccess, models.AuditEventPasswordChange)
+
+	prevHash := models.AuditGenesisHash
+	for _, entry := range chain {
+		if entry.PrevHash != prevHash {
+			t.Errorf("entry %d: PrevHash = %s, want %s", entry.ID, entry.PrevHash, prevHash)
+		}
+		if entry.Hash != entry.ComputeHash() {
+			t.Errorf("entry %d: Hash does not cover its content", entry.ID)
+		}
+		prevHash = entry.Hash
+	}
+
+	changed := *chain[0]
+	changed.Success = false
+	if changed.ComputeHash() == chain[0].Hash {
+		t.Error("ComputeHash ignores a changed field")
+	}
+
+	verification, err := NewAuditService(store.AuditLogs(), logger.NewLogger("disabled", "json")).VerifyChain(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyChain() error = %v", err)
+	}
+	if !verification.Valid || verification.Entries != 3 || verification.BrokenAt != nil {
+		t.Errorf("verification = %+v, want a valid chain of 3", verification)
+	}
+}
+
+func TestVerifyChainReportsTheBrokenEntry(t *testing.T) {
+	tests := map[string]struct {
+		tamper func(entries []*models.AuditLog) []*models.AuditLog
+		want   int
+		reason string
+	}{
+		"altered entry": {
+			tamper: func(entries []*models.AuditLog) []*models.AuditLog {
+				entries[1].Email = "someone-else@example.com"
+				return entries
+			},
+			want:   2,
+			reason: "content does not match",
+		},
+		"removed entry": {
+			tamper: func(entries []*models.AuditLog) []*models.AuditLog {
+				return append(entries[:1], entries[2:]...)
+			},
+			want:   3,
+			reason: "previous hash does not match",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := memory.NewStorage()
+			recordAuditEntries(t, store, models.AuditEventRegister, models.AuditEventLoginFailure, models.AuditEventLoginSuccess)
+
+			service := NewAuditService(tamperedChain{store.AuditLogs(), test.tamper}, logger.NewLogger("disabled", "json"))
+			verification, err := service.VerifyChain(context.Background())
+			if err != nil {
+				t.Fatalf("VerifyChain() error = %v", err)
+			}
+			if verification.Valid || verification.BrokenAt == nil || *verification.BrokenAt != test.want {
+				t.Fatalf("verification = %+v, want broken at entry %d", verification, test.want)
+			}
+			if !strings.Contains(verification.Reason, test.reason) {
+				t.Errorf("Reason = %q, want %q", verification.Reason, test.reason)
+			}
+		})
+	}
+}
@@ -1,39 +1,39 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"task_API/internal/models"
 	"task_API/internal/storage/repositories"
+	"task_API/internal/tracing"
+	apperrors "task_API/pkg/errors"
 	"task_API/pkg/utils"
 )
 
 type authService struct {
 	userRepo      repositories.UserRepository
+	txManager     repositories.TxManager
 	jwtSecret     string
 	jwtExpiration time.Duration
 }
 
-func NewAuthService(userRepo repositories.UserRepository, jwtSecret string, jwtExpiration time.Duration) AuthService {
+func NewAuthService(userRepo repositories.UserRepository, txManager repositories.TxManager, jwtSecret string, jwtExpiration time.Duration) AuthService {
 	return &authService{
 		userRepo:      userRepo,
+		txManager:     txManager,
 		jwtSecret:     jwtSecret,
 		jwtExpiration: jwtExpiration,
 	}
 }
 
-func (auth *authService) Register(request *models.RegisterUserRequest) (*models.AuthResponse, error) {
-	// Existing Check
-	existing, _ := auth.userRepo.GetUserByEmail(request.Email)
-	if existing != nil {
-		return nil, fmt.Errorf("user with email %s already exists", request.Email)
-	}
-
-	// Password Hashing
-	hashedPass, error := utils.HashPassword(request.Password)
+func (auth *authService) Register(ctx context.Context, request *models.RegisterUserRequest) (*models.AuthResponse, error) {
+	// Password Hashing, before the transaction so it is not held open meanwhile
+	hashedPass, error := hashPassword(ctx, request.Password)
 	if error != nil {
-		return nil, fmt.Errorf("failed to hash password: %v", error)
+		return nil, fmt.Errorf("failed to hash password: %w", error)
 	}
 
 	//
@@ -41,17 +41,18 @@ func (auth *authService) Register(request *models.RegisterUserRequest) (*models.
 		Name:         request.Name,
 		Email:        request.Email,
 		PasswordHash: hashedPass,
+		Role:         models.RoleUser,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
-	if error = auth.userRepo.CreateUser(user); error != nil {
-		return nil, fmt.Errorf("failed to create user: %v", error)
+	if error = auth.createUniqueUser(ctx, user); error != nil {
+		return nil, error
 	}
 
 	token, error := utils.GenerateJwtToken(user.ID, user.Email)
 	if error != nil {
-		return nil, fmt.Errorf("failed to generate token: %v", error)
+		return nil, fmt.Errorf("failed to generate token: %w", error)
 	}
 
 	return &models.AuthResponse{
@@ -60,22 +61,45 @@ func (auth *authService) Register(request *models.RegisterUserRequest) (*models.
 	}, nil
 }
 
-func (auth *authService) Login(request *models.LoginUserRequest) (*models.AuthResponse, error) {
-	user, error := auth.userRepo.GetUserByEmail(request.Email)
+// createUniqueUser checks that the email is free and creates the user in one transaction
+func (auth *authService) createUniqueUser(ctx context.Context, user *models.User) error {
+	return auth.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Existing Check
+		existing, err := auth.userRepo.GetUserByEmail(ctx, user.Email)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if existing != nil {
+			return apperrors.ErrAlreadyExists.Newf("user with email %s already exists", user.Email)
+		}
+
+		if err := auth.userRepo.CreateUser(ctx, user); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return nil
+	})
+}
+
+func (auth *authService) Login(ctx context.Context, request *models.LoginUserRequest) (*models.AuthResponse, error) {
+	user, error := auth.userRepo.GetUserByEmail(ctx, request.Email)
 
 	if error != nil {
-		return nil, fmt.Errorf("invalid creadientials")
+		// An unknown email is answered like a wrong password
+		if errors.Is(error, apperrors.ErrNotFound) {
+			return nil, apperrors.ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to get user: %w", error)
 	}
 
 	// Password Verification
-	if !utils.CheckHashedPassword(user.PasswordHash, request.Password) {
-		return nil, fmt.Errorf("invalid creadientials")
+	if !checkPassword(ctx, user.PasswordHash, request.Password) {
+		return nil, apperrors.ErrInvalidCredentials
 	}
 
 	// JWT Token Generation
 	token, error := utils.GenerateJwtToken(user.ID, user.Email)
 	if error != nil {
-		return nil, fmt.Errorf("failed to generate token: %v", error)
+		return nil, fmt.Errorf("failed to generate token: %w", error)
 	}
 
 	return &models.AuthResponse{
@@ -84,17 +108,66 @@ func (auth *authService) Login(request *models.LoginUserRequest) (*models.AuthRe
 	}, nil
 }
 
-func (auth *authService) ValidateToken(tokenString string) (*models.User, error) {
-	claim, error := utils.ValidateJWTToken(tokenString)
+func (auth *authService) ValidateToken(ctx context.Context, tokenString string) (*models.User, error) {
+	claim, error := ValidateJWT(ctx, tokenString)
 
 	if error != nil {
-		return nil, fmt.Errorf("invalid token: %v", error)
+		return nil, apperrors.ErrUnauthorized.Wrap(error, "invalid token")
 	}
 
-	user, error := auth.userRepo.GetUserById(claim.UserId)
+	user, error := auth.userRepo.GetUserById(ctx, claim.UserId)
 	if error != nil {
-		return nil, fmt.Errorf("user not found: %v", error)
+		return nil, fmt.Errorf("user not found: %w", error)
 	}
 
 	return user, nil
 }
+
+func (auth *authService) ChangePassword(ctx context.Context, userId int, request *models.ChangePasswordRequest) error {
+	user, error := auth.userRepo.GetUserById(ctx, userId)
+	if error != nil {
+		return fmt.Errorf("user not found: %w", error)
+	}
+
+	if !checkPassword(ctx, user.PasswordHash, request.CurrentPassword) {
+		return apperrors.ErrInvalidCredentials
+	}
+
+	hashedPass, error := hashPassword(ctx, request.NewPassword)
+	if error != nil {
+		return fmt.Errorf("failed to hash password: %w", error)
+	}
+
+	user.PasswordHash = hashedPass
+	user.UpdatedAt = time.Now()
+
+	if error = auth.userRepo.UpdateUser(ctx, user); error != nil {
+		return fmt.Errorf("failed to update password: %w", error)
+	}
+
+	return nil
+}
+
+// hashPassword hashes with bcrypt under a span of its own, hashing takes most of the
+// time of a registration
+func hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracing.Start(ctx, "bcrypt.hash")
+	hash, err := utils.HashPassword(password)
+	tracing.End(span, err)
+	return hash, err
+}
+
+// checkPassword compares with bcrypt under a span of its own
+func checkPassword(ctx context.Context, hash string, password string) bool {
+	_, span := tracing.Start(ctx, "bcrypt.compare")
+	defer span.End()
+	return utils.CheckHashedPassword(hash, password)
+}
+
+// ValidateJWT validates a token under a span of its own
+func ValidateJWT(ctx context.Context, token string) (*utils.JWTClaim, error) {
+	_, span := tracing.Start(ctx, "jwt.validate")
+	claim, err := utils.ValidateJWTToken(token)
+	tracing.End(span, err)
+	return claim, err
+}
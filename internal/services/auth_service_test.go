@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/memory"
+	"task_API/internal/storage/repositories"
+	apperrors "task_API/pkg/errors"
+)
+
+// failingLookups cannot read users, as when the database times out
+type failingLookups struct {
+	repositories.UserRepository
+}
+
+func (repo failingLookups) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return nil, context.DeadlineExceeded
+}
+
+func TestRegisterStopsWhenTheEmailCannotBeChecked(t *testing.T) {
+	store := memory.NewStorage()
+	service := NewAuthService(failingLookups{store.Users()}, store.TxManager(), "secret", time.Hour)
+
+	_, err := service.Register(context.Background(), &models.RegisterUserRequest{Email: "new@example.com", Password: "password123", Name: "New"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Register() error = %v, want the lookup error", err)
+	}
+
+	if _, err := store.Users().GetUserByEmail(context.Background(), "new@example.com"); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("GetUserByEmail() error = %v, want the user not created", err)
+	}
+}
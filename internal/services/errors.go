@@ -0,0 +1,8 @@
+package services
+
+import (
+	apperrors "task_API/pkg/errors"
+)
+
+// ErrPermissionDenied is returned when a user acts on a resource owned by someone else
+var ErrPermissionDenied = apperrors.ErrForBidden.New("unauthorized access to task")
@@ -1,17 +1,48 @@
 package services
 
-import "task_API/internal/models"
+import (
+	"context"
+	"time"
+
+	"task_API/internal/events"
+	"task_API/internal/models"
+)
 
 type AuthService interface {
-	Register(request *models.RegisterUserRequest) (*models.AuthResponse, error)
-	Login(request *models.LoginUserRequest) (*models.AuthResponse, error)
-	ValidateToken(token string) (*models.User, error)
+	Register(ctx context.Context, request *models.RegisterUserRequest) (*models.AuthResponse, error)
+	Login(ctx context.Context, request *models.LoginUserRequest) (*models.AuthResponse, error)
+	ValidateToken(ctx context.Context, token string) (*models.User, error)
+	ChangePassword(ctx context.Context, userId int, request *models.ChangePasswordRequest) error
 }
 
 type TaskService interface {
-	CreateTask(req *models.CreateTaskRequest, userId int) (*models.Task, error)
-	GetTaskById(taskId int, userId int) (*models.Task, error)
-	GetAllTasks(userId int) ([]*models.Task, error)
-	UpdateTask(taskId int, req *models.UpdateTaskRequest, userId int) (*models.Task, error)
-	DeleteTask(taskId int, userId int) error
+	CreateTask(ctx context.Context, req *models.CreateTaskRequest, userId int) (*models.Task, error)
+	GetTaskById(ctx context.Context, taskId int, userId int) (*models.Task, error)
+	GetAllTasks(ctx context.Context, userId int) ([]*models.Task, error)
+	UpdateTask(ctx context.Context, taskId int, req *models.UpdateTaskRequest, userId int) (*models.Task, error)
+	DeleteTask(ctx context.Context, taskId int, userId int) error
+	RestoreTask(ctx context.Context, taskId int, userId int) (*models.Task, error)
+	GetTaskHistory(ctx context.Context, taskId int, userId int) ([]*models.TaskEvent, error)
+	GetTaskAsOf(ctx context.Context, taskId int, userId int, at time.Time) (*models.Task, error)
+	GetEventsSince(ctx context.Context, userId int, afterId int, limit int) ([]events.Event, bool, error)
+}
+
+type AuditService interface {
+	Record(ctx context.Context, entry *models.AuditLog)
+	GetAuditLogs(ctx context.Context, filter *models.AuditLogFilter) ([]*models.AuditLog, error)
+	VerifyChain(ctx context.Context) (*models.AuditChainVerification, error)
+}
+
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, req *models.CreateWebhookRequest, userId int) (*models.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context, userId int) ([]*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionId int, userId int) error
+	GetDeliveries(ctx context.Context, subscriptionId int, userId int) ([]*models.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, subscriptionId int, deliveryId int, userId int) (*models.WebhookDelivery, error)
+}
+
+type NotificationService interface {
+	GetNotifications(ctx context.Context, userId int, unreadOnly bool, limit int) ([]*models.Notification, error)
+	MarkRead(ctx context.Context, notificationId int, userId int) error
+	MarkAllRead(ctx context.Context, userId int) (int, error)
 }
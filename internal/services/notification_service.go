@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+)
+
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 200
+)
+
+type notificationService struct {
+	notificationRepo repositories.NotificationRepository
+}
+
+func NewNotificationService(notificationRepo repositories.NotificationRepository) NotificationService {
+	return &notificationService{notificationRepo: notificationRepo}
+}
+
+func (nService *notificationService) GetNotifications(ctx context.Context, userId int, unreadOnly bool, limit int) ([]*models.Notification, error) {
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+	return nService.notificationRepo.GetNotifications(ctx, userId, unreadOnly, limit)
+}
+
+func (nService *notificationService) MarkRead(ctx context.Context, notificationId int, userId int) error {
+	return nService.notificationRepo.MarkNotificationRead(ctx, notificationId, userId, time.Now())
+}
+
+func (nService *notificationService) MarkAllRead(ctx context.Context, userId int) (int, error) {
+	return nService.notificationRepo.MarkAllNotificationsRead(ctx, userId, time.Now())
+}
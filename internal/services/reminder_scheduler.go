@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"task_API/internal/config"
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+	"task_API/pkg/clock"
+	"task_API/pkg/logger"
+)
+
+// ReminderScheduler turns due task reminders into notifications. Several instances can
+// run it at once, the repository locks the reminders it fires.
+type ReminderScheduler struct {
+	reminderRepo repositories.ReminderRepository
+	config       config.ReminderConfig
+	clock        clock.Clock
+	logger       *logger.Logger
+}
+
+func NewReminderScheduler(reminderRepo repositories.ReminderRepository, cfg config.ReminderConfig, clk clock.Clock, appLogger *logger.Logger) *ReminderScheduler {
+	return &ReminderScheduler{
+		reminderRepo: reminderRepo,
+		config:       cfg,
+		clock:        clk,
+		logger:       appLogger,
+	}
+}
+
+// Run fires due reminders every poll interval until the context is cancelled
+func (scheduler *ReminderScheduler) Run(ctx context.Context) {
+	for {
+		for {
+			fired, err := scheduler.FireDue(ctx)
+			if err != nil {
+				scheduler.logger.Error().Err(err).Msg("reminder scheduler failed")
+				break
+			}
+			// A full batch means more reminders may be waiting
+			if fired < scheduler.config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-scheduler.clock.After(scheduler.config.PollInterval):
+		}
+	}
+}
+
+// FireDue fires one batch of due reminders and returns how many reminders were handled.
+// The count comes from the repository, compose runs again when its transaction is retried.
+func (scheduler *ReminderScheduler) FireDue(ctx context.Context) (int, error) {
+	notifications, handled, err := scheduler.reminderRepo.FireDueReminders(ctx, scheduler.clock.Now(), scheduler.config.BatchSize, scheduler.compose)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(notifications) > 0 {
+		scheduler.logger.Info().Int("notifications", len(notifications)).Msg("task reminders sent")
+	}
+	return handled, nil
+}
+
+// compose builds the notification of a reminder, or nil when the task no longer needs one
+func (scheduler *ReminderScheduler) compose(due *models.DueReminder) *models.Notification {
+	if due.Task.DeletedAt != nil || due.Task.Completed || due.Task.DueDate == nil {
+		return nil
+	}
+
+	taskId := due.Task.ID
+	return &models.Notification{
+		UserId:    due.Reminder.UserId,
+		TaskId:    &taskId,
+		Type:      models.NotificationTaskReminder,
+		Message:   reminderMessage(due.Task.Title, due.Reminder.OffsetMinutes),
+		CreatedAt: scheduler.clock.Now(),
+	}
+}
+
+func reminderMessage(title string, offsetMinutes int) string {
+	if offsetMinutes == 0 {
+		return fmt.Sprintf("Task %q is due now", title)
+	}
+	return fmt.Sprintf("Task %q is due in %s", title, formatOffset(offsetMinutes))
+}
+
+// formatOffset spells out a reminder offset for people, such as "1 day and 2 hours"
+func formatOffset(offsetMinutes int) string {
+	units := []struct {
+		name    string
+		minutes int
+	}{
+		{"day", 24 * 60},
+		{"hour", 60},
+		{"minute", 1},
+	}
+
+	var parts []string
+	for _, unit := range units {
+		count := offsetMinutes / unit.minutes
+		offsetMinutes %= unit.minutes
+		switch {
+		case count == 1:
+			parts = append(parts, "1 "+unit.name)
+		case count > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", count, unit.name))
+		}
+	}
+
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
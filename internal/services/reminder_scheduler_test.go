@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"task_API/internal/config"
+	"task_API/internal/models"
+	"task_API/pkg/clock"
+	"task_API/pkg/logger"
+)
+
+// fakeReminderRepository fires reminders from memory the way the Postgres one does
+type fakeReminderRepository struct {
+	mutex         sync.Mutex
+	reminders     []*models.Reminder
+	tasks         map[int]*models.Task
+	notifications []*models.Notification
+
+	// rolledBack is how many attempts compose the due reminders and then roll back,
+	// like a transaction retried after a serialization failure
+	rolledBack int
+}
+
+func newFakeReminderRepository() *fakeReminderRepository {
+	return &fakeReminderRepository{tasks: make(map[int]*models.Task)}
+}
+
+func (repo *fakeReminderRepository) ReplaceTaskReminders(ctx context.Context, taskId int, reminders []*models.Reminder) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.deleteLocked(taskId)
+	for _, reminder := range reminders {
+		reminder.ID = len(repo.reminders) + 1
+		repo.reminders = append(repo.reminders, reminder)
+	}
+	return nil
+}
+
+func (repo *fakeReminderRepository) DeleteTaskReminders(ctx context.Context, taskId int) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.deleteLocked(taskId)
+	return nil
+}
+
+func (repo *fakeReminderRepository) deleteLocked(taskId int) {
+	kept := repo.reminders[:0]
+	for _, reminder := range repo.reminders {
+		if reminder.TaskId != taskId || reminder.SentAt != nil {
+			kept = append(kept, reminder)
+		}
+	}
+	repo.reminders = kept
+}
+
+func (repo *fakeReminderRepository) FireDueReminders(ctx context.Context, now time.Time, limit int, compose func(due *models.DueReminder) *models.Notification) ([]*models.Notification, int, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	for ; repo.rolledBack > 0; repo.rolledBack-- {
+		for _, reminder := range repo.reminders {
+			if reminder.SentAt == nil && !reminder.FireAt.After(now) {
+				compose(&models.DueReminder{Reminder: *reminder, Task: *repo.tasks[reminder.TaskId]})
+			}
+		}
+	}
+
+	var notifications []*models.Notification
+	handled := 0
+	for _, reminder := range repo.reminders {
+		if handled == limit {
+			break
+		}
+		if reminder.SentAt != nil || reminder.FireAt.After(now) {
+			continue
+		}
+		handled++
+		sentAt := now
+		reminder.SentAt = &sentAt
+		if notification := compose(&models.DueReminder{Reminder: *reminder, Task: *repo.tasks[reminder.TaskId]}); notification != nil {
+			notification.ID = len(repo.notifications) + 1
+			repo.notifications = append(repo.notifications, notification)
+			notifications = append(notifications, notification)
+		}
+	}
+	return notifications, handled, nil
+}
+
+func (repo *fakeReminderRepository) sent() []*models.Notification {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	return append([]*models.Notification(nil), repo.notifications...)
+}
+
+func newTestReminderScheduler(repo *fakeReminderRepository, clk clock.Clock) *ReminderScheduler {
+	return NewReminderScheduler(repo, config.ReminderConfig{
+		PollInterval: time.Minute,
+		BatchSize:    10,
+	}, clk, logger.NewLogger("disabled", "json"))
+}
+
+func addReminderTask(repo *fakeReminderRepository, task *models.Task, offsets ...int) {
+	repo.tasks[task.ID] = task
+	var reminders []*models.Reminder
+	for _, offset := range offsets {
+		reminders = append(reminders, &models.Reminder{
+			TaskId:        task.ID,
+			UserId:        task.UserId,
+			OffsetMinutes: offset,
+			FireAt:        task.DueDate.Add(-time.Duration(offset) * time.Minute),
+		})
+	}
+	repo.ReplaceTaskReminders(context.Background(), task.ID, reminders)
+}
+
+func TestReminderSchedulerFiresWhenDue(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	repo := newFakeReminderRepository()
+	scheduler := newTestReminderScheduler(repo, fake)
+
+	dueDate := start.Add(2 * time.Hour)
+	addReminderTask(repo, &models.Task{ID: 1, UserId: 7, Title: "Ship release", DueDate: &dueDate}, 60)
+
+	if _, err := scheduler.FireDue(context.Background()); err != nil {
+		t.Fatalf("FireDue: %v", err)
+	}
+	if sent := repo.sent(); len(sent) != 0 {
+		t.Fatalf("expected no notification before the reminder time, got %d", len(sent))
+	}
+
+	fake.Advance(time.Hour)
+	if _, err := scheduler.FireDue(context.Background()); err != nil {
+		t.Fatalf("FireDue: %v", err)
+	}
+	sent := repo.sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected one notification, got %d", len(sent))
+	}
+	if sent[0].UserId != 7 || sent[0].TaskId == nil || *sent[0].TaskId != 1 {
+		t.Fatalf("notification not addressed to the task owner: %+v", sent[0])
+	}
+	if sent[0].Type != models.NotificationTaskReminder || sent[0].Message != `Task "Ship release" is due in 1 hour` {
+		t.Fatalf("unexpected notification: %+v", sent[0])
+	}
+
+	// A reminder is only sent once
+	fake.Advance(time.Hour)
+	scheduler.FireDue(context.Background())
+	if sent := repo.sent(); len(sent) != 1 {
+		t.Fatalf("expected the reminder to fire once, got %d notifications", len(sent))
+	}
+}
+
+func TestReminderMessage(t *testing.T) {
+	tests := []struct {
+		offsetMinutes int
+		want          string
+	}{
+		{0, `Task "Report" is due now`},
+		{1, `Task "Report" is due in 1 minute`},
+		{45, `Task "Report" is due in 45 minutes`},
+		{60, `Task "Report" is due in 1 hour`},
+		{90, `Task "Report" is due in 1 hour and 30 minutes`},
+		{24 * 60, `Task "Report" is due in 1 day`},
+		{3 * 24 * 60, `Task "Report" is due in 3 days`},
+		{26*60 + 5, `Task "Report" is due in 1 day, 2 hours and 5 minutes`},
+	}
+
+	for _, test := range tests {
+		if got := reminderMessage("Report", test.offsetMinutes); got != test.want {
+			t.Errorf("reminderMessage(%d) = %q, want %q", test.offsetMinutes, got, test.want)
+		}
+	}
+}
+
+func TestReminderSchedulerSkipsFinishedTasks(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	repo := newFakeReminderRepository()
+	scheduler := newTestReminderScheduler(repo, fake)
+
+	dueDate := start.Add(time.Hour)
+	deletedAt := start
+	addReminderTask(repo, &models.Task{ID: 1, UserId: 7, Title: "Done", Completed: true, DueDate: &dueDate}, 0)
+	addReminderTask(repo, &models.Task{ID: 2, UserId: 7, Title: "Gone", DueDate: &dueDate, DeletedAt: &deletedAt}, 0)
+
+	fake.Advance(time.Hour)
+	handled, err := scheduler.FireDue(context.Background())
+	if err != nil {
+		t.Fatalf("FireDue: %v", err)
+	}
+	if handled != 2 {
+		t.Fatalf("expected both reminders to be handled, got %d", handled)
+	}
+	if sent := repo.sent(); len(sent) != 0 {
+		t.Fatalf("expected no notifications for finished tasks, got %d", len(sent))
+	}
+}
+
+func TestReminderSchedulerCountsCommittedReminders(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	repo := newFakeReminderRepository()
+	repo.rolledBack = 2
+	scheduler := newTestReminderScheduler(repo, fake)
+
+	dueDate := start.Add(time.Hour)
+	addReminderTask(repo, &models.Task{ID: 1, UserId: 7, Title: "Ship release", DueDate: &dueDate}, 60, 30)
+
+	fake.Advance(30 * time.Minute)
+	handled, err := scheduler.FireDue(context.Background())
+	if err != nil {
+		t.Fatalf("FireDue: %v", err)
+	}
+	if handled != 2 {
+		t.Fatalf("expected the 2 committed reminders to be counted once, got %d", handled)
+	}
+}
+
+func TestReminderSchedulerRunPollsOnClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	repo := newFakeReminderRepository()
+	scheduler := newTestReminderScheduler(repo, fake)
+
+	dueDate := start.Add(90 * time.Second)
+	addReminderTask(repo, &models.Task{ID: 1, UserId: 7, Title: "Call back", DueDate: &dueDate}, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitFor(t, func() bool { return fake.Waiters() == 1 })
+	fake.Advance(time.Minute)
+	waitFor(t, func() bool { return fake.Waiters() == 1 })
+	if sent := repo.sent(); len(sent) != 0 {
+		t.Fatalf("expected no notification after one poll, got %d", len(sent))
+	}
+
+	fake.Advance(time.Minute)
+	waitFor(t, func() bool { return len(repo.sent()) == 1 })
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
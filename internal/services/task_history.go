@@ -0,0 +1,213 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"task_API/internal/events"
+	"task_API/internal/models"
+	apperrors "task_API/pkg/errors"
+)
+
+// diffTask returns the fields that differ between two versions of a task.
+// A nil before means the task is being created, so every field is reported.
+func diffTask(before, after *models.Task) map[string]models.FieldChange {
+	changes := make(map[string]models.FieldChange)
+
+	if before == nil {
+		changes["title"] = models.FieldChange{Before: nil, After: after.Title}
+		changes["completed"] = models.FieldChange{Before: nil, After: after.Completed}
+		changes["due_date"] = models.FieldChange{Before: nil, After: after.DueDate}
+		changes["reminder_offsets"] = models.FieldChange{Before: nil, After: after.ReminderOffsets}
+		return changes
+	}
+
+	if before.Title != after.Title {
+		changes["title"] = models.FieldChange{Before: before.Title, After: after.Title}
+	}
+	if before.Completed != after.Completed {
+		changes["completed"] = models.FieldChange{Before: before.Completed, After: after.Completed}
+	}
+	if !equalTimes(before.DueDate, after.DueDate) {
+		changes["due_date"] = models.FieldChange{Before: before.DueDate, After: after.DueDate}
+	}
+	if !equalInts(before.ReminderOffsets, after.ReminderOffsets) {
+		changes["reminder_offsets"] = models.FieldChange{Before: before.ReminderOffsets, After: after.ReminderOffsets}
+	}
+	return changes
+}
+
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// replayTaskEvents rebuilds the state of a task at the given time by applying
+// its history, oldest event first
+func replayTaskEvents(events []*models.TaskEvent, at time.Time) (*models.Task, error) {
+	snapshots, err := replaySnapshots(events)
+	if err != nil {
+		return nil, err
+	}
+
+	var task *models.Task
+	for _, event := range events {
+		if event.CreatedAt.After(at) {
+			break
+		}
+		task = snapshots[event.ID]
+	}
+
+	if task == nil {
+		return nil, apperrors.ErrNotFound.Newf("task did not exist at %s", at.Format(time.RFC3339))
+	}
+	return task, nil
+}
+
+// replaySnapshots applies the history of a task and returns its state after each event, by event id
+func replaySnapshots(history []*models.TaskEvent) (map[int]*models.Task, error) {
+	snapshots := make(map[int]*models.Task, len(history))
+	var task *models.Task
+
+	for _, event := range history {
+		if task == nil {
+			task = &models.Task{
+				ID:        event.TaskId,
+				UserId:    event.UserId,
+				CreatedAt: event.CreatedAt,
+			}
+		}
+		if err := applyTaskChanges(task, event.Changes); err != nil {
+			return nil, fmt.Errorf("failed to replay task event %d: %w", event.ID, err)
+		}
+		task.UpdatedAt = event.CreatedAt
+
+		snapshot := *task
+		snapshots[event.ID] = &snapshot
+	}
+	return snapshots, nil
+}
+
+// taskEvents returns the published events matching a history entry. Completing a task
+// is reported as both an update and a completion.
+func taskEvents(history *models.TaskEvent, task *models.Task) []events.Event {
+	var eventTypes []string
+	switch history.Action {
+	case models.TaskActionCreated:
+		eventTypes = append(eventTypes, events.TaskCreated)
+	case models.TaskActionUpdated:
+		eventTypes = append(eventTypes, events.TaskUpdated)
+		if change, ok := history.Changes["completed"]; ok && change.After == true {
+			eventTypes = append(eventTypes, events.TaskCompleted)
+		}
+	case models.TaskActionDeleted:
+		eventTypes = append(eventTypes, events.TaskDeleted)
+	case models.TaskActionRestored:
+		eventTypes = append(eventTypes, events.TaskRestored)
+	}
+
+	snapshot := *task
+	published := make([]events.Event, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		published = append(published, events.Event{
+			ID:         history.ID,
+			Type:       eventType,
+			UserId:     history.UserId,
+			Task:       &snapshot,
+			OccurredAt: history.CreatedAt,
+		})
+	}
+	return published
+}
+
+func applyTaskChanges(task *models.Task, changes map[string]models.FieldChange) error {
+	for field, change := range changes {
+		switch field {
+		case "title":
+			if title, ok := change.After.(string); ok {
+				task.Title = title
+			}
+		case "completed":
+			if completed, ok := change.After.(bool); ok {
+				task.Completed = completed
+			}
+		case "due_date":
+			dueDate, err := historyTime(change.After)
+			if err != nil {
+				return err
+			}
+			task.DueDate = dueDate
+		case "reminder_offsets":
+			offsets, err := historyInts(change.After)
+			if err != nil {
+				return err
+			}
+			task.ReminderOffsets = offsets
+		case "deleted_at":
+			deletedAt, err := historyTime(change.After)
+			if err != nil {
+				return err
+			}
+			task.DeletedAt = deletedAt
+		}
+	}
+	return nil
+}
+
+// historyTime reads a timestamp stored in a field change. Values read back from
+// the database are RFC 3339 strings.
+func historyTime(value interface{}) (*time.Time, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return &v, nil
+	case *time.Time:
+		return v, nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", v, err)
+		}
+		return &parsed, nil
+	default:
+		return nil, fmt.Errorf("unexpected timestamp value %v", value)
+	}
+}
+
+// historyInts reads a list of integers stored in a field change. Values read back
+// from the database are lists of JSON numbers.
+func historyInts(value interface{}) ([]int, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []int:
+		return v, nil
+	case []interface{}:
+		ints := make([]int, 0, len(v))
+		for _, item := range v {
+			number, ok := item.(float64)
+			if !ok {
+				return nil, fmt.Errorf("unexpected list item %v", item)
+			}
+			ints = append(ints, int(number))
+		}
+		return ints, nil
+	default:
+		return nil, fmt.Errorf("unexpected list value %v", value)
+	}
+}
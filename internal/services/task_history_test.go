@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/memory"
+	apperrors "task_API/pkg/errors"
+)
+
+func TestDiffTaskReportsChangedFields(t *testing.T) {
+	due := time.Date(2026, time.June, 1, 17, 0, 0, 0, time.UTC)
+	before := &models.Task{Title: "Write report", DueDate: &due, ReminderOffsets: []int{60}}
+
+	tests := map[string]struct {
+		after *models.Task
+		want  []string
+	}{
+		"unchanged":       {after: &models.Task{Title: "Write report", DueDate: &due, ReminderOffsets: []int{60}}},
+		"same due date":   {after: &models.Task{Title: "Write report", DueDate: ptrTime(due.In(time.FixedZone("CEST", 2*3600))), ReminderOffsets: []int{60}}},
+		"title":           {after: &models.Task{Title: "Send report", DueDate: &due, ReminderOffsets: []int{60}}, want: []string{"title"}},
+		"completed":       {after: &models.Task{Title: "Write report", Completed: true, DueDate: &due, ReminderOffsets: []int{60}}, want: []string{"completed"}},
+		"due date":        {after: &models.Task{Title: "Write report", ReminderOffsets: []int{60}}, want: []string{"due_date"}},
+		"reminder offset": {after: &models.Task{Title: "Write report", DueDate: &due, ReminderOffsets: []int{60, 1440}}, want: []string{"reminder_offsets"}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			changes := diffTask(before, test.after)
+			if len(changes) != len(test.want) {
+				t.Fatalf("changes = %v, want %v", changes, test.want)
+			}
+			for _, field := range test.want {
+				if _, ok := changes[field]; !ok {
+					t.Errorf("changes = %v, want %s", changes, field)
+				}
+			}
+		})
+	}
+
+	if created := diffTask(nil, before); len(created) != 4 {
+		t.Errorf("created changes = %v, want every field", created)
+	}
+}
+
+func ptrTime(value time.Time) *time.Time {
+	return &value
+}
+
+func TestTaskHistoryReplaysEverySnapshot(t *testing.T) {
+	service, _, _ := newTestTaskService(t, (*memory.Storage).Webhooks)
+	ctx := context.Background()
+
+	due := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	task, err := service.CreateTask(ctx, &models.CreateTaskRequest{Title: "Write report"}, 1)
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if _, err := service.UpdateTask(ctx, task.ID, &models.UpdateTaskRequest{Title: "Send report", Completed: true, DueDate: &due, ReminderOffsets: []int{60}}, 1); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+	if err := service.DeleteTask(ctx, task.ID, 1); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+
+	history, err := service.GetTaskHistory(ctx, task.ID, 1)
+	if err != nil {
+		t.Fatalf("GetTaskHistory() error = %v", err)
+	}
+	actions := []string{models.TaskActionCreated, models.TaskActionUpdated, models.TaskActionDeleted}
+	if len(history) != len(actions) {
+		t.Fatalf("history = %+v, want %v", history, actions)
+	}
+	for index, action := range actions {
+		if history[index].Action != action {
+			t.Errorf("history[%d].Action = %q, want %q", index, history[index].Action, action)
+		}
+	}
+
+	created, err := service.GetTaskAsOf(ctx, task.ID, 1, history[1].CreatedAt.Add(-time.Nanosecond))
+	if err != nil {
+		t.Fatalf("GetTaskAsOf(between events) error = %v", err)
+	}
+	if created.Title != "Write report" || created.Completed || created.DueDate != nil || created.DeletedAt != nil {
+		t.Errorf("snapshot after creation = %+v", created)
+	}
+
+	updated, err := service.GetTaskAsOf(ctx, task.ID, 1, history[1].CreatedAt)
+	if err != nil {
+		t.Fatalf("GetTaskAsOf(update) error = %v", err)
+	}
+	if updated.Title != "Send report" || !updated.Completed || updated.DueDate == nil || !updated.DueDate.Equal(due) || !equalInts(updated.ReminderOffsets, []int{60}) {
+		t.Errorf("snapshot after update = %+v", updated)
+	}
+
+	deleted, err := service.GetTaskAsOf(ctx, task.ID, 1, time.Now())
+	if err != nil {
+		t.Fatalf("GetTaskAsOf(now) error = %v", err)
+	}
+	if deleted.DeletedAt == nil || deleted.Title != "Send report" {
+		t.Errorf("snapshot after deletion = %+v", deleted)
+	}
+}
+
+func TestTaskSnapshotBeforeCreationIsNotFound(t *testing.T) {
+	service, _, _ := newTestTaskService(t, (*memory.Storage).Webhooks)
+	ctx := context.Background()
+
+	task, err := service.CreateTask(ctx, &models.CreateTaskRequest{Title: "Write report"}, 1)
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	_, err = service.GetTaskAsOf(ctx, task.ID, 1, task.CreatedAt.Add(-time.Hour))
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("GetTaskAsOf() error = %v, want ErrNotFound", err)
+	}
+}
@@ -1,86 +1,378 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"time"
 
+	"task_API/internal/events"
 	"task_API/internal/models"
 	"task_API/internal/storage/repositories"
+	apperrors "task_API/pkg/errors"
 )
 
 type taskService struct {
-	taskRepo repositories.TaskRepository
+	taskRepo     repositories.TaskRepository
+	eventRepo    repositories.TaskEventRepository
+	reminderRepo repositories.ReminderRepository
+	webhookRepo  repositories.WebhookRepository
+	txManager    repositories.TxManager
+	emitter      events.Emitter
 }
 
-func NewTaskService(taskRepo repositories.TaskRepository) TaskService {
+func NewTaskService(taskRepo repositories.TaskRepository, eventRepo repositories.TaskEventRepository, reminderRepo repositories.ReminderRepository, webhookRepo repositories.WebhookRepository, txManager repositories.TxManager, emitter events.Emitter) TaskService {
 	return &taskService{
-		taskRepo: taskRepo,
+		taskRepo:     taskRepo,
+		eventRepo:    eventRepo,
+		reminderRepo: reminderRepo,
+		webhookRepo:  webhookRepo,
+		txManager:    txManager,
+		emitter:      emitter,
 	}
 }
 
-func (tService *taskService) CreateTask(req *models.CreateTaskRequest, userId int) (*models.Task, error) {
+func (tService *taskService) CreateTask(ctx context.Context, req *models.CreateTaskRequest, userId int) (*models.Task, error) {
+	offsets, err := normalizeReminderOffsets(req.DueDate, req.ReminderOffsets)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
 	task := &models.Task{
-		Title:     req.Title,
-		Completed: false,
-		UserId:    userId,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Title:           req.Title,
+		Completed:       false,
+		UserId:          userId,
+		DueDate:         req.DueDate,
+		ReminderOffsets: offsets,
+		CreatedAt:       now,
+		UpdatedAt:       now,
 	}
 
-	if err := tService.taskRepo.CreateTask(task); err != nil {
+	var history *models.TaskEvent
+	err = tService.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := tService.taskRepo.CreateTask(ctx, task); err != nil {
+			return err
+		}
+
+		if err := tService.scheduleReminders(ctx, task, now); err != nil {
+			return err
+		}
+
+		history, err = tService.recordEvent(ctx, task, userId, models.TaskActionCreated, diffTask(nil, task), now)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	tService.emit(history, task)
 	return task, nil
 }
 
-func (tService *taskService) GetTaskById(taskId int, userId int) (*models.Task, error) {
-	task, err := tService.taskRepo.GetTaskById(taskId)
+func (tService *taskService) GetTaskById(ctx context.Context, taskId int, userId int) (*models.Task, error) {
+	task, err := tService.getOwnedTask(ctx, taskId, userId)
 	if err != nil {
 		return nil, err
 	}
-	if task.UserId != userId {
-		return nil, fmt.Errorf("unauthorized access to task")
+	if task.DeletedAt != nil {
+		return nil, apperrors.ErrNotFound.New("task not found")
 	}
 	return task, nil
 }
 
-func (tService *taskService) GetAllTasks(userId int) ([]*models.Task, error) {
-	return tService.taskRepo.GetAllTasks(userId)
+func (tService *taskService) GetAllTasks(ctx context.Context, userId int) ([]*models.Task, error) {
+	return tService.taskRepo.GetAllTasks(ctx, userId)
 }
 
-func (tService *taskService) UpdateTask(taskId int, req *models.UpdateTaskRequest, userId int) (*models.Task, error) {
-	existing, err := tService.taskRepo.GetTaskById(taskId)
+func (tService *taskService) UpdateTask(ctx context.Context, taskId int, req *models.UpdateTaskRequest, userId int) (*models.Task, error) {
+	offsets, err := normalizeReminderOffsets(req.DueDate, req.ReminderOffsets)
+	if err != nil {
+		return nil, err
+	}
+
+	var task *models.Task
+	var history *models.TaskEvent
+	err = tService.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Locked until the transaction ends, so the recorded changes match what is overwritten
+		existing, err := tService.lockOwnedTask(ctx, taskId, userId)
+		if err != nil {
+			return err
+		}
+		if existing.DeletedAt != nil {
+			return apperrors.ErrNotFound.New("task not found")
+		}
+
+		now := time.Now()
+		task = &models.Task{
+			ID:              taskId,
+			Title:           req.Title,
+			Completed:       req.Completed,
+			UserId:          userId,
+			DueDate:         req.DueDate,
+			ReminderOffsets: offsets,
+			CreatedAt:       existing.CreatedAt,
+			UpdatedAt:       now,
+		}
 
+		if err := tService.taskRepo.UpdateTask(ctx, task); err != nil {
+			return err
+		}
+
+		// Only reschedule when something reminders depend on changed, so a reminder that
+		// is due but not sent yet is not thrown away by an unrelated edit
+		changes := diffTask(existing, task)
+		_, dueDateChanged := changes["due_date"]
+		_, offsetsChanged := changes["reminder_offsets"]
+		_, completedChanged := changes["completed"]
+		if dueDateChanged || offsetsChanged || completedChanged {
+			if err := tService.scheduleReminders(ctx, task, now); err != nil {
+				return err
+			}
+		}
+
+		history, err = tService.recordEvent(ctx, task, userId, models.TaskActionUpdated, changes, now)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	if existing.UserId != userId {
-		return nil, fmt.Errorf("resource not allowed")
+
+	tService.emit(history, task)
+	return task, nil
+}
+
+func (tService *taskService) DeleteTask(ctx context.Context, taskId int, userId int) error {
+	var task *models.Task
+	var history *models.TaskEvent
+	err := tService.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		task, err = tService.lockOwnedTask(ctx, taskId, userId)
+		if err != nil {
+			return err
+		}
+		if task.DeletedAt != nil {
+			return apperrors.ErrNotFound.New("task not found")
+		}
+
+		now := time.Now()
+		if err := tService.taskRepo.DeleteTask(ctx, taskId, now); err != nil {
+			return err
+		}
+
+		if err := tService.reminderRepo.DeleteTaskReminders(ctx, taskId); err != nil {
+			return err
+		}
+
+		changes := map[string]models.FieldChange{
+			"deleted_at": {Before: nil, After: now},
+		}
+		task.DeletedAt = &now
+		history, err = tService.recordEvent(ctx, task, userId, models.TaskActionDeleted, changes, now)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	tService.emit(history, task)
+	return nil
+}
+
+func (tService *taskService) RestoreTask(ctx context.Context, taskId int, userId int) (*models.Task, error) {
+	var task *models.Task
+	var history *models.TaskEvent
+	err := tService.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		task, err = tService.lockOwnedTask(ctx, taskId, userId)
+		if err != nil {
+			return err
+		}
+		if task.DeletedAt == nil {
+			return apperrors.ErrConflict.New("task is not deleted")
+		}
+
+		now := time.Now()
+		if err := tService.taskRepo.RestoreTask(ctx, taskId, now); err != nil {
+			return err
+		}
+
+		changes := map[string]models.FieldChange{
+			"deleted_at": {Before: *task.DeletedAt, After: nil},
+		}
+		task.DeletedAt = nil
+		task.UpdatedAt = now
+
+		if err := tService.scheduleReminders(ctx, task, now); err != nil {
+			return err
+		}
+
+		history, err = tService.recordEvent(ctx, task, userId, models.TaskActionRestored, changes, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	task := &models.Task{
-		Title:     req.Title,
-		Completed: req.Completed,
-		UserId:    userId,
-		CreatedAt: existing.CreatedAt,
-		UpdatedAt: time.Now(),
+	tService.emit(history, task)
+	return task, nil
+}
+
+func (tService *taskService) GetTaskHistory(ctx context.Context, taskId int, userId int) ([]*models.TaskEvent, error) {
+	if _, err := tService.getOwnedTask(ctx, taskId, userId); err != nil {
+		return nil, err
 	}
+	return tService.eventRepo.GetTaskEvents(ctx, taskId)
+}
 
-	err = tService.taskRepo.UpdateTask(task)
+func (tService *taskService) GetTaskAsOf(ctx context.Context, taskId int, userId int, at time.Time) (*models.Task, error) {
+	events, err := tService.GetTaskHistory(ctx, taskId, userId)
 	if err != nil {
 		return nil, err
 	}
+	return replayTaskEvents(events, at)
+}
+
+// getOwnedTask loads a task, including soft deleted ones, and checks that it belongs to the user
+func (tService *taskService) getOwnedTask(ctx context.Context, taskId int, userId int) (*models.Task, error) {
+	task, err := tService.taskRepo.GetTaskById(ctx, taskId)
+	if err != nil {
+		return nil, err
+	}
+	if task.UserId != userId {
+		return nil, ErrPermissionDenied
+	}
 	return task, nil
 }
 
-func (tService *taskService) DeleteTask(taskId int, userId int) error {
-	task, err := tService.taskRepo.GetTaskById(taskId)
+// lockOwnedTask is getOwnedTask for a change: the task stays locked until the
+// transaction of ctx ends, so the history entry is computed from the version it replaces
+func (tService *taskService) lockOwnedTask(ctx context.Context, taskId int, userId int) (*models.Task, error) {
+	task, err := tService.taskRepo.GetTaskByIdForUpdate(ctx, taskId)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if task.UserId != userId {
-		return fmt.Errorf("unauthorized access to task")
+		return nil, ErrPermissionDenied
+	}
+	return task, nil
+}
+
+// scheduleReminders replaces the pending reminders of a task. Reminders whose time has
+// already passed are not scheduled.
+func (tService *taskService) scheduleReminders(ctx context.Context, task *models.Task, now time.Time) error {
+	if task.DeletedAt != nil || task.Completed || task.DueDate == nil || len(task.ReminderOffsets) == 0 {
+		return tService.reminderRepo.DeleteTaskReminders(ctx, task.ID)
+	}
+
+	var reminders []*models.Reminder
+	for _, offset := range task.ReminderOffsets {
+		fireAt := task.DueDate.Add(-time.Duration(offset) * time.Minute)
+		if fireAt.Before(now) {
+			continue
+		}
+		reminders = append(reminders, &models.Reminder{
+			TaskId:        task.ID,
+			UserId:        task.UserId,
+			OffsetMinutes: offset,
+			FireAt:        fireAt,
+		})
+	}
+
+	return tService.reminderRepo.ReplaceTaskReminders(ctx, task.ID, reminders)
+}
+
+// recordEvent stores the history entry of a change and queues its webhook deliveries,
+// in the transaction of the change. The caller emits it once the transaction
+// committed, so a rolled back or retried change is never published.
+func (tService *taskService) recordEvent(ctx context.Context, task *models.Task, actorId int, action string, changes map[string]models.FieldChange, at time.Time) (*models.TaskEvent, error) {
+	event := &models.TaskEvent{
+		TaskId:    task.ID,
+		UserId:    task.UserId,
+		ActorId:   actorId,
+		Action:    action,
+		Changes:   changes,
+		CreatedAt: at,
+	}
+
+	if err := tService.eventRepo.CreateTaskEvent(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to record task history: %w", err)
+	}
+
+	for _, published := range taskEvents(event, task) {
+		if err := queueWebhookDeliveries(ctx, tService.webhookRepo, published); err != nil {
+			return nil, err
+		}
+	}
+	return event, nil
+}
+
+// emit publishes the task events matching a history entry
+func (tService *taskService) emit(history *models.TaskEvent, task *models.Task) {
+	for _, event := range taskEvents(history, task) {
+		tService.emitter.Emit(event)
+	}
+}
+
+// GetEventsSince rebuilds the task events of a user recorded after the given event id,
+// so that a client can catch up after reconnecting. complete is false when more than
+// limit events were missed and the client should reload its tasks instead.
+func (tService *taskService) GetEventsSince(ctx context.Context, userId int, afterId int, limit int) ([]events.Event, bool, error) {
+	history, err := tService.eventRepo.GetUserTaskEvents(ctx, userId, afterId, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(history) > limit {
+		return nil, false, nil
+	}
+
+	// Every event carries the task as it was at that point, which needs the full task history
+	snapshots := make(map[int]map[int]*models.Task)
+	var missed []events.Event
+	for _, entry := range history {
+		if _, ok := snapshots[entry.TaskId]; !ok {
+			taskHistory, err := tService.eventRepo.GetTaskEvents(ctx, entry.TaskId)
+			if err != nil {
+				return nil, false, err
+			}
+			if snapshots[entry.TaskId], err = replaySnapshots(taskHistory); err != nil {
+				return nil, false, err
+			}
+		}
+		missed = append(missed, taskEvents(entry, snapshots[entry.TaskId][entry.ID])...)
+	}
+
+	return missed, true, nil
+}
+
+// normalizeReminderOffsets checks the reminder offsets of a task and returns them sorted
+// and without duplicates
+func normalizeReminderOffsets(dueDate *time.Time, offsets []int) ([]int, error) {
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+	if dueDate == nil {
+		return nil, apperrors.ErrBadRequest.New("reminders require a due date")
+	}
+
+	normalized := make([]int, 0, len(offsets))
+	for _, offset := range offsets {
+		if offset < 0 {
+			return nil, apperrors.ErrBadRequest.New("reminder offsets must not be negative")
+		}
+		if !containsInt(normalized, offset) {
+			normalized = append(normalized, offset)
+		}
+	}
+	sort.Ints(normalized)
+	return normalized, nil
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
-	return tService.taskRepo.DeleteTask(taskId)
+	return false
 }
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"task_API/internal/events"
+	"task_API/internal/models"
+	"task_API/internal/storage/memory"
+	"task_API/internal/storage/repositories"
+)
+
+// failingDeliveries refuses to queue deliveries
+type failingDeliveries struct {
+	repositories.WebhookRepository
+}
+
+func (repo failingDeliveries) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return errors.New("webhook_deliveries is unavailable")
+}
+
+func newTestTaskService(t *testing.T, webhookRepo func(*memory.Storage) repositories.WebhookRepository) (TaskService, *memory.Storage, *[]events.Event) {
+	t.Helper()
+
+	store := memory.NewStorage()
+	if err := store.Users().CreateUser(context.Background(), &models.User{Email: "owner@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+	subscription := &models.WebhookSubscription{UserId: 1, URL: "https://hooks.example.com", Secret: "top-secret", Active: true}
+	if err := store.Webhooks().CreateSubscription(context.Background(), subscription); err != nil {
+		t.Fatal(err)
+	}
+
+	published := &[]events.Event{}
+	bus := events.NewBus()
+	bus.Subscribe(func(event events.Event) { *published = append(*published, event) })
+
+	service := NewTaskService(store.Tasks(), store.TaskEvents(), store.Reminders(), webhookRepo(store), store.TxManager(), bus)
+	return service, store, published
+}
+
+func TestTaskChangesQueueWebhookDeliveriesInTheirTransaction(t *testing.T) {
+	service, store, published := newTestTaskService(t, (*memory.Storage).Webhooks)
+
+	task, err := service.CreateTask(context.Background(), &models.CreateTaskRequest{Title: "Write report"}, 1)
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	deliveries, err := store.Webhooks().GetDeliveries(context.Background(), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].EventType != events.TaskCreated {
+		t.Fatalf("deliveries = %+v, want one task.created", deliveries)
+	}
+	if len(*published) != 1 || (*published)[0].Task.ID != task.ID {
+		t.Errorf("published = %+v, want the created task", *published)
+	}
+}
+
+func TestTaskChangeRollsBackWhenItsDeliveriesCannotBeQueued(t *testing.T) {
+	service, store, published := newTestTaskService(t, func(store *memory.Storage) repositories.WebhookRepository {
+		return failingDeliveries{store.Webhooks()}
+	})
+
+	if _, err := service.CreateTask(context.Background(), &models.CreateTaskRequest{Title: "Write report"}, 1); err == nil {
+		t.Fatal("CreateTask() succeeded without queueing its delivery")
+	}
+
+	tasks, err := store.Tasks().GetAllTasks(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("tasks = %+v, want the creation rolled back", tasks)
+	}
+	if len(*published) != 0 {
+		t.Errorf("published = %+v, want nothing for a rolled back change", *published)
+	}
+}
+
+func TestWebhookPayloadIsTheVersionOneEvent(t *testing.T) {
+	service, store, _ := newTestTaskService(t, (*memory.Storage).Webhooks)
+
+	dueDate := time.Date(2026, time.October, 20, 9, 0, 0, 0, time.UTC)
+	task, err := service.CreateTask(context.Background(), &models.CreateTaskRequest{Title: "Write report", DueDate: &dueDate}, 1)
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	deliveries, err := store.Webhooks().GetDeliveries(context.Background(), 1, 10)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("deliveries = %+v, %v, want one", deliveries, err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(deliveries[0].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	// Receivers depend on these fields, changing them breaks every webhook consumer
+	if got, want := sortedKeys(payload), []string{"id", "occurred_at", "task", "type", "user_id"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("payload fields = %v, want %v", got, want)
+	}
+	body, _ := payload["task"].(map[string]interface{})
+	want := []string{"completed", "creation_time", "due_date", "id", "title", "updatation_time", "user_id"}
+	if got := sortedKeys(body); !reflect.DeepEqual(got, want) {
+		t.Errorf("task fields = %v, want %v", got, want)
+	}
+	if payload["type"] != events.TaskCreated || body["id"] != float64(task.ID) || body["title"] != "Write report" {
+		t.Errorf("payload = %s", deliveries[0].Payload)
+	}
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
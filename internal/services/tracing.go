@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"task_API/internal/events"
+	"task_API/internal/models"
+	"task_API/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// The Traced services wrap a service so that every call gets a span named after the
+// interface and the method, such as TaskService.CreateTask, between the span of the
+// request and those of its queries.
+
+func userAttribute(userId int) attribute.KeyValue {
+	return attribute.Int("user_id", userId)
+}
+
+func taskAttribute(taskId int) attribute.KeyValue {
+	return attribute.Int("task_id", taskId)
+}
+
+type tracedAuthService struct {
+	inner AuthService
+}
+
+func TracedAuthService(inner AuthService) AuthService {
+	return &tracedAuthService{inner: inner}
+}
+
+func (traced *tracedAuthService) Register(ctx context.Context, request *models.RegisterUserRequest) (response *models.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Register")
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.Register(ctx, request)
+}
+
+func (traced *tracedAuthService) Login(ctx context.Context, request *models.LoginUserRequest) (response *models.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.Login(ctx, request)
+}
+
+func (traced *tracedAuthService) ValidateToken(ctx context.Context, token string) (user *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ValidateToken")
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.ValidateToken(ctx, token)
+}
+
+func (traced *tracedAuthService) ChangePassword(ctx context.Context, userId int, request *models.ChangePasswordRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ChangePassword", userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.ChangePassword(ctx, userId, request)
+}
+
+type tracedTaskService struct {
+	inner TaskService
+}
+
+func TracedTaskService(inner TaskService) TaskService {
+	return &tracedTaskService{inner: inner}
+}
+
+func (traced *tracedTaskService) CreateTask(ctx context.Context, req *models.CreateTaskRequest, userId int) (task *models.Task, err error) {
+	ctx, span := tracing.Start(ctx, "TaskService.CreateTask", userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.CreateTask(ctx, req, userId)
+}
+
+func (traced *tracedTaskService) GetTaskById(ctx context.Context, taskId int, userId int) (task *models.Task, err error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTaskById", taskAttribute(taskId), userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.GetTaskById(ctx, taskId, userId)
+}
+
+func (traced *tracedTaskService) GetAllTasks(ctx context.Context, userId int) (tasks []*models.Task, err error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetAllTasks", userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.GetAllTasks(ctx, userId)
+}
+
+func (traced *tracedTaskService) UpdateTask(ctx context.Context, taskId int, req *models.UpdateTaskRequest, userId int) (task *models.Task, err error) {
+	ctx, span := tracing.Start(ctx, "TaskService.UpdateTask", taskAttribute(taskId), userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.UpdateTask(ctx, taskId, req, userId)
+}
+
+func (traced *tracedTaskService) DeleteTask(ctx context.Context, taskId int, userId int) (err error) {
+	ctx, span := tracing.Start(ctx, "TaskService.DeleteTask", taskAttribute(taskId), userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.DeleteTask(ctx, taskId, userId)
+}
+
+func (traced *tracedTaskService) RestoreTask(ctx context.Context, taskId int, userId int) (task *models.Task, err error) {
+	ctx, span := tracing.Start(ctx, "TaskService.RestoreTask", taskAttribute(taskId), userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.RestoreTask(ctx, taskId, userId)
+}
+
+func (traced *tracedTaskService) GetTaskHistory(ctx context.Context, taskId int, userId int) (history []*models.TaskEvent, err error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTaskHistory", taskAttribute(taskId), userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.GetTaskHistory(ctx, taskId, userId)
+}
+
+func (traced *tracedTaskService) GetTaskAsOf(ctx context.Context, taskId int, userId int, at time.Time) (task *models.Task, err error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTaskAsOf", taskAttribute(taskId), userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.GetTaskAsOf(ctx, taskId, userId, at)
+}
+
+func (traced *tracedTaskService) GetEventsSince(ctx context.Context, userId int, afterId int, limit int) (taskEvents []events.Event, complete bool, err error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetEventsSince", userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.GetEventsSince(ctx, userId, afterId, limit)
+}
+
+type tracedAuditService struct {
+	inner AuditService
+}
+
+func TracedAuditService(inner AuditService) AuditService {
+	return &tracedAuditService{inner: inner}
+}
+
+func (traced *tracedAuditService) Record(ctx context.Context, entry *models.AuditLog) {
+	ctx, span := tracing.Start(ctx, "AuditService.Record", attribute.String("event", entry.Event))
+	defer span.End()
+	traced.inner.Record(ctx, entry)
+}
+
+func (traced *tracedAuditService) GetAuditLogs(ctx context.Context, filter *models.AuditLogFilter) (logs []*models.AuditLog, err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.GetAuditLogs")
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.GetAuditLogs(ctx, filter)
+}
+
+func (traced *tracedAuditService) VerifyChain(ctx context.Context) (verification *models.AuditChainVerification, err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.VerifyChain")
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.VerifyChain(ctx)
+}
+
+type tracedWebhookService struct {
+	inner WebhookService
+}
+
+func TracedWebhookService(inner WebhookService) WebhookService {
+	return &tracedWebhookService{inner: inner}
+}
+
+func (traced *tracedWebhookService) CreateSubscription(ctx context.Context, req *models.CreateWebhookRequest, userId int) (subscription *models.WebhookSubscription, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.CreateSubscription", userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.CreateSubscription(ctx, req, userId)
+}
+
+func (traced *tracedWebhookService) GetSubscriptions(ctx context.Context, userId int) (subscriptions []*models.WebhookSubscription, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetSubscriptions", userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.GetSubscriptions(ctx, userId)
+}
+
+func (traced *tracedWebhookService) DeleteSubscription(ctx context.Context, subscriptionId int, userId int) (err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.DeleteSubscription", attribute.Int("subscription_id", subscriptionId), userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.DeleteSubscription(ctx, subscriptionId, userId)
+}
+
+func (traced *tracedWebhookService) GetDeliveries(ctx context.Context, subscriptionId int, userId int) (deliveries []*models.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetDeliveries", attribute.Int("subscription_id", subscriptionId), userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.GetDeliveries(ctx, subscriptionId, userId)
+}
+
+func (traced *tracedWebhookService) RetryDelivery(ctx context.Context, subscriptionId int, deliveryId int, userId int) (delivery *models.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.RetryDelivery", attribute.Int("subscription_id", subscriptionId), attribute.Int("delivery_id", deliveryId), userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.RetryDelivery(ctx, subscriptionId, deliveryId, userId)
+}
+
+type tracedNotificationService struct {
+	inner NotificationService
+}
+
+func TracedNotificationService(inner NotificationService) NotificationService {
+	return &tracedNotificationService{inner: inner}
+}
+
+func (traced *tracedNotificationService) GetNotifications(ctx context.Context, userId int, unreadOnly bool, limit int) (notifications []*models.Notification, err error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.GetNotifications", userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.GetNotifications(ctx, userId, unreadOnly, limit)
+}
+
+func (traced *tracedNotificationService) MarkRead(ctx context.Context, notificationId int, userId int) (err error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.MarkRead", attribute.Int("notification_id", notificationId), userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.MarkRead(ctx, notificationId, userId)
+}
+
+func (traced *tracedNotificationService) MarkAllRead(ctx context.Context, userId int) (count int, err error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.MarkAllRead", userAttribute(userId))
+	defer func() { tracing.End(span, err) }()
+	return traced.inner.MarkAllRead(ctx, userId)
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"task_API/internal/config"
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+	"task_API/pkg/logger"
+	"task_API/pkg/utils"
+)
+
+// Headers sent with every webhook request
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// WebhookDispatcher sends queued webhook deliveries in the background, retrying
+// failures with exponential backoff until they are dead-lettered
+type WebhookDispatcher struct {
+	webhookRepo repositories.WebhookRepository
+	config      config.WebhookConfig
+	client      *http.Client
+	logger      *logger.Logger
+	now         func() time.Time
+}
+
+// NewWebhookDispatcher returns a dispatcher that only sends to public addresses and to
+// internal ones in allowedNetworks
+func NewWebhookDispatcher(webhookRepo repositories.WebhookRepository, cfg config.WebhookConfig, allowedNetworks []netip.Prefix, appLogger *logger.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhookRepo: webhookRepo,
+		config:      cfg,
+		client:      newWebhookClient(cfg.RequestTimeout, allowedNetworks),
+		logger:      appLogger,
+		now:         time.Now,
+	}
+}
+
+// Run dispatches due deliveries every poll interval until the context is cancelled
+func (dispatcher *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := dispatcher.DispatchDue(ctx); err != nil {
+			dispatcher.logger.Error().Err(err).Msg("webhook dispatch failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue sends one batch of due deliveries and returns how many were attempted
+func (dispatcher *WebhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	// Deliveries of a batch are sent one after another, the lease has to cover all of them
+	lease := dispatcher.config.RequestTimeout * time.Duration(dispatcher.config.BatchSize+1)
+
+	deliveries, err := dispatcher.webhookRepo.ClaimDueDeliveries(ctx, dispatcher.now(), lease, dispatcher.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	subscriptions := make(map[int]*models.WebhookSubscription)
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptions[delivery.SubscriptionId]
+		if !ok {
+			if subscription, err = dispatcher.webhookRepo.GetSubscriptionById(ctx, delivery.SubscriptionId); err != nil {
+				dispatcher.logger.Error().Err(err).Int("delivery_id", delivery.ID).Msg("failed to load webhook subscription")
+				continue
+			}
+			subscriptions[delivery.SubscriptionId] = subscription
+		}
+
+		dispatcher.attempt(ctx, delivery, subscription)
+
+		if err := dispatcher.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			dispatcher.logger.Error().Err(err).Int("delivery_id", delivery.ID).Msg("failed to update webhook delivery")
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// attempt sends the delivery once and records the outcome on it
+func (dispatcher *WebhookDispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery, subscription *models.WebhookSubscription) {
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+
+	var err error
+	if subscription.Active {
+		var status int
+		status, err = dispatcher.send(ctx, delivery, subscription)
+		if status != 0 {
+			delivery.ResponseStatus = &status
+		}
+	} else {
+		err = fmt.Errorf("subscription is disabled")
+	}
+
+	now := dispatcher.now()
+	delivery.UpdatedAt = now
+
+	if err == nil {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if !subscription.Active || delivery.Attempts >= dispatcher.config.MaxAttempts {
+		delivery.Status = models.WebhookDeliveryDead
+		dispatcher.logger.Warn().Int("delivery_id", delivery.ID).Int("attempts", delivery.Attempts).Str("error", delivery.LastError).Msg("webhook delivery dead-lettered")
+		return
+	}
+	delivery.NextAttemptAt = now.Add(dispatcher.backoff(delivery.Attempts))
+}
+
+// send posts the signed payload and returns the response status code
+func (dispatcher *WebhookDispatcher) send(ctx context.Context, delivery *models.WebhookDelivery, subscription *models.WebhookSubscription) (int, error) {
+	timestamp := dispatcher.now().Unix()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "task-api-webhooks")
+	request.Header.Set(WebhookEventHeader, delivery.EventType)
+	request.Header.Set(WebhookDeliveryHeader, strconv.Itoa(delivery.ID))
+	request.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(WebhookSignatureHeader, "sha256="+utils.SignPayload(subscription.Secret, timestamp, delivery.Payload))
+
+	response, err := dispatcher.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("receiver responded with status %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+// backoff doubles the wait after every failed attempt, up to the configured maximum
+func (dispatcher *WebhookDispatcher) backoff(attempts int) time.Duration {
+	wait := dispatcher.config.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= dispatcher.config.MaxBackoff {
+			return dispatcher.config.MaxBackoff
+		}
+	}
+	return wait
+}
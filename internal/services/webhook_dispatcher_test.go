@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"task_API/internal/config"
+	"task_API/internal/models"
+	"task_API/pkg/logger"
+	"task_API/pkg/utils"
+)
+
+// fakeWebhookRepository keeps subscriptions and deliveries in memory
+type fakeWebhookRepository struct {
+	mutex         sync.Mutex
+	subscriptions map[int]*models.WebhookSubscription
+	deliveries    map[int]*models.WebhookDelivery
+}
+
+func newFakeWebhookRepository() *fakeWebhookRepository {
+	return &fakeWebhookRepository{
+		subscriptions: make(map[int]*models.WebhookSubscription),
+		deliveries:    make(map[int]*models.WebhookDelivery),
+	}
+}
+
+func (repo *fakeWebhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	subscription.ID = len(repo.subscriptions) + 1
+	repo.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (repo *fakeWebhookRepository) GetSubscriptionById(ctx context.Context, id int) (*models.WebhookSubscription, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	subscription, ok := repo.subscriptions[id]
+	if !ok {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	return subscription, nil
+}
+
+func (repo *fakeWebhookRepository) GetSubscriptions(ctx context.Context, userId int) ([]*models.WebhookSubscription, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	var subscriptions []*models.WebhookSubscription
+	for _, subscription := range repo.subscriptions {
+		if subscription.UserId == userId {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (repo *fakeWebhookRepository) DeleteSubscription(ctx context.Context, id int) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.subscriptions, id)
+	return nil
+}
+
+func (repo *fakeWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delivery.ID = len(repo.deliveries) + 1
+	repo.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (repo *fakeWebhookRepository) GetDeliveryById(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delivery, ok := repo.deliveries[id]
+	if !ok {
+		return nil, fmt.Errorf("webhook delivery not found")
+	}
+	return delivery, nil
+}
+
+func (repo *fakeWebhookRepository) GetDeliveries(ctx context.Context, subscriptionId int, limit int) ([]*models.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (repo *fakeWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	var due []*models.WebhookDelivery
+	for _, delivery := range repo.deliveries {
+		if delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+func (repo *fakeWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return nil
+}
+
+func (repo *fakeWebhookRepository) RequeueDeadDelivery(ctx context.Context, id int, now time.Time) (*models.WebhookDelivery, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+// loopbackNetworks allow the httptest receivers, which listen on loopback
+var loopbackNetworks = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+func newTestDispatcher(repo *fakeWebhookRepository, now *time.Time, allowedNetworks []netip.Prefix) *WebhookDispatcher {
+	dispatcher := NewWebhookDispatcher(repo, config.WebhookConfig{
+		MaxAttempts:    3,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Hour,
+		RequestTimeout: time.Second,
+		BatchSize:      10,
+	}, allowedNetworks, logger.NewLogger("disabled", "json"))
+	dispatcher.now = func() time.Time { return *now }
+	return dispatcher
+}
+
+func queueDelivery(t *testing.T, repo *fakeWebhookRepository, url string, now time.Time) *models.WebhookDelivery {
+	t.Helper()
+
+	subscription := &models.WebhookSubscription{UserId: 1, URL: url, Secret: "top-secret", Active: true}
+	if err := repo.CreateSubscription(context.Background(), subscription); err != nil {
+		t.Fatal(err)
+	}
+	delivery := &models.WebhookDelivery{
+		SubscriptionId: subscription.ID,
+		EventType:      "task.created",
+		Payload:        []byte(`{"type":"task.created"}`),
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  now,
+	}
+	if err := repo.CreateDelivery(context.Background(), delivery); err != nil {
+		t.Fatal(err)
+	}
+	return delivery
+}
+
+func TestWebhookDispatcherSignsPayload(t *testing.T) {
+	now := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+	received := make(chan *http.Request, 1)
+	var body []byte
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ = io.ReadAll(request.Body)
+		received <- request
+	}))
+	defer receiver.Close()
+
+	repo := newFakeWebhookRepository()
+	delivery := queueDelivery(t, repo, receiver.URL, now)
+
+	if _, err := newTestDispatcher(repo, &now, loopbackNetworks).DispatchDue(context.Background()); err != nil {
+		t.Fatalf("DispatchDue() error = %v", err)
+	}
+
+	request := <-received
+	timestamp, err := strconv.ParseInt(request.Header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid timestamp header: %v", err)
+	}
+	want := "sha256=" + utils.SignPayload("top-secret", timestamp, body)
+	if got := request.Header.Get(WebhookSignatureHeader); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if got := request.Header.Get(WebhookEventHeader); got != "task.created" {
+		t.Errorf("event header = %q, want task.created", got)
+	}
+	if delivery.Status != models.WebhookDeliveryDelivered || delivery.DeliveredAt == nil {
+		t.Errorf("delivery status = %q, want %q", delivery.Status, models.WebhookDeliveryDelivered)
+	}
+}
+
+func TestWebhookDispatcherRetriesThenDeadLetters(t *testing.T) {
+	now := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	repo := newFakeWebhookRepository()
+	delivery := queueDelivery(t, repo, receiver.URL, now)
+	dispatcher := newTestDispatcher(repo, &now, loopbackNetworks)
+
+	wantBackoff := []time.Duration{time.Minute, 2 * time.Minute}
+	for attempt, backoff := range wantBackoff {
+		if _, err := dispatcher.DispatchDue(context.Background()); err != nil {
+			t.Fatalf("DispatchDue() error = %v", err)
+		}
+		if delivery.Status != models.WebhookDeliveryPending {
+			t.Fatalf("attempt %d: status = %q, want pending", attempt+1, delivery.Status)
+		}
+		if got := delivery.NextAttemptAt.Sub(now); got != backoff {
+			t.Errorf("attempt %d: next attempt in %v, want %v", attempt+1, got, backoff)
+		}
+		if delivery.ResponseStatus == nil || *delivery.ResponseStatus != http.StatusServiceUnavailable {
+			t.Errorf("attempt %d: response status = %v, want 503", attempt+1, delivery.ResponseStatus)
+		}
+
+		// Nothing is due until the backoff has elapsed
+		if count, _ := dispatcher.DispatchDue(context.Background()); count != 0 {
+			t.Errorf("attempt %d: dispatched %d deliveries before the backoff elapsed", attempt+1, count)
+		}
+		now = delivery.NextAttemptAt
+	}
+
+	if _, err := dispatcher.DispatchDue(context.Background()); err != nil {
+		t.Fatalf("DispatchDue() error = %v", err)
+	}
+	if delivery.Status != models.WebhookDeliveryDead {
+		t.Errorf("status = %q after %d attempts, want %q", delivery.Status, delivery.Attempts, models.WebhookDeliveryDead)
+	}
+}
+
+func TestWebhookDispatcherRefusesInternalAddresses(t *testing.T) {
+	now := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+	hits := make(chan struct{}, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		hits <- struct{}{}
+	}))
+	defer receiver.Close()
+
+	repo := newFakeWebhookRepository()
+	// localhost resolves to loopback, which is only checked once dialing
+	delivery := queueDelivery(t, repo, strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1), now)
+
+	if _, err := newTestDispatcher(repo, &now, nil).DispatchDue(context.Background()); err != nil {
+		t.Fatalf("DispatchDue() error = %v", err)
+	}
+
+	select {
+	case <-hits:
+		t.Fatal("the webhook was sent to a loopback address")
+	default:
+	}
+	if delivery.Status != models.WebhookDeliveryPending || !strings.Contains(delivery.LastError, "not allowed") {
+		t.Errorf("status = %q, last error = %q, want a failed attempt", delivery.Status, delivery.LastError)
+	}
+}
+
+func TestWebhookDispatcherDoesNotFollowRedirects(t *testing.T) {
+	now := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+	hits := make(chan struct{}, 1)
+	target := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		hits <- struct{}{}
+	}))
+	defer target.Close()
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer receiver.Close()
+
+	repo := newFakeWebhookRepository()
+	delivery := queueDelivery(t, repo, receiver.URL, now)
+
+	if _, err := newTestDispatcher(repo, &now, loopbackNetworks).DispatchDue(context.Background()); err != nil {
+		t.Fatalf("DispatchDue() error = %v", err)
+	}
+
+	select {
+	case <-hits:
+		t.Fatal("the redirect was followed")
+	default:
+	}
+	if delivery.ResponseStatus == nil || *delivery.ResponseStatus != http.StatusTemporaryRedirect {
+		t.Errorf("response status = %v, want 307", delivery.ResponseStatus)
+	}
+	if delivery.Status != models.WebhookDeliveryPending {
+		t.Errorf("status = %q, want a failed attempt", delivery.Status)
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"task_API/pkg/utils"
+)
+
+// webhookAddressAllowed reports whether webhooks may be sent to addr: public addresses,
+// and internal ones in the allowed networks
+func webhookAddressAllowed(addr netip.Addr, allowedNetworks []netip.Prefix) bool {
+	return !utils.IsInternalIP(addr) || utils.InNetworks(addr, allowedNetworks)
+}
+
+// newWebhookClient returns a client that refuses to connect to internal addresses and
+// does not follow redirects. The address is checked when dialing, after DNS resolution,
+// so a host name that resolves to an internal address is refused as well.
+func newWebhookClient(timeout time.Duration, allowedNetworks []netip.Prefix) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("webhook address %q: %w", address, err)
+			}
+			if !webhookAddressAllowed(addrPort.Addr(), allowedNetworks) {
+				return fmt.Errorf("webhook address %s is internal and not allowed", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect on our behalf, past the check of the dialer
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// A redirect answers the delivery, it is recorded with its status as a failure
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
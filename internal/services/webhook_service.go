@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"task_API/internal/dto"
+	"task_API/internal/events"
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+	apperrors "task_API/pkg/errors"
+	"task_API/pkg/utils"
+)
+
+const (
+	webhookSecretSize      = 32
+	defaultDeliveryLogSize = 50
+	// webhookPayloadVersion is the API version of the task in webhook payloads. Receivers
+	// cannot choose a version, so it stays v1 until they can.
+	webhookPayloadVersion = dto.V1
+)
+
+type webhookService struct {
+	webhookRepo     repositories.WebhookRepository
+	allowedNetworks []netip.Prefix
+}
+
+// NewWebhookService returns the webhook service. URLs on internal addresses are refused
+// unless they are in allowedNetworks.
+func NewWebhookService(webhookRepo repositories.WebhookRepository, allowedNetworks []netip.Prefix) WebhookService {
+	return &webhookService{
+		webhookRepo:     webhookRepo,
+		allowedNetworks: allowedNetworks,
+	}
+}
+
+func (wService *webhookService) CreateSubscription(ctx context.Context, req *models.CreateWebhookRequest, userId int) (*models.WebhookSubscription, error) {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, apperrors.ErrBadRequest.New("webhook url must be an absolute http or https url")
+	}
+	// Host names are checked by the dispatcher once resolved
+	if addr, err := netip.ParseAddr(target.Hostname()); err == nil && !webhookAddressAllowed(addr, wService.allowedNetworks) {
+		return nil, apperrors.ErrBadRequest.New("webhook url must not point to an internal address")
+	}
+
+	eventTypes := []string{}
+	for _, eventType := range req.EventTypes {
+		if !events.IsTaskEventType(eventType) {
+			return nil, apperrors.ErrBadRequest.Newf("unknown event type %q", eventType)
+		}
+		if !containsString(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = utils.GenerateSecret(webhookSecretSize); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+	}
+
+	subscription := &models.WebhookSubscription{
+		UserId:     userId,
+		URL:        target.String(),
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     true,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := wService.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	// The secret is only returned when the subscription is created
+	return subscription, nil
+}
+
+func (wService *webhookService) GetSubscriptions(ctx context.Context, userId int) ([]*models.WebhookSubscription, error) {
+	subscriptions, err := wService.webhookRepo.GetSubscriptions(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+	return subscriptions, nil
+}
+
+func (wService *webhookService) DeleteSubscription(ctx context.Context, subscriptionId int, userId int) error {
+	if _, err := wService.getOwnedSubscription(ctx, subscriptionId, userId); err != nil {
+		return err
+	}
+	return wService.webhookRepo.DeleteSubscription(ctx, subscriptionId)
+}
+
+func (wService *webhookService) GetDeliveries(ctx context.Context, subscriptionId int, userId int) ([]*models.WebhookDelivery, error) {
+	if _, err := wService.getOwnedSubscription(ctx, subscriptionId, userId); err != nil {
+		return nil, err
+	}
+	return wService.webhookRepo.GetDeliveries(ctx, subscriptionId, defaultDeliveryLogSize)
+}
+
+// RetryDelivery puts a dead delivery back in the queue with a fresh retry budget. Other
+// deliveries are a conflict, a pending one may be in the hands of a dispatcher.
+func (wService *webhookService) RetryDelivery(ctx context.Context, subscriptionId int, deliveryId int, userId int) (*models.WebhookDelivery, error) {
+	if _, err := wService.getOwnedSubscription(ctx, subscriptionId, userId); err != nil {
+		return nil, err
+	}
+
+	delivery, err := wService.webhookRepo.GetDeliveryById(ctx, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.SubscriptionId != subscriptionId {
+		return nil, apperrors.ErrNotFound.New("webhook delivery not found")
+	}
+
+	return wService.webhookRepo.RequeueDeadDelivery(ctx, deliveryId, time.Now())
+}
+
+// queueWebhookDeliveries queues a delivery of the event for every matching subscription
+// of its owner. The task service calls it inside the transaction of the change, so the
+// deliveries are stored or rolled back together with it and none is lost in between.
+func queueWebhookDeliveries(ctx context.Context, webhookRepo repositories.WebhookRepository, event events.Event) error {
+	subscriptions, err := webhookRepo.GetSubscriptions(ctx, event.UserId)
+	if err != nil {
+		return fmt.Errorf("failed to load webhook subscriptions: %w", err)
+	}
+
+	var payload []byte
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		if !subscription.Active || !subscribesTo(subscription, event.Type) {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = json.Marshal(dto.NewEvent(webhookPayloadVersion, event)); err != nil {
+				return fmt.Errorf("failed to encode webhook payload: %w", err)
+			}
+		}
+
+		delivery := &models.WebhookDelivery{
+			SubscriptionId: subscription.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+func (wService *webhookService) getOwnedSubscription(ctx context.Context, subscriptionId int, userId int) (*models.WebhookSubscription, error) {
+	subscription, err := wService.webhookRepo.GetSubscriptionById(ctx, subscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.UserId != userId {
+		return nil, ErrPermissionDenied
+	}
+	return subscription, nil
+}
+
+// subscribesTo reports whether the subscription wants the event type. No types means all of them.
+func subscribesTo(subscription *models.WebhookSubscription, eventType string) bool {
+	return len(subscription.EventTypes) == 0 || containsString(subscription.EventTypes, eventType)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
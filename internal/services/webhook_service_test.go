@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"task_API/internal/models"
+	apperrors "task_API/pkg/errors"
+)
+
+func TestCreateSubscriptionRefusesInternalAddresses(t *testing.T) {
+	allowed := []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}
+	service := NewWebhookService(newFakeWebhookRepository(), allowed)
+
+	tests := []struct {
+		url     string
+		refused bool
+	}{
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://192.168.1.10/hook", true},
+		{"http://0.0.0.0/hook", true},
+		{"http://[::ffff:10.0.0.1]/hook", true},
+		{"http://10.1.2.3/hook", false},
+		{"https://203.0.113.7/hook", false},
+		// Host names are checked once resolved, when sending
+		{"https://hooks.example.com/hook", false},
+	}
+	for _, test := range tests {
+		_, err := service.CreateSubscription(context.Background(), &models.CreateWebhookRequest{URL: test.url}, 1)
+		if refused := errors.Is(err, apperrors.ErrBadRequest); refused != test.refused || (!test.refused && err != nil) {
+			t.Errorf("CreateSubscription(%q) error = %v, want refused %v", test.url, err, test.refused)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"task_API/internal/models"
+)
+
+type auditLogRepository struct {
+	storage *Storage
+}
+
+// AppendAuditLog links the entry to the end of the hash chain and stores it
+func (repo *auditLogRepository) AppendAuditLog(ctx context.Context, entry *models.AuditLog) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	prevHash := models.AuditGenesisHash
+	if count := len(repo.storage.auditLogs); count > 0 {
+		prevHash = repo.storage.auditLogs[count-1].Hash
+	}
+
+	// Same precision as Postgres, so chains can be compared across backends
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+	entry.PrevHash = prevHash
+	entry.Hash = entry.ComputeHash()
+	entry.ID = repo.storage.nextId("audit_logs")
+
+	stored := *entry
+	stored.UserId = copyInt(entry.UserId)
+	appendRow(repo.storage, &repo.storage.auditLogs, stored)
+	return nil
+}
+
+// GetAuditLogs returns the entries matching the filter, newest first
+func (repo *auditLogRepository) GetAuditLogs(ctx context.Context, filter *models.AuditLogFilter) ([]*models.AuditLog, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	var entries []*models.AuditLog
+	for index := len(repo.storage.auditLogs) - 1; index >= 0 && len(entries) < filter.Limit; index-- {
+		entry := repo.storage.auditLogs[index]
+		if matchesAuditFilter(&entry, filter) {
+			entry.UserId = copyInt(entry.UserId)
+			entries = append(entries, &entry)
+		}
+	}
+	return entries, nil
+}
+
+// GetAuditChain returns up to limit entries after the given id, oldest first
+func (repo *auditLogRepository) GetAuditChain(ctx context.Context, afterId int, limit int) ([]*models.AuditLog, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	var entries []*models.AuditLog
+	for _, entry := range repo.storage.auditLogs {
+		if len(entries) == limit {
+			break
+		}
+		if entry.ID > afterId {
+			entry.UserId = copyInt(entry.UserId)
+			entries = append(entries, &entry)
+		}
+	}
+	return entries, nil
+}
+
+func matchesAuditFilter(entry *models.AuditLog, filter *models.AuditLogFilter) bool {
+	if filter.UserId != nil && (entry.UserId == nil || *entry.UserId != *filter.UserId) {
+		return false
+	}
+	if filter.Event != "" && entry.Event != filter.Event {
+		return false
+	}
+	if filter.Success != nil && entry.Success != *filter.Success {
+		return false
+	}
+	if filter.IPAddress != "" && entry.IPAddress != filter.IPAddress {
+		return false
+	}
+	if filter.Since != nil && entry.CreatedAt.Before(*filter.Since) {
+		return false
+	}
+	if filter.Until != nil && !entry.CreatedAt.Before(*filter.Until) {
+		return false
+	}
+	if filter.BeforeId > 0 && entry.ID >= filter.BeforeId {
+		return false
+	}
+	return true
+}
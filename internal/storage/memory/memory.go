@@ -0,0 +1,246 @@
+// Package memory implements the repositories in process memory. Data is lost on
+// restart, which makes it suited to development and tests.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+)
+
+// Storage keeps every table in maps guarded by a single mutex, so operations spanning
+// several tables are atomic like their Postgres transactions
+type Storage struct {
+	mutex sync.Mutex
+
+	users         map[int]models.User
+	tasks         map[int]models.Task
+	taskEvents    []models.TaskEvent
+	auditLogs     []models.AuditLog
+	subscriptions map[int]models.WebhookSubscription
+	deliveries    map[int]delivery
+	reminders     map[int]models.Reminder
+	notifications map[int]models.Notification
+
+	// Last id handed out per table, ids start at 1 like SERIAL columns
+	lastIds map[string]int
+
+	// logging is set while a transaction is open, undoLog then holds how to undo each
+	// of its changes
+	logging bool
+	undoLog []func()
+}
+
+// delivery is a webhook delivery with its lease
+type delivery struct {
+	models.WebhookDelivery
+	lockedUntil *time.Time
+}
+
+func NewStorage() *Storage {
+	return &Storage{
+		users:         make(map[int]models.User),
+		tasks:         make(map[int]models.Task),
+		subscriptions: make(map[int]models.WebhookSubscription),
+		deliveries:    make(map[int]delivery),
+		reminders:     make(map[int]models.Reminder),
+		notifications: make(map[int]models.Notification),
+		lastIds:       make(map[string]int),
+	}
+}
+
+func (storage *Storage) Users() repositories.UserRepository { return &userRepository{storage} }
+
+func (storage *Storage) Tasks() repositories.TaskRepository { return &taskRepository{storage} }
+
+func (storage *Storage) TaskEvents() repositories.TaskEventRepository {
+	return &taskEventRepository{storage}
+}
+
+func (storage *Storage) AuditLogs() repositories.AuditLogRepository {
+	return &auditLogRepository{storage}
+}
+
+func (storage *Storage) Webhooks() repositories.WebhookRepository {
+	return &webhookRepository{storage}
+}
+
+func (storage *Storage) Reminders() repositories.ReminderRepository {
+	return &reminderRepository{storage}
+}
+
+func (storage *Storage) Notifications() repositories.NotificationRepository {
+	return &notificationRepository{storage}
+}
+
+func (storage *Storage) TxManager() repositories.TxManager { return storage }
+
+// Migrate has nothing to do, the maps are the schema
+func (storage *Storage) Migrate(ctx context.Context) error {
+	return nil
+}
+
+// Ping always succeeds, the data lives in the process
+func (storage *Storage) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// SchemaVersion reports no migrations, there is no schema to migrate
+func (storage *Storage) SchemaVersion(ctx context.Context) (int, int, error) {
+	return 0, 0, nil
+}
+
+func (storage *Storage) Close() error {
+	return nil
+}
+
+// lock takes the mutex unless the context is already done, the way a database call
+// fails with a cancelled context. Inside WithinTx the transaction holds it already.
+func (storage *Storage) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if storage.inTx(ctx) {
+		return nil
+	}
+	storage.mutex.Lock()
+	return nil
+}
+
+// unlock releases the mutex taken by lock
+func (storage *Storage) unlock(ctx context.Context) {
+	if !storage.inTx(ctx) {
+		storage.mutex.Unlock()
+	}
+}
+
+// txKey marks the context of a transaction on this storage
+type txKey struct {
+	storage *Storage
+}
+
+// transaction is open while its WithinTx runs, a context that outlives it takes the
+// mutex again
+type transaction struct {
+	open bool
+}
+
+func (storage *Storage) inTx(ctx context.Context) bool {
+	tx, ok := ctx.Value(txKey{storage}).(*transaction)
+	return ok && tx.open
+}
+
+// WithinTx holds the mutex while fn runs, so the transaction is serializable and never
+// has to be retried. When fn fails its changes are undone, a nested WithinTx undoes
+// only what it changed itself. Like sequences, ids handed out in a rolled back
+// transaction are not reused.
+func (storage *Storage) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if storage.inTx(ctx) {
+		return storage.savepoint(ctx, fn)
+	}
+
+	if err := storage.lock(ctx); err != nil {
+		return err
+	}
+	defer storage.mutex.Unlock()
+
+	tx := &transaction{open: true}
+	storage.logging = true
+	defer func() {
+		tx.open = false
+		storage.logging = false
+		storage.undoLog = nil
+	}()
+
+	return storage.savepoint(context.WithValue(ctx, txKey{storage}, tx), fn)
+}
+
+// savepoint runs fn and undoes its changes when it fails or panics. The mutex must be held.
+func (storage *Storage) savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	mark := len(storage.undoLog)
+	committed := false
+	defer func() {
+		if !committed {
+			storage.rollbackTo(mark)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// rollbackTo undoes the changes logged after mark, latest first. The mutex must be held.
+func (storage *Storage) rollbackTo(mark int) {
+	for i := len(storage.undoLog) - 1; i >= mark; i-- {
+		storage.undoLog[i]()
+	}
+	storage.undoLog = storage.undoLog[:mark]
+}
+
+// onRollback logs how to undo a change when a transaction is open, so a rollback
+// costs as much as the transaction wrote rather than the size of the tables
+func (storage *Storage) onRollback(undo func()) {
+	if storage.logging {
+		storage.undoLog = append(storage.undoLog, undo)
+	}
+}
+
+// put stores row under id in table. Rows are never changed in place, a change stores
+// a new row, so the one it replaces can be put back. The mutex must be held.
+func put[Row any](storage *Storage, table map[int]Row, id int, row Row) {
+	saveRow(storage, table, id)
+	table[id] = row
+}
+
+// remove deletes the row id of table. The mutex must be held.
+func remove[Row any](storage *Storage, table map[int]Row, id int) {
+	saveRow(storage, table, id)
+	delete(table, id)
+}
+
+// appendRow adds row to a table that is only appended to. The mutex must be held.
+func appendRow[Row any](storage *Storage, table *[]Row, row Row) {
+	length := len(*table)
+	storage.onRollback(func() { *table = (*table)[:length] })
+	*table = append(*table, row)
+}
+
+// saveRow logs how to put the row id of table back the way it is now
+func saveRow[Row any](storage *Storage, table map[int]Row, id int) {
+	previous, existed := table[id]
+	storage.onRollback(func() {
+		if existed {
+			table[id] = previous
+		} else {
+			delete(table, id)
+		}
+	})
+}
+
+// nextId returns the next id of a table. The mutex must be held.
+func (storage *Storage) nextId(table string) int {
+	storage.lastIds[table]++
+	return storage.lastIds[table]
+}
+
+func copyTime(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+func copyInt(value *int) *int {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		return NewStorage()
+	})
+}
+
+var errRollback = errors.New("rollback")
+
+// seed stores a user with a task, a webhook subscription and a pending delivery
+func seed(t *testing.T, storage *Storage) (*models.Task, *models.WebhookDelivery) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+
+	if err := storage.Users().CreateUser(ctx, &models.User{Email: "owner@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+	task := &models.Task{Title: "Write report", UserId: 1, CreatedAt: now, UpdatedAt: now}
+	if err := storage.Tasks().CreateTask(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Webhooks().CreateSubscription(ctx, &models.WebhookSubscription{UserId: 1, URL: "https://hooks.example.com", Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	delivery := &models.WebhookDelivery{SubscriptionId: 1, EventType: "task.created", Payload: []byte(`{}`), Status: models.WebhookDeliveryPending, NextAttemptAt: now}
+	if err := storage.Webhooks().CreateDelivery(ctx, delivery); err != nil {
+		t.Fatal(err)
+	}
+	return task, delivery
+}
+
+func TestRollbackUndoesEveryKindOfWrite(t *testing.T) {
+	storage := NewStorage()
+	task, delivery := seed(t, storage)
+	if len(storage.undoLog) != 0 {
+		t.Fatalf("writes outside a transaction logged %d undo entries", len(storage.undoLog))
+	}
+
+	err := storage.WithinTx(context.Background(), func(ctx context.Context) error {
+		updated := *task
+		updated.Title = "Changed"
+		if err := storage.Tasks().UpdateTask(ctx, &updated); err != nil {
+			return err
+		}
+		if err := storage.Tasks().CreateTask(ctx, &models.Task{Title: "Added", UserId: 1}); err != nil {
+			return err
+		}
+		delivered := *delivery
+		delivered.Status = models.WebhookDeliveryDelivered
+		if err := storage.Webhooks().UpdateDelivery(ctx, &delivered); err != nil {
+			return err
+		}
+		if err := storage.AuditLogs().AppendAuditLog(ctx, &models.AuditLog{Event: models.AuditEventLoginSuccess, CreatedAt: time.Now()}); err != nil {
+			return err
+		}
+		// Also deletes the deliveries of the subscription
+		if err := storage.Webhooks().DeleteSubscription(ctx, 1); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("WithinTx() error = %v, want the error of fn", err)
+	}
+
+	if stored := storage.tasks[task.ID]; stored.Title != "Write report" {
+		t.Errorf("task title = %q, want the update undone", stored.Title)
+	}
+	if len(storage.tasks) != 1 {
+		t.Errorf("tasks = %d, want the insert undone", len(storage.tasks))
+	}
+	if _, ok := storage.subscriptions[1]; !ok {
+		t.Error("subscription is gone, want the delete undone")
+	}
+	if stored, ok := storage.deliveries[delivery.ID]; !ok || stored.Status != models.WebhookDeliveryPending {
+		t.Errorf("delivery = %+v, %v, want it pending again", stored, ok)
+	}
+	if len(storage.auditLogs) != 0 {
+		t.Errorf("audit logs = %d, want the append undone", len(storage.auditLogs))
+	}
+	if storage.undoLog != nil || storage.logging {
+		t.Error("the undo log outlived the transaction")
+	}
+}
+
+func TestNestedRollbackKeepsTheWritesBeforeIt(t *testing.T) {
+	storage := NewStorage()
+	task, _ := seed(t, storage)
+
+	err := storage.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := storage.Tasks().DeleteTask(ctx, task.ID, time.Now()); err != nil {
+			return err
+		}
+		logged := len(storage.undoLog)
+
+		err := storage.WithinTx(ctx, func(ctx context.Context) error {
+			if err := storage.Tasks().RestoreTask(ctx, task.ID, time.Now()); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Errorf("nested WithinTx() error = %v, want the error of fn", err)
+		}
+		// Only the entries of the savepoint are consumed
+		if len(storage.undoLog) != logged {
+			t.Errorf("undo log = %d entries, want the %d of the outer writes", len(storage.undoLog), logged)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+
+	if storage.tasks[task.ID].DeletedAt == nil {
+		t.Error("task is not deleted, want the outer delete committed")
+	}
+}
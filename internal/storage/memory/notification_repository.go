@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"task_API/internal/models"
+	apperrors "task_API/pkg/errors"
+)
+
+type notificationRepository struct {
+	storage *Storage
+}
+
+// GetNotifications returns the notifications of a user, newest first
+func (repo *notificationRepository) GetNotifications(ctx context.Context, userId int, unreadOnly bool, limit int) ([]*models.Notification, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	var notifications []*models.Notification
+	for _, notification := range repo.storage.notifications {
+		if notification.UserId != userId || (unreadOnly && notification.ReadAt != nil) {
+			continue
+		}
+		notification.TaskId = copyInt(notification.TaskId)
+		notification.ReadAt = copyTime(notification.ReadAt)
+		notifications = append(notifications, &notification)
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].ID > notifications[j].ID
+	})
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+	}
+	return notifications, nil
+}
+
+// MarkNotificationRead marks one notification of the user as read, keeping the first read time
+func (repo *notificationRepository) MarkNotificationRead(ctx context.Context, id int, userId int, readAt time.Time) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	notification, ok := repo.storage.notifications[id]
+	if !ok || notification.UserId != userId {
+		return apperrors.ErrNotFound.New("notification not found")
+	}
+	if notification.ReadAt == nil {
+		notification.ReadAt = &readAt
+		put(repo.storage, repo.storage.notifications, id, notification)
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user as read and returns how many there were
+func (repo *notificationRepository) MarkAllNotificationsRead(ctx context.Context, userId int, readAt time.Time) (int, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	marked := 0
+	for id, notification := range repo.storage.notifications {
+		if notification.UserId == userId && notification.ReadAt == nil {
+			notification.ReadAt = &readAt
+			put(repo.storage, repo.storage.notifications, id, notification)
+			marked++
+		}
+	}
+	return marked, nil
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"task_API/internal/models"
+)
+
+type reminderRepository struct {
+	storage *Storage
+}
+
+// ReplaceTaskReminders swaps the pending reminders of a task for the given ones
+func (repo *reminderRepository) ReplaceTaskReminders(ctx context.Context, taskId int, reminders []*models.Reminder) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	repo.storage.deletePendingRemindersLocked(taskId)
+	for _, reminder := range reminders {
+		reminder.ID = repo.storage.nextId("task_reminders")
+		stored := *reminder
+		stored.SentAt = copyTime(reminder.SentAt)
+		put(repo.storage, repo.storage.reminders, reminder.ID, stored)
+	}
+	return nil
+}
+
+// DeleteTaskReminders removes the reminders of a task that have not been sent yet
+func (repo *reminderRepository) DeleteTaskReminders(ctx context.Context, taskId int) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	repo.storage.deletePendingRemindersLocked(taskId)
+	return nil
+}
+
+// FireDueReminders marks up to limit due reminders as sent and stores the notification
+// that compose builds for each of them. compose may return nil to drop a reminder
+// without notifying. It returns the notifications and how many reminders fired.
+func (repo *reminderRepository) FireDueReminders(ctx context.Context, now time.Time, limit int, compose func(due *models.DueReminder) *models.Notification) ([]*models.Notification, int, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, 0, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	var due []models.Reminder
+	for _, reminder := range repo.storage.reminders {
+		if reminder.SentAt == nil && !reminder.FireAt.After(now) {
+			due = append(due, reminder)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].FireAt.Equal(due[j].FireAt) {
+			return due[i].FireAt.Before(due[j].FireAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	var notifications []*models.Notification
+	fired := 0
+	for _, reminder := range due {
+		task, ok := repo.storage.tasks[reminder.TaskId]
+		if !ok {
+			continue
+		}
+
+		dueReminder := &models.DueReminder{Reminder: reminder, Task: copyTask(&task)}
+		if notification := compose(dueReminder); notification != nil {
+			notification.ID = repo.storage.nextId("notifications")
+			stored := *notification
+			stored.TaskId = copyInt(notification.TaskId)
+			stored.ReadAt = copyTime(notification.ReadAt)
+			put(repo.storage, repo.storage.notifications, notification.ID, stored)
+			notifications = append(notifications, notification)
+		}
+
+		sentAt := now
+		reminder.SentAt = &sentAt
+		put(repo.storage, repo.storage.reminders, reminder.ID, reminder)
+		fired++
+	}
+	return notifications, fired, nil
+}
+
+// deletePendingRemindersLocked removes the unsent reminders of a task. The mutex must be held.
+func (storage *Storage) deletePendingRemindersLocked(taskId int) {
+	for id, reminder := range storage.reminders {
+		if reminder.TaskId == taskId && reminder.SentAt == nil {
+			remove(storage, storage.reminders, id)
+		}
+	}
+}
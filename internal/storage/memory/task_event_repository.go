@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"task_API/internal/models"
+)
+
+type taskEventRepository struct {
+	storage *Storage
+}
+
+// CreateTaskEvent appends an event to the task history
+func (repo *taskEventRepository) CreateTaskEvent(ctx context.Context, event *models.TaskEvent) error {
+	// Changes go through JSON like the Postgres JSONB column, so history replay sees
+	// the same value types from every backend
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode task changes: %w", err)
+	}
+
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	stored := *event
+	stored.Changes = nil
+	if err := json.Unmarshal(changes, &stored.Changes); err != nil {
+		return fmt.Errorf("failed to decode task changes: %w", err)
+	}
+
+	stored.ID = repo.storage.nextId("task_events")
+	event.ID = stored.ID
+	appendRow(repo.storage, &repo.storage.taskEvents, stored)
+	return nil
+}
+
+// GetTaskEvents returns the history of a task, oldest event first
+func (repo *taskEventRepository) GetTaskEvents(ctx context.Context, taskId int) ([]*models.TaskEvent, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	var events []*models.TaskEvent
+	for _, event := range repo.storage.taskEvents {
+		if event.TaskId == taskId {
+			events = append(events, copyTaskEvent(event))
+		}
+	}
+	return events, nil
+}
+
+// GetUserTaskEvents returns up to limit events on the tasks of a user that were
+// recorded after the given event id, oldest first
+func (repo *taskEventRepository) GetUserTaskEvents(ctx context.Context, userId int, afterId int, limit int) ([]*models.TaskEvent, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	var events []*models.TaskEvent
+	for _, event := range repo.storage.taskEvents {
+		if len(events) == limit {
+			break
+		}
+		if event.UserId == userId && event.ID > afterId {
+			events = append(events, copyTaskEvent(event))
+		}
+	}
+	return events, nil
+}
+
+func copyTaskEvent(event models.TaskEvent) *models.TaskEvent {
+	changes := make(map[string]models.FieldChange, len(event.Changes))
+	for field, change := range event.Changes {
+		changes[field] = change
+	}
+	event.Changes = changes
+	return &event
+}
@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"task_API/internal/models"
+	apperrors "task_API/pkg/errors"
+)
+
+type taskRepository struct {
+	storage *Storage
+}
+
+func (repo *taskRepository) CreateTask(ctx context.Context, task *models.Task) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	if _, ok := repo.storage.users[task.UserId]; !ok {
+		return fmt.Errorf("failed to create task: user %d does not exist", task.UserId)
+	}
+
+	task.ID = repo.storage.nextId("tasks")
+	put(repo.storage, repo.storage.tasks, task.ID, copyTask(task))
+	return nil
+}
+
+// GetAllTasks returns the tasks of the user that are not deleted, newest first
+func (repo *taskRepository) GetAllTasks(ctx context.Context, userId int) ([]*models.Task, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	var tasks []*models.Task
+	for _, task := range repo.storage.tasks {
+		if task.UserId == userId && task.DeletedAt == nil {
+			copied := copyTask(&task)
+			tasks = append(tasks, &copied)
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID > tasks[j].ID
+	})
+	return tasks, nil
+}
+
+// GetTaskById returns the task, including when it is soft deleted
+func (repo *taskRepository) GetTaskById(ctx context.Context, id int) (*models.Task, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	task, ok := repo.storage.tasks[id]
+	if !ok {
+		return &models.Task{}, apperrors.ErrNotFound.New("task not found")
+	}
+	copied := copyTask(&task)
+	return &copied, nil
+}
+
+// GetTaskByIdForUpdate needs no lock of its own, a transaction holds the mutex
+func (repo *taskRepository) GetTaskByIdForUpdate(ctx context.Context, id int) (*models.Task, error) {
+	return repo.GetTaskById(ctx, id)
+}
+
+func (repo *taskRepository) UpdateTask(ctx context.Context, task *models.Task) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	existing, ok := repo.storage.tasks[task.ID]
+	if !ok || existing.UserId != task.UserId || existing.DeletedAt != nil {
+		return apperrors.ErrNotFound.New("task not found")
+	}
+
+	existing.Title = task.Title
+	existing.Completed = task.Completed
+	existing.DueDate = copyTime(task.DueDate)
+	existing.ReminderOffsets = append([]int{}, task.ReminderOffsets...)
+	existing.UpdatedAt = task.UpdatedAt
+	put(repo.storage, repo.storage.tasks, task.ID, existing)
+
+	task.CreatedAt = existing.CreatedAt
+	return nil
+}
+
+// DeleteTask soft deletes the task so it can be restored later
+func (repo *taskRepository) DeleteTask(ctx context.Context, id int, deletedAt time.Time) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	task, ok := repo.storage.tasks[id]
+	if !ok || task.DeletedAt != nil {
+		return apperrors.ErrNotFound.New("task not found")
+	}
+
+	task.DeletedAt = &deletedAt
+	put(repo.storage, repo.storage.tasks, id, task)
+	return nil
+}
+
+// RestoreTask clears the deletion mark of a soft deleted task
+func (repo *taskRepository) RestoreTask(ctx context.Context, id int, restoredAt time.Time) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	task, ok := repo.storage.tasks[id]
+	if !ok || task.DeletedAt == nil {
+		return apperrors.ErrNotFound.New("deleted task not found")
+	}
+
+	task.DeletedAt = nil
+	task.UpdatedAt = restoredAt
+	put(repo.storage, repo.storage.tasks, id, task)
+	return nil
+}
+
+// deleteTaskLocked removes a task with its history and reminders. Notifications keep
+// existing without the task. The mutex must be held.
+func (storage *Storage) deleteTaskLocked(id int) {
+	remove(storage, storage.tasks, id)
+
+	events := storage.taskEvents[:0]
+	for _, event := range storage.taskEvents {
+		if event.TaskId != id {
+			events = append(events, event)
+		}
+	}
+	storage.taskEvents = events
+
+	for reminderId, reminder := range storage.reminders {
+		if reminder.TaskId == id {
+			remove(storage, storage.reminders, reminderId)
+		}
+	}
+	for notificationId, notification := range storage.notifications {
+		if notification.TaskId != nil && *notification.TaskId == id {
+			notification.TaskId = nil
+			put(storage, storage.notifications, notificationId, notification)
+		}
+	}
+}
+
+// copyTask copies a task so callers cannot change the stored one. A missing offset
+// list comes back empty, as it does from Postgres.
+func copyTask(task *models.Task) models.Task {
+	copied := *task
+	copied.DueDate = copyTime(task.DueDate)
+	copied.DeletedAt = copyTime(task.DeletedAt)
+	copied.ReminderOffsets = append([]int{}, task.ReminderOffsets...)
+	return copied
+}
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+
+	"task_API/internal/models"
+	apperrors "task_API/pkg/errors"
+)
+
+type userRepository struct {
+	storage *Storage
+}
+
+func (repo *userRepository) CreateUser(ctx context.Context, user *models.User) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	for _, existing := range repo.storage.users {
+		if existing.Email == user.Email {
+			return apperrors.ErrAlreadyExists.New("email already exists")
+		}
+	}
+
+	user.ID = repo.storage.nextId("users")
+	put(repo.storage, repo.storage.users, user.ID, *user)
+	return nil
+}
+
+func (repo *userRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	for _, user := range repo.storage.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, apperrors.ErrNotFound.New("user not found")
+}
+
+func (repo *userRepository) GetUserById(ctx context.Context, id int) (*models.User, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	user, ok := repo.storage.users[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound.New("user not found")
+	}
+	return &user, nil
+}
+
+func (repo *userRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	existing, ok := repo.storage.users[user.ID]
+	if !ok {
+		return apperrors.ErrNotFound.New("user not found")
+	}
+	for _, other := range repo.storage.users {
+		if other.ID != user.ID && other.Email == user.Email {
+			return apperrors.ErrAlreadyExists.New("email already exists")
+		}
+	}
+
+	user.CreatedAt = existing.CreatedAt
+	put(repo.storage, repo.storage.users, user.ID, *user)
+	return nil
+}
+
+// DeleteUser removes the user and, like the foreign keys in Postgres, everything they own
+func (repo *userRepository) DeleteUser(ctx context.Context, id int) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	remove(repo.storage, repo.storage.users, id)
+
+	for taskId, task := range repo.storage.tasks {
+		if task.UserId == id {
+			repo.storage.deleteTaskLocked(taskId)
+		}
+	}
+	events := repo.storage.taskEvents[:0]
+	for _, event := range repo.storage.taskEvents {
+		if event.UserId != id {
+			events = append(events, event)
+		}
+	}
+	repo.storage.taskEvents = events
+
+	for subscriptionId, subscription := range repo.storage.subscriptions {
+		if subscription.UserId == id {
+			repo.storage.deleteSubscriptionLocked(subscriptionId)
+		}
+	}
+	for reminderId, reminder := range repo.storage.reminders {
+		if reminder.UserId == id {
+			remove(repo.storage, repo.storage.reminders, reminderId)
+		}
+	}
+	for notificationId, notification := range repo.storage.notifications {
+		if notification.UserId == id {
+			remove(repo.storage, repo.storage.notifications, notificationId)
+		}
+	}
+	return nil
+}
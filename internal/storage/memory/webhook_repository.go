@@ -0,0 +1,238 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"task_API/internal/models"
+	apperrors "task_API/pkg/errors"
+)
+
+type webhookRepository struct {
+	storage *Storage
+}
+
+func (repo *webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	if _, ok := repo.storage.users[subscription.UserId]; !ok {
+		return fmt.Errorf("failed to create webhook subscription: user %d does not exist", subscription.UserId)
+	}
+
+	subscription.ID = repo.storage.nextId("webhook_subscriptions")
+	put(repo.storage, repo.storage.subscriptions, subscription.ID, copySubscription(subscription))
+	return nil
+}
+
+func (repo *webhookRepository) GetSubscriptionById(ctx context.Context, id int) (*models.WebhookSubscription, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	subscription, ok := repo.storage.subscriptions[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound.New("webhook not found")
+	}
+	copied := copySubscription(&subscription)
+	return &copied, nil
+}
+
+func (repo *webhookRepository) GetSubscriptions(ctx context.Context, userId int) ([]*models.WebhookSubscription, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	var subscriptions []*models.WebhookSubscription
+	for _, subscription := range repo.storage.subscriptions {
+		if subscription.UserId == userId {
+			copied := copySubscription(&subscription)
+			subscriptions = append(subscriptions, &copied)
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].ID < subscriptions[j].ID
+	})
+	return subscriptions, nil
+}
+
+func (repo *webhookRepository) DeleteSubscription(ctx context.Context, id int) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	if _, ok := repo.storage.subscriptions[id]; !ok {
+		return apperrors.ErrNotFound.New("webhook not found")
+	}
+	repo.storage.deleteSubscriptionLocked(id)
+	return nil
+}
+
+func (repo *webhookRepository) CreateDelivery(ctx context.Context, webhookDelivery *models.WebhookDelivery) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	if _, ok := repo.storage.subscriptions[webhookDelivery.SubscriptionId]; !ok {
+		return fmt.Errorf("failed to create webhook delivery: webhook %d does not exist", webhookDelivery.SubscriptionId)
+	}
+
+	webhookDelivery.ID = repo.storage.nextId("webhook_deliveries")
+	put(repo.storage, repo.storage.deliveries, webhookDelivery.ID, delivery{WebhookDelivery: copyDelivery(webhookDelivery)})
+	return nil
+}
+
+func (repo *webhookRepository) GetDeliveryById(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	stored, ok := repo.storage.deliveries[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound.New("webhook delivery not found")
+	}
+	copied := copyDelivery(&stored.WebhookDelivery)
+	return &copied, nil
+}
+
+// GetDeliveries returns the delivery log of a subscription, newest first
+func (repo *webhookRepository) GetDeliveries(ctx context.Context, subscriptionId int, limit int) ([]*models.WebhookDelivery, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	var deliveries []*models.WebhookDelivery
+	for _, stored := range repo.storage.deliveries {
+		if stored.SubscriptionId == subscriptionId {
+			copied := copyDelivery(&stored.WebhookDelivery)
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries leases pending deliveries whose next attempt is due, so they are
+// not picked up again while they are being sent
+func (repo *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	var due []delivery
+	for _, stored := range repo.storage.deliveries {
+		if stored.Status != models.WebhookDeliveryPending || stored.NextAttemptAt.After(now) {
+			continue
+		}
+		if stored.lockedUntil != nil && stored.lockedUntil.After(now) {
+			continue
+		}
+		due = append(due, stored)
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	lockedUntil := now.Add(lease)
+	var deliveries []*models.WebhookDelivery
+	for _, stored := range due {
+		stored.lockedUntil = &lockedUntil
+		put(repo.storage, repo.storage.deliveries, stored.ID, stored)
+		copied := copyDelivery(&stored.WebhookDelivery)
+		deliveries = append(deliveries, &copied)
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery stores the outcome of an attempt and releases the lease
+func (repo *webhookRepository) UpdateDelivery(ctx context.Context, webhookDelivery *models.WebhookDelivery) error {
+	if err := repo.storage.lock(ctx); err != nil {
+		return err
+	}
+	defer repo.storage.unlock(ctx)
+
+	stored, ok := repo.storage.deliveries[webhookDelivery.ID]
+	if !ok {
+		return apperrors.ErrNotFound.New("webhook delivery not found")
+	}
+
+	stored.Status = webhookDelivery.Status
+	stored.Attempts = webhookDelivery.Attempts
+	stored.NextAttemptAt = webhookDelivery.NextAttemptAt
+	stored.LastError = webhookDelivery.LastError
+	stored.ResponseStatus = copyInt(webhookDelivery.ResponseStatus)
+	stored.UpdatedAt = webhookDelivery.UpdatedAt
+	stored.DeliveredAt = copyTime(webhookDelivery.DeliveredAt)
+	stored.lockedUntil = nil
+	put(repo.storage, repo.storage.deliveries, stored.ID, stored)
+	return nil
+}
+
+// RequeueDeadDelivery puts a dead delivery back in the queue with a fresh retry budget.
+// Deliveries that are not dead, or still leased to a dispatcher, are a conflict.
+func (repo *webhookRepository) RequeueDeadDelivery(ctx context.Context, id int, now time.Time) (*models.WebhookDelivery, error) {
+	if err := repo.storage.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer repo.storage.unlock(ctx)
+
+	stored, ok := repo.storage.deliveries[id]
+	if !ok || stored.Status != models.WebhookDeliveryDead || (stored.lockedUntil != nil && !stored.lockedUntil.Before(now)) {
+		return nil, apperrors.ErrConflict.New("only dead webhook deliveries can be retried")
+	}
+
+	stored.Status = models.WebhookDeliveryPending
+	stored.Attempts = 0
+	stored.NextAttemptAt = now
+	stored.UpdatedAt = now
+	stored.DeliveredAt = nil
+	put(repo.storage, repo.storage.deliveries, id, stored)
+	copied := copyDelivery(&stored.WebhookDelivery)
+	return &copied, nil
+}
+
+// deleteSubscriptionLocked removes a subscription with its deliveries. The mutex must be held.
+func (storage *Storage) deleteSubscriptionLocked(id int) {
+	remove(storage, storage.subscriptions, id)
+	for deliveryId, stored := range storage.deliveries {
+		if stored.SubscriptionId == id {
+			remove(storage, storage.deliveries, deliveryId)
+		}
+	}
+}
+
+func copySubscription(subscription *models.WebhookSubscription) models.WebhookSubscription {
+	copied := *subscription
+	copied.EventTypes = append([]string{}, subscription.EventTypes...)
+	return copied
+}
+
+func copyDelivery(webhookDelivery *models.WebhookDelivery) models.WebhookDelivery {
+	copied := *webhookDelivery
+	copied.Payload = append([]byte(nil), webhookDelivery.Payload...)
+	copied.ResponseStatus = copyInt(webhookDelivery.ResponseStatus)
+	copied.DeliveredAt = copyTime(webhookDelivery.DeliveredAt)
+	return copied
+}
@@ -0,0 +1,428 @@
+// Package migrate applies versioned SQL migrations and records them in the
+// schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"task_API/pkg/logger"
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Dialect holds the statements that differ between databases
+type Dialect struct {
+	// CreateTable creates the schema_migrations table if it does not exist
+	CreateTable string
+	// TableExists selects whether the schema_migrations table exists
+	TableExists string
+	// Lock and Unlock serialize migration runs across processes. They are empty when
+	// the database already serializes writers, every migration is then checked again
+	// inside its own transaction.
+	Lock   string
+	Unlock string
+}
+
+// Postgres holds an advisory lock while migrating, so instances starting at the same
+// time do not apply the same migration twice
+var Postgres = Dialect{
+	CreateTable: `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	TableExists: `SELECT to_regclass('schema_migrations') IS NOT NULL`,
+	Lock:        `SELECT pg_advisory_lock(727002)`,
+	Unlock:      `SELECT pg_advisory_unlock(727002)`,
+}
+
+// SQLite relies on the database being opened with immediate transactions, which take
+// the write lock when they begin
+var SQLite = Dialect{
+	CreateTable: `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`,
+	TableExists: `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')`,
+}
+
+// Migration is one schema change with the SQL to apply and revert it
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status reports whether a migration has been applied. Missing is set for a migration
+// recorded in the database whose files are gone, Modified when its up file changed.
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Missing   bool
+	Modified  bool
+}
+
+// Load reads the migrations of a directory, ordered by version. Every version needs
+// an up file, down files are optional.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		sum := sha256.Sum256([]byte(migration.Up))
+		migration.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applies migrations to a database
+type Migrator struct {
+	database   *sql.DB
+	dialect    Dialect
+	migrations []Migration
+	logger     *logger.Logger
+}
+
+func NewMigrator(database *sql.DB, dialect Dialect, migrations []Migration, appLogger *logger.Logger) *Migrator {
+	return &Migrator{database: database, dialect: dialect, migrations: migrations, logger: appLogger}
+}
+
+// Up applies every pending migration and returns the ones it applied
+func (migrator *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := migrator.locked(ctx, func(conn *sql.Conn) error {
+		done, err := migrator.verified(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrator.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			ran, err := migrator.apply(ctx, conn, migration)
+			if err != nil {
+				return err
+			}
+			if ran {
+				applied = append(applied, migration)
+			}
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first
+func (migrator *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := migrator.locked(ctx, func(conn *sql.Conn) error {
+		done, err := migrator.verified(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for index := len(migrator.migrations) - 1; index >= 0 && len(reverted) < steps; index-- {
+			migration := migrator.migrations[index]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if err := migrator.revert(ctx, conn, migration); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Redo reverts the latest applied migration and applies it again
+func (migrator *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := migrator.locked(ctx, func(conn *sql.Conn) error {
+		done, err := migrator.verified(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for index := len(migrator.migrations) - 1; index >= 0; index-- {
+			migration := migrator.migrations[index]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if err := migrator.revert(ctx, conn, migration); err != nil {
+				return err
+			}
+			if _, err := migrator.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			redone = &migration
+			return nil
+		}
+		return fmt.Errorf("no migration has been applied")
+	})
+	return redone, err
+}
+
+// Status lists every known migration and every migration recorded in the database
+func (migrator *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := migrator.locked(ctx, func(conn *sql.Conn) error {
+		done, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrator.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if record, ok := done[migration.Version]; ok {
+				appliedAt := record.appliedAt
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+				status.Modified = record.checksum != migration.Checksum
+				delete(done, migration.Version)
+			}
+			statuses = append(statuses, status)
+		}
+		for _, record := range done {
+			appliedAt := record.appliedAt
+			statuses = append(statuses, Status{
+				Version:   record.version,
+				Name:      record.name,
+				Applied:   true,
+				AppliedAt: &appliedAt,
+				Missing:   true,
+			})
+		}
+		sort.Slice(statuses, func(i, j int) bool {
+			return statuses[i].Version < statuses[j].Version
+		})
+		return nil
+	})
+	return statuses, err
+}
+
+// Version returns the latest applied migration version, 0 when nothing is applied or
+// the database was never migrated. It only reads, so health checks can call it.
+func (migrator *Migrator) Version(ctx context.Context) (int, error) {
+	var exists bool
+	if err := migrator.database.QueryRowContext(ctx, migrator.dialect.TableExists).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+	if err := migrator.database.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Latest returns the highest version known to the migrator
+func (migrator *Migrator) Latest() int {
+	if len(migrator.migrations) == 0 {
+		return 0
+	}
+	return migrator.migrations[len(migrator.migrations)-1].Version
+}
+
+// locked runs fn on a single connection holding the migration advisory lock
+func (migrator *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := migrator.database.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if migrator.dialect.Lock != "" {
+		if _, err := conn.ExecContext(ctx, migrator.dialect.Lock); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), migrator.dialect.Unlock)
+	}
+
+	if err := migrator.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// verified returns the applied migrations after checking that none of them was
+// edited or removed since it was applied
+func (migrator *Migrator) verified(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	done, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]Migration, len(migrator.migrations))
+	for _, migration := range migrator.migrations {
+		known[migration.Version] = migration
+	}
+	for version, record := range done {
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("applied migration %d_%s is missing from the migration files", version, record.name)
+		}
+		if migration.Checksum != record.checksum {
+			return nil, fmt.Errorf("migration %d_%s was modified after it was applied", version, migration.Name)
+		}
+	}
+	return done, nil
+}
+
+func (migrator *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	if _, err := conn.ExecContext(ctx, migrator.dialect.CreateTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[int]appliedMigration)
+	for rows.Next() {
+		var record appliedMigration
+		if err := rows.Scan(&record.version, &record.name, &record.checksum, &record.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		done[record.version] = record
+	}
+	return done, rows.Err()
+}
+
+// apply runs a migration and records it in the same transaction. It returns false
+// when the migration had already been applied.
+func (migrator *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) (bool, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Another process may have applied it since the applied versions were read
+	var applied int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = $1`, migration.Version).Scan(&applied); err != nil {
+		return false, fmt.Errorf("failed to check migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if applied > 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+		return false, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`,
+		migration.Version, migration.Name, migration.Checksum, time.Now().UTC(),
+	); err != nil {
+		return false, fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	migrator.logger.Info().Int("version", migration.Version).Str("name", migration.Name).Msg("Applied migration")
+	return true, nil
+}
+
+// revert runs the down file of a migration and removes its record
+func (migrator *Migrator) revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+		return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+		return fmt.Errorf("failed to remove migration record %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	migrator.logger.Info().Int("version", migration.Version).Str("name", migration.Name).Msg("Reverted migration")
+	return nil
+}
@@ -0,0 +1,93 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	dbmigrations "task_API/db/migrations"
+)
+
+func TestLoadOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_add_index.up.sql":   {Data: []byte("CREATE INDEX a ON t(a);")},
+		"002_create_t.up.sql":    {Data: []byte("CREATE TABLE t (a INT);")},
+		"002_create_t.down.sql":  {Data: []byte("DROP TABLE t;")},
+		"010_add_index.down.sql": {Data: []byte("DROP INDEX a;")},
+		"README.md":              {Data: []byte("not a migration")},
+		"001_first.up.sql":       {Data: []byte("SELECT 1;")},
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	var versions []int
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	if len(versions) != 3 || versions[0] != 1 || versions[1] != 2 || versions[2] != 10 {
+		t.Fatalf("unexpected order %v", versions)
+	}
+	if migrations[1].Name != "create_t" || migrations[1].Down != "DROP TABLE t;" {
+		t.Fatalf("unexpected migration %+v", migrations[1])
+	}
+	if migrations[0].Down != "" {
+		t.Fatalf("expected no down file for %d", migrations[0].Version)
+	}
+}
+
+func TestLoadChecksumFollowsUpFile(t *testing.T) {
+	load := func(up string) Migration {
+		migrations, err := Load(fstest.MapFS{"001_init.up.sql": {Data: []byte(up)}})
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		return migrations[0]
+	}
+
+	first := load("CREATE TABLE t (a INT);")
+	if first.Checksum != load("CREATE TABLE t (a INT);").Checksum {
+		t.Fatal("checksum is not stable")
+	}
+	if first.Checksum == load("CREATE TABLE t (a BIGINT);").Checksum {
+		t.Fatal("checksum did not change with the up file")
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"bad name":        {"create_t.up.sql": {Data: []byte("SELECT 1;")}},
+		"missing up file": {"001_init.down.sql": {Data: []byte("SELECT 1;")}},
+		"duplicate version": {
+			"001_a.up.sql": {Data: []byte("SELECT 1;")},
+			"001_b.up.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+
+	for name, fsys := range cases {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPostgresMigrationsAreReversible(t *testing.T) {
+	migrations, err := Load(dbmigrations.Postgres())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	for index, migration := range migrations {
+		if migration.Version != index+1 {
+			t.Errorf("migration %d_%s breaks the version sequence", migration.Version, migration.Name)
+		}
+		if strings.TrimSpace(migration.Down) == "" {
+			t.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+	}
+}
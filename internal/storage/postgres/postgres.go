@@ -0,0 +1,161 @@
+// Package postgres implements the repositories on top of PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbmigrations "task_API/db/migrations"
+	"task_API/internal/config"
+	"task_API/internal/metrics"
+	"task_API/internal/storage/migrate"
+	"task_API/internal/storage/repositories"
+	"task_API/internal/storage/sqlrepo"
+	"task_API/internal/storage/sqltx"
+	"task_API/pkg/logger"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Storage holds the connection pool and the repositories built on it
+type Storage struct {
+	DB *sql.DB
+
+	tx            *sqltx.DB
+	users         repositories.UserRepository
+	tasks         repositories.TaskRepository
+	taskEvents    repositories.TaskEventRepository
+	auditLogs     repositories.AuditLogRepository
+	webhooks      repositories.WebhookRepository
+	reminders     repositories.ReminderRepository
+	notifications repositories.NotificationRepository
+	migrator      *migrate.Migrator
+}
+
+// Open connects to Postgres and checks that the database is reachable. Migrations
+// log through appLogger.
+func Open(cfg *config.DatabaseConfig, appLogger *logger.Logger) (*Storage, error) {
+	return open(config.GetDBConnectionString(cfg), cfg, appLogger)
+}
+
+func open(dsn string, cfg *config.DatabaseConfig, appLogger *logger.Logger) (*Storage, error) {
+	db, error := sql.Open("pgx", dsn)
+	if error != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", error)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	// Connection check
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	// The migrations are embedded, they are parsed once rather than on every health check
+	migrations, err := migrate.Load(dbmigrations.Postgres())
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	tx := sqltx.New(db, sqltx.Options{MaxRetries: cfg.TxMaxRetries, Retryable: isSerializationFailure, Observe: metrics.ObserveQuery, System: "postgresql"})
+
+	return &Storage{
+		DB:            db,
+		tx:            tx,
+		users:         sqlrepo.NewUserRepository(tx, dialect, cfg.QueryTimeout),
+		tasks:         sqlrepo.NewTaskRepository(tx, dialect, cfg.QueryTimeout),
+		taskEvents:    sqlrepo.NewTaskEventRepository(tx, dialect, cfg.QueryTimeout),
+		auditLogs:     sqlrepo.NewAuditLogRepository(tx, dialect, cfg.QueryTimeout),
+		webhooks:      sqlrepo.NewWebhookRepository(tx, dialect, cfg.QueryTimeout),
+		reminders:     sqlrepo.NewReminderRepository(tx, dialect, cfg.QueryTimeout),
+		notifications: sqlrepo.NewNotificationRepository(tx, dialect, cfg.QueryTimeout),
+		migrator:      migrate.NewMigrator(db, migrate.Postgres, migrations, appLogger),
+	}, nil
+}
+
+// dialect runs the shared repositories on Postgres, which locks single rows
+var dialect = sqlrepo.Dialect{
+	RowLocks:          true,
+	LockAuditChain:    `SELECT pg_advisory_xact_lock(727001)`,
+	JSON:              func(document []byte) interface{} { return document },
+	IsUniqueViolation: isUniqueViolation,
+}
+
+// isSerializationFailure reports whether a transaction lost a conflict with another one
+// and succeeds when run again. Transactions run READ COMMITTED, where this is a
+// deadlock; serialization failures only happen at higher isolation levels.
+func isSerializationFailure(err error) bool {
+	var pgError *pgconn.PgError
+	if !errors.As(err, &pgError) {
+		return false
+	}
+	// serialization_failure, deadlock_detected
+	return pgError.Code == "40001" || pgError.Code == "40P01"
+}
+
+// isUniqueViolation reports whether a write failed on a unique constraint
+func isUniqueViolation(err error) bool {
+	var pgError *pgconn.PgError
+	return errors.As(err, &pgError) && pgError.Code == "23505"
+}
+
+func (storage *Storage) Users() repositories.UserRepository { return storage.users }
+
+func (storage *Storage) Tasks() repositories.TaskRepository { return storage.tasks }
+
+func (storage *Storage) TaskEvents() repositories.TaskEventRepository { return storage.taskEvents }
+
+func (storage *Storage) AuditLogs() repositories.AuditLogRepository { return storage.auditLogs }
+
+func (storage *Storage) Webhooks() repositories.WebhookRepository { return storage.webhooks }
+
+func (storage *Storage) Reminders() repositories.ReminderRepository { return storage.reminders }
+
+func (storage *Storage) Notifications() repositories.NotificationRepository {
+	return storage.notifications
+}
+
+func (storage *Storage) TxManager() repositories.TxManager { return storage.tx }
+
+// Migrator returns the migration runner for the Postgres schema
+func (storage *Storage) Migrator() *migrate.Migrator {
+	return storage.migrator
+}
+
+// Migrate applies the pending schema migrations
+func (storage *Storage) Migrate(ctx context.Context) error {
+	_, err := storage.migrator.Up(ctx)
+	return err
+}
+
+// Ping checks that the database answers
+func (storage *Storage) Ping(ctx context.Context) error {
+	return storage.DB.PingContext(ctx)
+}
+
+// SchemaVersion returns the applied and the latest known migration version. It only
+// reads, readiness probes call it.
+func (storage *Storage) SchemaVersion(ctx context.Context) (int, int, error) {
+	current, err := storage.migrator.Version(ctx)
+	return current, storage.migrator.Latest(), err
+}
+
+// Pool returns the connection pool, for its statistics
+func (storage *Storage) Pool() *sql.DB {
+	return storage.DB
+}
+
+func (storage *Storage) Close() error {
+	return storage.DB.Close()
+}
@@ -1,19 +1,73 @@
 package repositories
 
-import "task_API/internal/models"
+import (
+	"context"
+	"time"
+
+	"task_API/internal/models"
+)
 
 type UserRepository interface {
-	CreateUser(user *models.User) error
-	GetUserById(id int) (*models.User, error)
-	GetUserByEmail(email string) (*models.User, error)
-	UpdateUser(user *models.User) error
-	DeleteUser(id int) error
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserById(ctx context.Context, id int) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id int) error
 }
 
 type TaskRepository interface {
-	CreateTask(task *models.Task) error
-	GetTaskById(id int) (*models.Task, error)
-	GetAllTasks(userId int) ([]*models.Task, error)
-	UpdateTask(task *models.Task) error
-	DeleteTask(id int) error
+	CreateTask(ctx context.Context, task *models.Task) error
+	GetTaskById(ctx context.Context, id int) (*models.Task, error)
+	// GetTaskByIdForUpdate is GetTaskById that keeps other transactions from changing
+	// the task until the transaction of ctx ends
+	GetTaskByIdForUpdate(ctx context.Context, id int) (*models.Task, error)
+	GetAllTasks(ctx context.Context, userId int) ([]*models.Task, error)
+	UpdateTask(ctx context.Context, task *models.Task) error
+	DeleteTask(ctx context.Context, id int, deletedAt time.Time) error
+	RestoreTask(ctx context.Context, id int, restoredAt time.Time) error
+}
+
+type TaskEventRepository interface {
+	CreateTaskEvent(ctx context.Context, event *models.TaskEvent) error
+	GetTaskEvents(ctx context.Context, taskId int) ([]*models.TaskEvent, error)
+	GetUserTaskEvents(ctx context.Context, userId int, afterId int, limit int) ([]*models.TaskEvent, error)
+}
+
+type AuditLogRepository interface {
+	AppendAuditLog(ctx context.Context, entry *models.AuditLog) error
+	GetAuditLogs(ctx context.Context, filter *models.AuditLogFilter) ([]*models.AuditLog, error)
+	GetAuditChain(ctx context.Context, afterId int, limit int) ([]*models.AuditLog, error)
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	GetSubscriptionById(ctx context.Context, id int) (*models.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context, userId int) ([]*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int) error
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDeliveryById(ctx context.Context, id int) (*models.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, subscriptionId int, limit int) ([]*models.WebhookDelivery, error)
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	RequeueDeadDelivery(ctx context.Context, id int, now time.Time) (*models.WebhookDelivery, error)
+}
+
+type ReminderRepository interface {
+	ReplaceTaskReminders(ctx context.Context, taskId int, reminders []*models.Reminder) error
+	DeleteTaskReminders(ctx context.Context, taskId int) error
+	FireDueReminders(ctx context.Context, now time.Time, limit int, compose func(due *models.DueReminder) *models.Notification) ([]*models.Notification, int, error)
+}
+
+type NotificationRepository interface {
+	GetNotifications(ctx context.Context, userId int, unreadOnly bool, limit int) ([]*models.Notification, error)
+	MarkNotificationRead(ctx context.Context, id int, userId int, readAt time.Time) error
+	MarkAllNotificationsRead(ctx context.Context, userId int, readAt time.Time) (int, error)
+}
+
+// TxManager runs a unit of work in one transaction. Repository calls made with the
+// context passed to fn join the transaction, and a nested WithinTx only rolls back
+// its own work when it fails. fn may run more than once when the transaction is
+// retried after a serialization failure.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
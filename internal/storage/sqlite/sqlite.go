@@ -0,0 +1,210 @@
+// Package sqlite implements the repositories on top of SQLite, for deployments that
+// run as a single binary without a database server.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	dbmigrations "task_API/db/migrations"
+	"task_API/internal/config"
+	"task_API/internal/metrics"
+	"task_API/internal/storage/migrate"
+	"task_API/internal/storage/repositories"
+	"task_API/internal/storage/sqlrepo"
+	"task_API/internal/storage/sqltx"
+	"task_API/pkg/logger"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Storage holds the database handle and the repositories built on it
+type Storage struct {
+	DB *sql.DB
+
+	tx            *sqltx.DB
+	users         repositories.UserRepository
+	tasks         repositories.TaskRepository
+	taskEvents    repositories.TaskEventRepository
+	auditLogs     repositories.AuditLogRepository
+	webhooks      repositories.WebhookRepository
+	reminders     repositories.ReminderRepository
+	notifications repositories.NotificationRepository
+	migrator      *migrate.Migrator
+}
+
+// Open opens the database file, creating it when missing. The database runs in WAL
+// mode so reads do not block on writes, and transactions are immediate so two
+// writers never deadlock upgrading their locks. Migrations log through appLogger.
+func Open(cfg *config.DatabaseConfig, appLogger *logger.Logger) (*Storage, error) {
+	db, error := sql.Open("sqlite", dataSourceName(cfg.SQLitePath))
+	if error != nil {
+		return nil, fmt.Errorf("failed to open database: %w", error)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	// Connection check
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	// The migrations are embedded, they are parsed once rather than on every health check
+	migrations, err := migrate.Load(dbmigrations.SQLite())
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	tx := sqltx.New(db, sqltx.Options{MaxRetries: cfg.TxMaxRetries, Retryable: isBusy, Observe: metrics.ObserveQuery, System: "sqlite"})
+	database := &utcDB{tx}
+
+	return &Storage{
+		DB:            db,
+		tx:            tx,
+		users:         sqlrepo.NewUserRepository(database, dialect, cfg.QueryTimeout),
+		tasks:         sqlrepo.NewTaskRepository(database, dialect, cfg.QueryTimeout),
+		taskEvents:    sqlrepo.NewTaskEventRepository(database, dialect, cfg.QueryTimeout),
+		auditLogs:     sqlrepo.NewAuditLogRepository(database, dialect, cfg.QueryTimeout),
+		webhooks:      sqlrepo.NewWebhookRepository(database, dialect, cfg.QueryTimeout),
+		reminders:     sqlrepo.NewReminderRepository(database, dialect, cfg.QueryTimeout),
+		notifications: sqlrepo.NewNotificationRepository(database, dialect, cfg.QueryTimeout),
+		migrator:      migrate.NewMigrator(db, migrate.SQLite, migrations, appLogger),
+	}, nil
+}
+
+// dialect runs the shared repositories on SQLite. Transactions are immediate and hold
+// the write lock of the database, so rows and the audit chain need no locks of their
+// own. JSON is stored as text.
+var dialect = sqlrepo.Dialect{
+	JSON:              func(document []byte) interface{} { return string(document) },
+	IsUniqueViolation: isUniqueViolation,
+}
+
+// isBusy reports whether a transaction gave up waiting for the write lock, after the
+// busy timeout, and succeeds when run again
+func isBusy(err error) bool {
+	var sqliteError *sqlite.Error
+	if !errors.As(err, &sqliteError) {
+		return false
+	}
+	// Extended codes such as SQLITE_BUSY_SNAPSHOT keep the primary code in the low byte
+	return sqliteError.Code()&0xff == sqlite3.SQLITE_BUSY
+}
+
+// isUniqueViolation reports whether a write failed on a unique constraint
+func isUniqueViolation(err error) bool {
+	var sqliteError *sqlite.Error
+	return errors.As(err, &sqliteError) && sqliteError.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+func dataSourceName(path string) string {
+	params := url.Values{}
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Set("_txlock", "immediate")
+	// Sortable text timestamps, see utcDB
+	params.Set("_time_format", "sqlite")
+	return "file:" + path + "?" + params.Encode()
+}
+
+func (storage *Storage) Users() repositories.UserRepository { return storage.users }
+
+func (storage *Storage) Tasks() repositories.TaskRepository { return storage.tasks }
+
+func (storage *Storage) TaskEvents() repositories.TaskEventRepository { return storage.taskEvents }
+
+func (storage *Storage) AuditLogs() repositories.AuditLogRepository { return storage.auditLogs }
+
+func (storage *Storage) Webhooks() repositories.WebhookRepository { return storage.webhooks }
+
+func (storage *Storage) Reminders() repositories.ReminderRepository { return storage.reminders }
+
+func (storage *Storage) Notifications() repositories.NotificationRepository {
+	return storage.notifications
+}
+
+func (storage *Storage) TxManager() repositories.TxManager { return storage.tx }
+
+// Migrator returns the migration runner for the SQLite schema
+func (storage *Storage) Migrator() *migrate.Migrator {
+	return storage.migrator
+}
+
+// Migrate applies the pending schema migrations
+func (storage *Storage) Migrate(ctx context.Context) error {
+	_, err := storage.migrator.Up(ctx)
+	return err
+}
+
+// Ping checks that the database answers
+func (storage *Storage) Ping(ctx context.Context) error {
+	return storage.DB.PingContext(ctx)
+}
+
+// SchemaVersion returns the applied and the latest known migration version. It only
+// reads, readiness probes call it.
+func (storage *Storage) SchemaVersion(ctx context.Context) (int, int, error) {
+	current, err := storage.migrator.Version(ctx)
+	return current, storage.migrator.Latest(), err
+}
+
+// Pool returns the connection pool, for its statistics
+func (storage *Storage) Pool() *sql.DB {
+	return storage.DB
+}
+
+func (storage *Storage) Close() error {
+	return storage.DB.Close()
+}
+
+// utcDB converts every time argument to UTC before it reaches the driver. SQLite
+// stores timestamps as text and compares them as strings, which only orders them
+// correctly when they share one offset. Queries run in the transaction of the
+// context, if there is one.
+type utcDB struct {
+	*sqltx.DB
+}
+
+func (database *utcDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return database.DB.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (database *utcDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return database.DB.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (database *utcDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return database.DB.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+func utcArgs(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for index, arg := range args {
+		switch value := arg.(type) {
+		case time.Time:
+			converted[index] = value.UTC()
+		case *time.Time:
+			if value != nil {
+				converted[index] = value.UTC()
+			} else {
+				converted[index] = nil
+			}
+		default:
+			converted[index] = arg
+		}
+	}
+	return converted
+}
@@ -0,0 +1,213 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"task_API/internal/config"
+	"task_API/internal/models"
+	"task_API/internal/storage/sqltx"
+	"task_API/internal/storage/storagetest"
+	"task_API/pkg/logger"
+)
+
+func openTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	storage, err := Open(&config.DatabaseConfig{
+		SQLitePath:   filepath.Join(t.TempDir(), "test.db"),
+		MaxOpenConns: 4,
+		MaxIdleConns: 4,
+	}, logger.NewLogger("disabled", "json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+
+	if err := storage.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	return storage
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		return openTestStorage(t)
+	})
+}
+
+func TestOpenUsesWAL(t *testing.T) {
+	storage := openTestStorage(t)
+
+	var mode string
+	if err := storage.DB.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil {
+		t.Fatalf("journal_mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Fatalf("expected WAL mode, got %q", mode)
+	}
+}
+
+func TestMigrationsRoundTrip(t *testing.T) {
+	storage := openTestStorage(t)
+	ctx := context.Background()
+
+	migrator := storage.Migrator()
+
+	reverted, err := migrator.Down(ctx, migrator.Latest())
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if len(reverted) != migrator.Latest() {
+		t.Fatalf("expected %d reverted migrations, got %d", migrator.Latest(), len(reverted))
+	}
+	if version, _ := migrator.Version(ctx); version != 0 {
+		t.Fatalf("expected version 0 after reverting everything, got %d", version)
+	}
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if _, err := migrator.Redo(ctx); err != nil {
+		t.Fatalf("Redo: %v", err)
+	}
+	if version, _ := migrator.Version(ctx); version != migrator.Latest() {
+		t.Fatalf("expected version %d, got %d", migrator.Latest(), version)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("expected nothing left to apply, got %d", len(applied))
+	}
+}
+
+// Timestamps are compared as text, so times given in different zones must still
+// fire in time order
+func TestDueRemindersAcrossTimeZones(t *testing.T) {
+	storage := openTestStorage(t)
+	ctx := context.Background()
+
+	user := &models.User{Name: "Zone", Email: "zone@example.com", PasswordHash: "x", Role: models.RoleUser}
+	if err := storage.Users().CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	task := &models.Task{Title: "Zones", UserId: user.ID, DueDate: &now, CreatedAt: now, UpdatedAt: now}
+	if err := storage.Tasks().CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+
+	// 13:30 in UTC+2 is 11:30 UTC and due, 11:00 in UTC-2 is 13:00 UTC and not
+	east := time.FixedZone("east", 2*60*60)
+	west := time.FixedZone("west", -2*60*60)
+	err := storage.Reminders().ReplaceTaskReminders(ctx, task.ID, []*models.Reminder{
+		{TaskId: task.ID, UserId: user.ID, OffsetMinutes: 30, FireAt: time.Date(2024, 3, 1, 13, 30, 0, 0, east)},
+		{TaskId: task.ID, UserId: user.ID, OffsetMinutes: 0, FireAt: time.Date(2024, 3, 1, 11, 0, 0, 0, west)},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceTaskReminders: %v", err)
+	}
+
+	var fired []int
+	_, _, err = storage.Reminders().FireDueReminders(ctx, now, 10, func(due *models.DueReminder) *models.Notification {
+		fired = append(fired, due.Reminder.OffsetMinutes)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FireDueReminders: %v", err)
+	}
+	if len(fired) != 1 || fired[0] != 30 {
+		t.Fatalf("expected only the 30 minute reminder to fire, got %v", fired)
+	}
+}
+
+func TestSchemaVersionFollowsMigrations(t *testing.T) {
+	storage := openTestStorage(t)
+	ctx := context.Background()
+
+	if err := storage.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	current, latest, err := storage.SchemaVersion(ctx)
+	if err != nil || current != latest || latest == 0 {
+		t.Fatalf("SchemaVersion = %d, %d, %v, want the latest version applied", current, latest, err)
+	}
+
+	migrator := storage.Migrator()
+	if _, err := migrator.Down(ctx, 1); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if current, latest, _ = storage.SchemaVersion(ctx); current != latest-1 {
+		t.Fatalf("SchemaVersion = %d, %d after reverting one migration", current, latest)
+	}
+}
+
+func TestSchemaVersionOfAnUnmigratedDatabaseOnlyReads(t *testing.T) {
+	storage, err := Open(&config.DatabaseConfig{SQLitePath: filepath.Join(t.TempDir(), "test.db"), MaxOpenConns: 1}, logger.NewLogger("disabled", "json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer storage.Close()
+
+	current, latest, err := storage.SchemaVersion(context.Background())
+	if err != nil || current != 0 || latest == 0 {
+		t.Fatalf("SchemaVersion = %d, %d, %v, want nothing applied", current, latest, err)
+	}
+
+	var tables int
+	if err := storage.DB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_migrations'`).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Fatal("SchemaVersion created the schema_migrations table")
+	}
+}
+
+// A transaction that cannot get the write lock within the busy timeout fails with
+// SQLITE_BUSY and is run again
+func TestBusyTransactionsAreRetried(t *testing.T) {
+	ctx := context.Background()
+	pool, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_pragma=busy_timeout(20)")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	if _, err := pool.Exec(`CREATE TABLE items (name TEXT NOT NULL)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	holder, err := pool.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+	defer holder.Close()
+	if _, err := holder.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		t.Fatalf("BEGIN IMMEDIATE: %v", err)
+	}
+
+	db := sqltx.New(pool, sqltx.Options{MaxRetries: 2, Retryable: isBusy})
+	attempts := 0
+	err = db.WithinTx(ctx, func(ctx context.Context) error {
+		attempts++
+		_, err := db.ExecContext(ctx, `INSERT INTO items (name) VALUES ($1)`, "retried")
+		if err != nil && attempts == 1 {
+			// The next attempt finds the write lock free
+			if _, commitErr := holder.ExecContext(ctx, `COMMIT`); commitErr != nil {
+				t.Errorf("COMMIT: %v", commitErr)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("attempts = %d, want the busy attempt and a retry", attempts)
+	}
+}
@@ -0,0 +1,168 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+)
+
+type auditLogRepository struct {
+	database     Database
+	dialect      Dialect
+	queryTimeout time.Duration
+}
+
+func NewAuditLogRepository(database Database, dialect Dialect, queryTimeout time.Duration) repositories.AuditLogRepository {
+	return &auditLogRepository{database: database, dialect: dialect, queryTimeout: queryTimeout}
+}
+
+// AppendAuditLog links the entry to the end of the hash chain and stores it
+func (repo *auditLogRepository) AppendAuditLog(ctx context.Context, entry *models.AuditLog) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	return repo.database.WithinTx(ctx, func(ctx context.Context) error {
+		if repo.dialect.LockAuditChain != "" {
+			if _, error := repo.database.ExecContext(ctx, repo.dialect.LockAuditChain); error != nil {
+				return fmt.Errorf("failed to lock audit chain: %w", error)
+			}
+		}
+
+		prevHash := models.AuditGenesisHash
+		error := repo.database.QueryRowContext(ctx, `SELECT hash FROM audit_logs ORDER BY id DESC LIMIT 1`).Scan(&prevHash)
+		if error != nil && error != sql.ErrNoRows {
+			return fmt.Errorf("failed to read audit chain head: %w", error)
+		}
+
+		// Postgres keeps microseconds, the hash must survive the round trip. SQLite keeps
+		// the same precision, so chains can be compared across backends.
+		entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+		entry.PrevHash = prevHash
+		entry.Hash = entry.ComputeHash()
+
+		query := `
+		INSERT INTO audit_logs (user_id, event, email, ip_address, user_agent, success, details, prev_hash, hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+		`
+
+		error = repo.database.QueryRowContext(ctx,
+			query,
+			entry.UserId,
+			entry.Event,
+			entry.Email,
+			entry.IPAddress,
+			entry.UserAgent,
+			entry.Success,
+			entry.Details,
+			entry.PrevHash,
+			entry.Hash,
+			entry.CreatedAt,
+		).Scan(&entry.ID)
+
+		if error != nil {
+			return fmt.Errorf("failed to create audit log: %w", error)
+		}
+
+		return nil
+	})
+}
+
+// GetAuditLogs returns the entries matching the filter, newest first
+func (repo *auditLogRepository) GetAuditLogs(ctx context.Context, filter *models.AuditLogFilter) ([]*models.AuditLog, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserId != nil {
+		addCondition("user_id = $%d", *filter.UserId)
+	}
+	if filter.Event != "" {
+		addCondition("event = $%d", filter.Event)
+	}
+	if filter.Success != nil {
+		addCondition("success = $%d", *filter.Success)
+	}
+	if filter.IPAddress != "" {
+		addCondition("ip_address = $%d", filter.IPAddress)
+	}
+	if filter.Since != nil {
+		addCondition("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		addCondition("created_at < $%d", *filter.Until)
+	}
+	if filter.BeforeId > 0 {
+		addCondition("id < $%d", filter.BeforeId)
+	}
+
+	query := `
+	SELECT id, user_id, event, email, ip_address, user_agent, success, details, prev_hash, hash, created_at
+	FROM audit_logs
+	`
+	if len(conditions) > 0 {
+		query += "WHERE " + strings.Join(conditions, " AND ") + "\n"
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf("ORDER BY id DESC LIMIT $%d", len(args))
+
+	return repo.queryAuditLogs(ctx, query, args...)
+}
+
+// GetAuditChain returns up to limit entries after the given id, oldest first
+func (repo *auditLogRepository) GetAuditChain(ctx context.Context, afterId int, limit int) ([]*models.AuditLog, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, user_id, event, email, ip_address, user_agent, success, details, prev_hash, hash, created_at
+	FROM audit_logs
+	WHERE id > $1
+	ORDER BY id ASC
+	LIMIT $2
+	`
+	return repo.queryAuditLogs(ctx, query, afterId, limit)
+}
+
+func (repo *auditLogRepository) queryAuditLogs(ctx context.Context, query string, args ...interface{}) ([]*models.AuditLog, error) {
+	rows, error := repo.database.QueryContext(ctx, query, args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get audit logs: %w", error)
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditLog
+	for rows.Next() {
+		var entry models.AuditLog
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.UserId,
+			&entry.Event,
+			&entry.Email,
+			&entry.IPAddress,
+			&entry.UserAgent,
+			&entry.Success,
+			&entry.Details,
+			&entry.PrevHash,
+			&entry.Hash,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,112 @@
+package sqlrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+	apperrors "task_API/pkg/errors"
+)
+
+type notificationRepository struct {
+	database     Database
+	dialect      Dialect
+	queryTimeout time.Duration
+}
+
+func NewNotificationRepository(database Database, dialect Dialect, queryTimeout time.Duration) repositories.NotificationRepository {
+	return &notificationRepository{database: database, dialect: dialect, queryTimeout: queryTimeout}
+}
+
+// GetNotifications returns the notifications of a user, newest first
+func (repo *notificationRepository) GetNotifications(ctx context.Context, userId int, unreadOnly bool, limit int) ([]*models.Notification, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, user_id, task_id, type, message, read_at, created_at
+	FROM notifications
+	WHERE user_id = $1 AND ($2 = FALSE OR read_at IS NULL)
+	ORDER BY id DESC
+	LIMIT $3
+	`
+
+	rows, error := repo.database.QueryContext(ctx, query, userId, unreadOnly, limit)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", error)
+	}
+	defer rows.Close()
+
+	var notifications []*models.Notification
+	for rows.Next() {
+		var notification models.Notification
+		if err := rows.Scan(
+			&notification.ID,
+			&notification.UserId,
+			&notification.TaskId,
+			&notification.Type,
+			&notification.Message,
+			&notification.ReadAt,
+			&notification.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, &notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+// MarkNotificationRead marks one notification of the user as read, keeping the first read time
+func (repo *notificationRepository) MarkNotificationRead(ctx context.Context, id int, userId int, readAt time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	UPDATE notifications
+	SET read_at = COALESCE(read_at, $1)
+	WHERE id = $2 AND user_id = $3
+	`
+
+	result, error := repo.database.ExecContext(ctx, query, readAt, id, userId)
+	if error != nil {
+		return fmt.Errorf("failed to mark notification as read: %w", error)
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return fmt.Errorf("failed to get rows affected: %w", error)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.ErrNotFound.New("notification not found")
+	}
+
+	return nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user as read and returns how many there were
+func (repo *notificationRepository) MarkAllNotificationsRead(ctx context.Context, userId int, readAt time.Time) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	UPDATE notifications
+	SET read_at = $1
+	WHERE user_id = $2 AND read_at IS NULL
+	`
+
+	result, error := repo.database.ExecContext(ctx, query, readAt, userId)
+	if error != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", error)
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", error)
+	}
+
+	return int(rowsAffected), nil
+}
@@ -0,0 +1,151 @@
+package sqlrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+)
+
+type reminderRepository struct {
+	database     Database
+	dialect      Dialect
+	queryTimeout time.Duration
+}
+
+func NewReminderRepository(database Database, dialect Dialect, queryTimeout time.Duration) repositories.ReminderRepository {
+	return &reminderRepository{database: database, dialect: dialect, queryTimeout: queryTimeout}
+}
+
+// ReplaceTaskReminders swaps the pending reminders of a task for the given ones
+func (repo *reminderRepository) ReplaceTaskReminders(ctx context.Context, taskId int, reminders []*models.Reminder) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	return repo.database.WithinTx(ctx, func(ctx context.Context) error {
+		if _, error := repo.database.ExecContext(ctx, `DELETE FROM task_reminders WHERE task_id = $1 AND sent_at IS NULL`, taskId); error != nil {
+			return fmt.Errorf("failed to delete task reminders: %w", error)
+		}
+
+		query := `
+		INSERT INTO task_reminders (task_id, user_id, offset_minutes, fire_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+		`
+		for _, reminder := range reminders {
+			error := repo.database.QueryRowContext(ctx,
+				query,
+				reminder.TaskId,
+				reminder.UserId,
+				reminder.OffsetMinutes,
+				reminder.FireAt,
+			).Scan(&reminder.ID)
+			if error != nil {
+				return fmt.Errorf("failed to create task reminder: %w", error)
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteTaskReminders removes the reminders of a task that have not been sent yet
+func (repo *reminderRepository) DeleteTaskReminders(ctx context.Context, taskId int) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	if _, error := repo.database.ExecContext(ctx, `DELETE FROM task_reminders WHERE task_id = $1 AND sent_at IS NULL`, taskId); error != nil {
+		return fmt.Errorf("failed to delete task reminders: %w", error)
+	}
+	return nil
+}
+
+// FireDueReminders marks up to limit due reminders as sent and stores the notification
+// that compose builds for each of them, all in one transaction. Rows locked by another
+// instance are skipped, or the transaction holds the write lock of the database, so
+// every reminder fires exactly once. compose may return nil
+// to drop a reminder without notifying. It returns the notifications and how many
+// reminders fired, dropped ones included, as committed.
+func (repo *reminderRepository) FireDueReminders(ctx context.Context, now time.Time, limit int, compose func(due *models.DueReminder) *models.Notification) ([]*models.Notification, int, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	var notifications []*models.Notification
+	var fired int
+	error := repo.database.WithinTx(ctx, func(ctx context.Context) error {
+		query := `
+		SELECT r.id, r.task_id, r.user_id, r.offset_minutes, r.fire_at,
+			t.id, t.title, t.completed, t.user_id, t.due_date, t.deleted_at
+		FROM task_reminders r
+		JOIN tasks t ON t.id = r.task_id
+		WHERE r.sent_at IS NULL AND r.fire_at <= $1
+		ORDER BY r.fire_at
+		LIMIT $2
+		` + repo.dialect.skipLocked("r")
+
+		rows, error := repo.database.QueryContext(ctx, query, now, limit)
+		if error != nil {
+			return fmt.Errorf("failed to get due reminders: %w", error)
+		}
+
+		var due []*models.DueReminder
+		for rows.Next() {
+			var reminder models.DueReminder
+			if err := rows.Scan(
+				&reminder.Reminder.ID,
+				&reminder.Reminder.TaskId,
+				&reminder.Reminder.UserId,
+				&reminder.Reminder.OffsetMinutes,
+				&reminder.Reminder.FireAt,
+				&reminder.Task.ID,
+				&reminder.Task.Title,
+				&reminder.Task.Completed,
+				&reminder.Task.UserId,
+				&reminder.Task.DueDate,
+				&reminder.Task.DeletedAt,
+			); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan due reminder: %w", err)
+			}
+			due = append(due, &reminder)
+		}
+		rows.Close()
+		if error := rows.Err(); error != nil {
+			return fmt.Errorf("failed to get due reminders: %w", error)
+		}
+
+		// A retried transaction starts over
+		notifications, fired = nil, len(due)
+		for _, reminder := range due {
+			if notification := compose(reminder); notification != nil {
+				error := repo.database.QueryRowContext(ctx, `
+				INSERT INTO notifications (user_id, task_id, type, message, created_at)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING id
+				`,
+					notification.UserId,
+					notification.TaskId,
+					notification.Type,
+					notification.Message,
+					notification.CreatedAt,
+				).Scan(&notification.ID)
+				if error != nil {
+					return fmt.Errorf("failed to create notification: %w", error)
+				}
+				notifications = append(notifications, notification)
+			}
+
+			if _, error := repo.database.ExecContext(ctx, `UPDATE task_reminders SET sent_at = $1 WHERE id = $2`, now, reminder.Reminder.ID); error != nil {
+				return fmt.Errorf("failed to mark reminder as sent: %w", error)
+			}
+		}
+
+		return nil
+	})
+	if error != nil {
+		return nil, 0, error
+	}
+	return notifications, fired, nil
+}
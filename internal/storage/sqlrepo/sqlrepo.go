@@ -0,0 +1,59 @@
+// Package sqlrepo implements the repositories once for the database/sql backends.
+// Postgres and SQLite run the same statements, a Dialect holds what differs between
+// them.
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Database runs the statements of the repositories, a *sqltx.DB or a wrapper of it
+type Database interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Dialect is what the repositories need to know about a database
+type Dialect struct {
+	// RowLocks is set when the database locks single rows. Without it, as on SQLite,
+	// a transaction holds the write lock of the whole database from its first
+	// statement, so reads need no locking clause.
+	RowLocks bool
+	// LockAuditChain is run before an audit entry is appended so that every entry
+	// links to the latest one, empty when transactions already exclude each other
+	LockAuditChain string
+	// JSON turns an encoded document into the argument of a JSON column
+	JSON func(document []byte) interface{}
+	// IsUniqueViolation reports whether a write failed on a unique constraint
+	IsUniqueViolation func(err error) bool
+}
+
+// forUpdate locks the rows a SELECT reads until the transaction ends
+func (dialect Dialect) forUpdate() string {
+	if !dialect.RowLocks {
+		return ""
+	}
+	return "FOR UPDATE"
+}
+
+// skipLocked locks the rows of table that a SELECT reads and skips those another
+// transaction holds
+func (dialect Dialect) skipLocked(table string) string {
+	if !dialect.RowLocks {
+		return ""
+	}
+	return "FOR UPDATE OF " + table + " SKIP LOCKED"
+}
+
+// withQueryTimeout bounds a repository call by the configured query timeout. Without
+// a timeout only the deadline of the caller applies.
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
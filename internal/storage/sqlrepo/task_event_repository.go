@@ -0,0 +1,117 @@
+package sqlrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+)
+
+type taskEventRepository struct {
+	database     Database
+	dialect      Dialect
+	queryTimeout time.Duration
+}
+
+func NewTaskEventRepository(database Database, dialect Dialect, queryTimeout time.Duration) repositories.TaskEventRepository {
+	return &taskEventRepository{database: database, dialect: dialect, queryTimeout: queryTimeout}
+}
+
+// CreateTaskEvent appends an event to the task history
+func (repo *taskEventRepository) CreateTaskEvent(ctx context.Context, event *models.TaskEvent) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO task_events (task_id, user_id, actor_id, action, changes, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id
+	`
+
+	changes, error := json.Marshal(event.Changes)
+	if error != nil {
+		return fmt.Errorf("failed to encode task changes: %w", error)
+	}
+
+	error = repo.database.QueryRowContext(ctx,
+		query,
+		event.TaskId,
+		event.UserId,
+		event.ActorId,
+		event.Action,
+		repo.dialect.JSON(changes),
+		event.CreatedAt,
+	).Scan(&event.ID)
+
+	if error != nil {
+		return fmt.Errorf("failed to create task event: %w", error)
+	}
+
+	return nil
+}
+
+// GetTaskEvents returns the history of a task, oldest event first
+func (repo *taskEventRepository) GetTaskEvents(ctx context.Context, taskId int) ([]*models.TaskEvent, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, task_id, user_id, actor_id, action, changes, created_at
+	FROM task_events
+	WHERE task_id = $1
+	ORDER BY id ASC
+	`
+
+	return repo.queryTaskEvents(ctx, query, taskId)
+}
+
+// GetUserTaskEvents returns up to limit events on the tasks of a user that were
+// recorded after the given event id, oldest first
+func (repo *taskEventRepository) GetUserTaskEvents(ctx context.Context, userId int, afterId int, limit int) ([]*models.TaskEvent, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, task_id, user_id, actor_id, action, changes, created_at
+	FROM task_events
+	WHERE user_id = $1 AND id > $2
+	ORDER BY id ASC
+	LIMIT $3
+	`
+
+	return repo.queryTaskEvents(ctx, query, userId, afterId, limit)
+}
+
+func (repo *taskEventRepository) queryTaskEvents(ctx context.Context, query string, args ...interface{}) ([]*models.TaskEvent, error) {
+	rows, error := repo.database.QueryContext(ctx, query, args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get task events: %w", error)
+	}
+	defer rows.Close()
+
+	var events []*models.TaskEvent
+	for rows.Next() {
+		var event models.TaskEvent
+		var changes []byte
+		if err := rows.Scan(
+			&event.ID,
+			&event.TaskId,
+			&event.UserId,
+			&event.ActorId,
+			&event.Action,
+			&changes,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan task event: %w", err)
+		}
+		if err := json.Unmarshal(changes, &event.Changes); err != nil {
+			return nil, fmt.Errorf("failed to decode task changes: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,271 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+	apperrors "task_API/pkg/errors"
+)
+
+type taskRepository struct {
+	database     Database
+	dialect      Dialect
+	queryTimeout time.Duration
+}
+
+func NewTaskRepository(database Database, dialect Dialect, queryTimeout time.Duration) repositories.TaskRepository {
+	return &taskRepository{database: database, dialect: dialect, queryTimeout: queryTimeout}
+}
+
+// Create Task database interface
+func (repo *taskRepository) CreateTask(ctx context.Context, task *models.Task) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO tasks (title, completed, user_id, due_date, reminder_offsets, created_at, updated_at) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, title, completed, user_id, created_at, updated_at
+	`
+
+	reminderOffsets, error := encodeReminderOffsets(task.ReminderOffsets)
+	if error != nil {
+		return error
+	}
+
+	error = repo.database.QueryRowContext(ctx,
+		query,
+		task.Title,
+		task.Completed,
+		task.UserId,
+		task.DueDate,
+		repo.dialect.JSON(reminderOffsets),
+		task.CreatedAt,
+		task.UpdatedAt,
+	).Scan(
+		&task.ID,
+		&task.Title,
+		&task.Completed,
+		&task.UserId,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	)
+
+	if error != nil {
+		return fmt.Errorf("failed to create task: %w", error)
+	}
+
+	return nil
+}
+
+// Get All Tasks database interface
+func (repo *taskRepository) GetAllTasks(ctx context.Context, userId int) ([]*models.Task, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, title, completed, user_id, due_date, reminder_offsets, created_at, updated_at
+	FROM tasks
+	WHERE user_id = $1 AND deleted_at IS NULL
+	ORDER BY created_at DESC
+	`
+
+	rows, error := repo.database.QueryContext(ctx, query, userId)
+
+	if error != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", error)
+	}
+	defer rows.Close()
+
+	var tasks []*models.Task
+	for rows.Next() {
+		var task models.Task
+		var reminderOffsets []byte
+		if err := rows.Scan(
+			&task.ID,
+			&task.Title,
+			&task.Completed,
+			&task.UserId,
+			&task.DueDate,
+			&reminderOffsets,
+			&task.CreatedAt,
+			&task.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		if err := json.Unmarshal(reminderOffsets, &task.ReminderOffsets); err != nil {
+			return nil, fmt.Errorf("failed to decode reminder offsets: %w", err)
+		}
+		tasks = append(tasks, &task)
+	}
+
+	return tasks, nil
+}
+
+// Get TAsk by id
+func (repo *taskRepository) GetTaskById(ctx context.Context, id int) (*models.Task, error) {
+	return repo.getTask(ctx, id, "")
+}
+
+// GetTaskByIdForUpdate locks the row. Postgres transactions run READ COMMITTED and
+// would otherwise both read the version the other one overwrites.
+func (repo *taskRepository) GetTaskByIdForUpdate(ctx context.Context, id int) (*models.Task, error) {
+	return repo.getTask(ctx, id, repo.dialect.forUpdate())
+}
+
+func (repo *taskRepository) getTask(ctx context.Context, id int, locking string) (*models.Task, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, title, completed, user_id, due_date, reminder_offsets, created_at, updated_at, deleted_at
+	FROM tasks
+	WHERE id = $1
+	` + locking
+
+	var task models.Task
+	var reminderOffsets []byte
+
+	if error := repo.database.QueryRowContext(ctx, query, id).Scan(
+		&task.ID,
+		&task.Title,
+		&task.Completed,
+		&task.UserId,
+		&task.DueDate,
+		&reminderOffsets,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		&task.DeletedAt,
+	); error != nil {
+		if error == sql.ErrNoRows {
+			return &models.Task{}, apperrors.ErrNotFound.New("task not found")
+		} else {
+			return &models.Task{}, fmt.Errorf("failed to get task: %w", error)
+		}
+	}
+
+	if error := json.Unmarshal(reminderOffsets, &task.ReminderOffsets); error != nil {
+		return &models.Task{}, fmt.Errorf("failed to decode reminder offsets: %w", error)
+	}
+
+	return &task, nil
+
+}
+
+func (repo *taskRepository) UpdateTask(ctx context.Context, task *models.Task) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	UPDATE tasks
+	SET title = $1, completed = $2, due_date = $3, reminder_offsets = $4, updated_at = $5
+	WHERE id = $6 AND user_id = $7 AND deleted_at IS NULL
+	RETURNING id, title, completed, user_id, created_at, updated_at
+	`
+
+	reminderOffsets, error := encodeReminderOffsets(task.ReminderOffsets)
+	if error != nil {
+		return error
+	}
+
+	error = repo.database.QueryRowContext(ctx,
+		query,
+		task.Title,
+		task.Completed,
+		task.DueDate,
+		repo.dialect.JSON(reminderOffsets),
+		task.UpdatedAt,
+		task.ID,
+		task.UserId,
+	).Scan(
+		&task.ID,
+		&task.Title,
+		&task.Completed,
+		&task.UserId,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	)
+
+	if error != nil {
+		if error == sql.ErrNoRows {
+			return apperrors.ErrNotFound.New("task not found")
+		} else {
+			return fmt.Errorf("failed to update task: %w", error)
+		}
+	}
+
+	return nil
+}
+
+// DeleteTask soft deletes the task so it can be restored later
+func (repo *taskRepository) DeleteTask(ctx context.Context, id int, deletedAt time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	UPDATE tasks
+	SET deleted_at = $1
+	WHERE id = $2 AND deleted_at IS NULL
+	`
+	result, error := repo.database.ExecContext(ctx, query, deletedAt, id)
+
+	if error != nil {
+		return fmt.Errorf("failed to delete task: %w", error)
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return fmt.Errorf("failed to get rows affected: %w", error)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.ErrNotFound.New("task not found")
+	}
+
+	return nil
+}
+
+// RestoreTask clears the deletion mark of a soft deleted task
+func (repo *taskRepository) RestoreTask(ctx context.Context, id int, restoredAt time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	UPDATE tasks
+	SET deleted_at = NULL, updated_at = $1
+	WHERE id = $2 AND deleted_at IS NOT NULL
+	`
+	result, error := repo.database.ExecContext(ctx, query, restoredAt, id)
+
+	if error != nil {
+		return fmt.Errorf("failed to restore task: %w", error)
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return fmt.Errorf("failed to get rows affected: %w", error)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.ErrNotFound.New("deleted task not found")
+	}
+
+	return nil
+}
+
+// encodeReminderOffsets stores a missing list as an empty JSON array
+func encodeReminderOffsets(offsets []int) ([]byte, error) {
+	if offsets == nil {
+		offsets = []int{}
+	}
+	encoded, err := json.Marshal(offsets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode reminder offsets: %w", err)
+	}
+	return encoded, nil
+}
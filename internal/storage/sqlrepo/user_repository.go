@@ -0,0 +1,171 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+	apperrors "task_API/pkg/errors"
+)
+
+type userRepository struct {
+	database     Database
+	dialect      Dialect
+	queryTimeout time.Duration
+}
+
+func NewUserRepository(database Database, dialect Dialect, queryTimeout time.Duration) repositories.UserRepository {
+	return &userRepository{database: database, dialect: dialect, queryTimeout: queryTimeout}
+}
+
+func (repo *userRepository) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO users(name, email, password_hash, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	error := repo.database.QueryRowContext(ctx,
+		query,
+		user.Name,
+		user.Email,
+		user.PasswordHash,
+		user.Role,
+		user.CreatedAt,
+		user.UpdatedAt,
+	).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if error != nil {
+		if repo.dialect.IsUniqueViolation(error) {
+			return apperrors.ErrAlreadyExists.Wrap(error, "email already exists")
+		}
+		return fmt.Errorf("failed to create user: %w", error)
+	}
+
+	return nil
+}
+
+func (repo *userRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, name, email, password_hash, role, created_at, updated_at
+		FROM users
+		WHERE email = $1
+	`
+
+	var user models.User
+
+	err := repo.database.QueryRowContext(ctx, query, email).Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrNotFound.New("user not found")
+		} else {
+			return nil, fmt.Errorf("failed to get user by email: %w", err)
+		}
+	}
+	return &user, nil
+}
+
+func (repo *userRepository) GetUserById(ctx context.Context, id int) (*models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, name, email, password_hash, role, created_at, updated_at
+		FROM users
+		WHERE id = $1
+	`
+
+	var user models.User
+
+	err := repo.database.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrNotFound.New("user not found")
+		} else {
+			return nil, fmt.Errorf("failed to get user by id: %w", err)
+		}
+	}
+	return &user, nil
+}
+
+func (repo *userRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET name = $1, email = $2, password_hash = $3, role = $4, updated_at = $5
+		WHERE id = $6
+		RETURNING updated_at
+	`
+
+	error := repo.database.QueryRowContext(ctx,
+		query,
+		user.Name,
+		user.Email,
+		user.PasswordHash,
+		user.Role,
+		user.UpdatedAt,
+		user.ID,
+	).Scan(
+		&user.UpdatedAt,
+	)
+
+	if error != nil {
+		if repo.dialect.IsUniqueViolation(error) {
+			return apperrors.ErrAlreadyExists.Wrap(error, "email already exists")
+		}
+		if error == sql.ErrNoRows {
+			return apperrors.ErrNotFound.New("user not found")
+		}
+		return fmt.Errorf("failed to update user: %w", error)
+	}
+
+	return nil
+}
+
+func (repo *userRepository) DeleteUser(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM users
+		WHERE id = $1
+	`
+	error := repo.database.QueryRowContext(ctx, query, id).Err()
+	if error != nil {
+		return fmt.Errorf("failed to delete user: %w", error)
+	}
+	return nil
+}
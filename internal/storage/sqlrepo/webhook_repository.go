@@ -0,0 +1,356 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+	apperrors "task_API/pkg/errors"
+)
+
+type webhookRepository struct {
+	database     Database
+	dialect      Dialect
+	queryTimeout time.Duration
+}
+
+func NewWebhookRepository(database Database, dialect Dialect, queryTimeout time.Duration) repositories.WebhookRepository {
+	return &webhookRepository{database: database, dialect: dialect, queryTimeout: queryTimeout}
+}
+
+func (repo *webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO webhook_subscriptions (user_id, url, event_types, secret, active, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id
+	`
+
+	eventTypes, error := json.Marshal(subscription.EventTypes)
+	if error != nil {
+		return fmt.Errorf("failed to encode event types: %w", error)
+	}
+
+	error = repo.database.QueryRowContext(ctx,
+		query,
+		subscription.UserId,
+		subscription.URL,
+		repo.dialect.JSON(eventTypes),
+		subscription.Secret,
+		subscription.Active,
+		subscription.CreatedAt,
+	).Scan(&subscription.ID)
+
+	if error != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", error)
+	}
+
+	return nil
+}
+
+func (repo *webhookRepository) GetSubscriptionById(ctx context.Context, id int) (*models.WebhookSubscription, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, user_id, url, event_types, secret, active, created_at
+	FROM webhook_subscriptions
+	WHERE id = $1
+	`
+
+	subscription, error := scanSubscription(repo.database.QueryRowContext(ctx, query, id))
+	if error != nil {
+		if error == sql.ErrNoRows {
+			return nil, apperrors.ErrNotFound.New("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", error)
+	}
+
+	return subscription, nil
+}
+
+func (repo *webhookRepository) GetSubscriptions(ctx context.Context, userId int) ([]*models.WebhookSubscription, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, user_id, url, event_types, secret, active, created_at
+	FROM webhook_subscriptions
+	WHERE user_id = $1
+	ORDER BY id ASC
+	`
+
+	rows, error := repo.database.QueryContext(ctx, query, userId)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions: %w", error)
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.WebhookSubscription
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (repo *webhookRepository) DeleteSubscription(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	result, error := repo.database.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if error != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", error)
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return fmt.Errorf("failed to get rows affected: %w", error)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.ErrNotFound.New("webhook not found")
+	}
+
+	return nil
+}
+
+func (repo *webhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO webhook_deliveries (subscription_id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id
+	`
+
+	error := repo.database.QueryRowContext(ctx,
+		query,
+		delivery.SubscriptionId,
+		delivery.EventType,
+		repo.dialect.JSON(delivery.Payload),
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+		delivery.UpdatedAt,
+	).Scan(&delivery.ID)
+
+	if error != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", error)
+	}
+
+	return nil
+}
+
+func (repo *webhookRepository) GetDeliveryById(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, subscription_id, event_type, payload, status, attempts, next_attempt_at,
+		last_error, response_status, created_at, updated_at, delivered_at
+	FROM webhook_deliveries
+	WHERE id = $1
+	`
+
+	delivery, error := scanDelivery(repo.database.QueryRowContext(ctx, query, id))
+	if error != nil {
+		if error == sql.ErrNoRows {
+			return nil, apperrors.ErrNotFound.New("webhook delivery not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", error)
+	}
+
+	return delivery, nil
+}
+
+// GetDeliveries returns the delivery log of a subscription, newest first
+func (repo *webhookRepository) GetDeliveries(ctx context.Context, subscriptionId int, limit int) ([]*models.WebhookDelivery, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, subscription_id, event_type, payload, status, attempts, next_attempt_at,
+		last_error, response_status, created_at, updated_at, delivered_at
+	FROM webhook_deliveries
+	WHERE subscription_id = $1
+	ORDER BY id DESC
+	LIMIT $2
+	`
+
+	return repo.queryDeliveries(ctx, query, subscriptionId, limit)
+}
+
+// ClaimDueDeliveries leases pending deliveries whose next attempt is due. Rows locked
+// by another instance are skipped, and the lease keeps them from being picked up
+// again while they are being sent. Without row locks the transaction holds the write
+// lock, so no other instance claims at the same time.
+func (repo *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	UPDATE webhook_deliveries
+	SET locked_until = $2
+	WHERE id IN (
+		SELECT id FROM webhook_deliveries
+		WHERE status = $3 AND next_attempt_at <= $1
+			AND (locked_until IS NULL OR locked_until <= $1)
+		ORDER BY next_attempt_at
+		LIMIT $4
+		` + repo.dialect.skipLocked("webhook_deliveries") + `
+	)
+	RETURNING id, subscription_id, event_type, payload, status, attempts, next_attempt_at,
+		last_error, response_status, created_at, updated_at, delivered_at
+	`
+
+	return repo.queryDeliveries(ctx, query, now, now.Add(lease), models.WebhookDeliveryPending, limit)
+}
+
+// UpdateDelivery stores the outcome of an attempt and releases the lease
+func (repo *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	UPDATE webhook_deliveries
+	SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4,
+		response_status = $5, updated_at = $6, delivered_at = $7, locked_until = NULL
+	WHERE id = $8
+	`
+
+	result, error := repo.database.ExecContext(ctx,
+		query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastError,
+		delivery.ResponseStatus,
+		delivery.UpdatedAt,
+		delivery.DeliveredAt,
+		delivery.ID,
+	)
+	if error != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", error)
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return fmt.Errorf("failed to get rows affected: %w", error)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.ErrNotFound.New("webhook delivery not found")
+	}
+
+	return nil
+}
+
+// RequeueDeadDelivery puts a dead delivery back in the queue with a fresh retry budget.
+// Deliveries that are not dead, or still leased to a dispatcher, are a conflict: they
+// would be sent twice.
+func (repo *webhookRepository) RequeueDeadDelivery(ctx context.Context, id int, now time.Time) (*models.WebhookDelivery, error) {
+	ctx, cancel := withQueryTimeout(ctx, repo.queryTimeout)
+	defer cancel()
+
+	query := `
+	UPDATE webhook_deliveries
+	SET status = $2, attempts = 0, next_attempt_at = $3, updated_at = $3, delivered_at = NULL
+	WHERE id = $1 AND status = $4 AND (locked_until IS NULL OR locked_until < $3)
+	RETURNING id, subscription_id, event_type, payload, status, attempts, next_attempt_at,
+		last_error, response_status, created_at, updated_at, delivered_at
+	`
+
+	deliveries, error := repo.queryDeliveries(ctx, query, id, models.WebhookDeliveryPending, now, models.WebhookDeliveryDead)
+	if error != nil {
+		return nil, error
+	}
+	if len(deliveries) == 0 {
+		return nil, apperrors.ErrConflict.New("only dead webhook deliveries can be retried")
+	}
+	return deliveries[0], nil
+}
+
+func (repo *webhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	rows, error := repo.database.QueryContext(ctx, query, args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", error)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row rowScanner) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	var eventTypes []byte
+
+	if err := row.Scan(
+		&subscription.ID,
+		&subscription.UserId,
+		&subscription.URL,
+		&eventTypes,
+		&subscription.Secret,
+		&subscription.Active,
+		&subscription.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(eventTypes, &subscription.EventTypes); err != nil {
+		return nil, fmt.Errorf("failed to decode event types: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var payload []byte
+
+	if err := row.Scan(
+		&delivery.ID,
+		&delivery.SubscriptionId,
+		&delivery.EventType,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastError,
+		&delivery.ResponseStatus,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+		&delivery.DeliveredAt,
+	); err != nil {
+		return nil, err
+	}
+
+	delivery.Payload = payload
+	return &delivery, nil
+}
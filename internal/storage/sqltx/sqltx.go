@@ -0,0 +1,199 @@
+// Package sqltx runs the repositories of the database/sql backends inside
+// transactions. WithinTx puts its transaction into the context, and every call made
+// through the DB with that context joins it. Nested WithinTx calls use savepoints.
+package sqltx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"task_API/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options of a DB
+type Options struct {
+	// MaxRetries is how often a transaction that failed with a retryable error is run again
+	MaxRetries int
+	// Retryable reports whether an error is a deadlock or a busy database, after which
+	// the whole transaction can be run again
+	Retryable func(error) bool
+	// Observe, when set, is told how long every statement took
+	Observe func(query string, elapsed time.Duration)
+	// System names the database in the spans of the statements, such as postgresql
+	System string
+}
+
+// DB runs queries on the transaction of the context, or on the pool without one
+type DB struct {
+	pool    *sql.DB
+	options Options
+}
+
+func New(pool *sql.DB, options Options) *DB {
+	return &DB{pool: pool, options: options}
+}
+
+// executor is implemented by both *sql.DB and *sql.Tx
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txKey is the context key of the transaction, one per DB so that a transaction is
+// never joined by queries meant for another database
+type txKey struct {
+	db *DB
+}
+
+type txState struct {
+	tx         *sql.Tx
+	savepoints int
+}
+
+func (db *DB) state(ctx context.Context) (*txState, bool) {
+	state, ok := ctx.Value(txKey{db}).(*txState)
+	return state, ok
+}
+
+func (db *DB) executor(ctx context.Context) executor {
+	if state, ok := db.state(ctx); ok {
+		return state.tx
+	}
+	return db.pool
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	ctx, done := db.start(ctx, query)
+	defer func() { done(err) }()
+	return db.executor(ctx).ExecContext(ctx, query, args...)
+}
+
+// QueryContext is observed until the first rows are ready, not while they are read
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	ctx, done := db.start(ctx, query)
+	defer func() { done(err) }()
+	return db.executor(ctx).QueryContext(ctx, query, args...)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) (row *sql.Row) {
+	ctx, done := db.start(ctx, query)
+	defer func() { done(row.Err()) }()
+	return db.executor(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// start opens the span of a statement. The returned function ends it with the error
+// of the statement and records its duration.
+func (db *DB) start(ctx context.Context, query string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracing.StartKind(ctx, trace.SpanKindClient, "db.query",
+		attribute.String("db.system", db.options.System),
+		attribute.String("db.statement", query),
+		attribute.Bool("db.in_transaction", db.InTx(ctx)),
+	)
+	return ctx, func(err error) {
+		if err == sql.ErrNoRows {
+			// Not finding a row is an answer, not a failure
+			err = nil
+		}
+		tracing.End(span, err)
+		if db.options.Observe != nil {
+			db.options.Observe(query, time.Since(start))
+		}
+	}
+}
+
+// InTx reports whether the context carries a transaction of this DB
+func (db *DB) InTx(ctx context.Context) bool {
+	_, ok := db.state(ctx)
+	return ok
+}
+
+// WithinTx runs fn in a transaction that is committed when fn returns nil and rolled
+// back otherwise. Inside a transaction it runs fn under a savepoint instead, so a
+// failing fn only undoes its own work. A transaction that fails with a retryable
+// error is run again from the start, fn must not have effects outside of it.
+//
+// Transactions run at the default isolation level of the database, READ COMMITTED on
+// Postgres, so they do not fail on serialization conflicts. A row that is read to
+// decide a write has to be locked, as GetTaskByIdForUpdate does.
+func (db *DB) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if state, ok := db.state(ctx); ok {
+		return db.savepoint(ctx, state, fn)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := db.run(ctx, fn)
+		if err == nil || attempt >= db.options.MaxRetries || db.options.Retryable == nil || !db.options.Retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+}
+
+func (db *DB) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	ctx, span := tracing.StartKind(ctx, trace.SpanKindClient, "db.transaction", attribute.String("db.system", db.options.System))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := db.pool.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		// Also reached when fn panics
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{db}, &txState{tx: tx})); err != nil {
+		return err
+	}
+
+	committed = true
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) savepoint(ctx context.Context, state *txState, fn func(ctx context.Context) error) error {
+	state.savepoints++
+	name := fmt.Sprintf("sp_%d", state.savepoints)
+	defer func() { state.savepoints-- }()
+
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	if err := fn(ctx); err != nil {
+		// The savepoint is rolled back even when the error is a cancelled context
+		if _, rollbackErr := state.tx.ExecContext(context.WithoutCancel(ctx), "ROLLBACK TO SAVEPOINT "+name); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback to savepoint failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	if _, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+// retryDelay waits a little longer after every failed attempt, so that the
+// transactions that conflicted do not collide again right away
+func retryDelay(attempt int) time.Duration {
+	return time.Duration(attempt+1) * 10 * time.Millisecond
+}
@@ -0,0 +1,261 @@
+package sqltx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	_ "modernc.org/sqlite"
+)
+
+var errConflict = errors.New("conflict")
+
+func openTestDB(t *testing.T, options Options) *DB {
+	t.Helper()
+	pool, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+
+	if _, err := pool.Exec(`CREATE TABLE items (name TEXT NOT NULL)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	return New(pool, options)
+}
+
+func insert(t *testing.T, ctx context.Context, db *DB, name string) {
+	t.Helper()
+	if _, err := db.ExecContext(ctx, `INSERT INTO items (name) VALUES ($1)`, name); err != nil {
+		t.Fatalf("insert %s: %v", name, err)
+	}
+}
+
+func names(t *testing.T, db *DB) []string {
+	t.Helper()
+	rows, err := db.QueryContext(context.Background(), `SELECT name FROM items ORDER BY name`)
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+func TestWithinTxJoinsTheTransaction(t *testing.T) {
+	db := openTestDB(t, Options{})
+	ctx := context.Background()
+
+	err := db.WithinTx(ctx, func(ctx context.Context) error {
+		if !db.InTx(ctx) {
+			t.Error("expected the context of fn to carry the transaction")
+		}
+		insert(t, ctx, db, "a")
+
+		var count int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM items`).Scan(&count); err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("expected the transaction to see its own insert, got %d rows", count)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+	if db.InTx(ctx) {
+		t.Error("expected the outer context to carry no transaction")
+	}
+	if got := names(t, db); len(got) != 1 {
+		t.Fatalf("expected the insert to be committed, got %v", got)
+	}
+}
+
+func TestWithinTxRollsBackOnError(t *testing.T) {
+	db := openTestDB(t, Options{})
+
+	err := db.WithinTx(context.Background(), func(ctx context.Context) error {
+		insert(t, ctx, db, "a")
+		return errConflict
+	})
+	if !errors.Is(err, errConflict) {
+		t.Fatalf("WithinTx returned %v, want the error of fn", err)
+	}
+	if got := names(t, db); len(got) != 0 {
+		t.Fatalf("expected the insert to be rolled back, got %v", got)
+	}
+}
+
+func TestWithinTxRollsBackOnPanic(t *testing.T) {
+	db := openTestDB(t, Options{})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to propagate")
+			}
+		}()
+		db.WithinTx(context.Background(), func(ctx context.Context) error {
+			insert(t, ctx, db, "a")
+			panic("boom")
+		})
+	}()
+
+	if got := names(t, db); len(got) != 0 {
+		t.Fatalf("expected the insert to be rolled back, got %v", got)
+	}
+}
+
+func TestNestedWithinTxUsesSavepoints(t *testing.T) {
+	db := openTestDB(t, Options{})
+
+	err := db.WithinTx(context.Background(), func(ctx context.Context) error {
+		insert(t, ctx, db, "outer")
+
+		err := db.WithinTx(ctx, func(ctx context.Context) error {
+			insert(t, ctx, db, "failed")
+			return errConflict
+		})
+		if !errors.Is(err, errConflict) {
+			t.Errorf("nested WithinTx returned %v, want the error of fn", err)
+		}
+
+		return db.WithinTx(ctx, func(ctx context.Context) error {
+			insert(t, ctx, db, "released")
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+
+	got := names(t, db)
+	if len(got) != 2 || got[0] != "outer" || got[1] != "released" {
+		t.Fatalf("expected outer and released to be committed, got %v", got)
+	}
+}
+
+func TestWithinTxRetriesRetryableErrors(t *testing.T) {
+	retryable := func(err error) bool { return errors.Is(err, errConflict) }
+	db := openTestDB(t, Options{MaxRetries: 2, Retryable: retryable})
+
+	attempts := 0
+	err := db.WithinTx(context.Background(), func(ctx context.Context) error {
+		attempts++
+		insert(t, ctx, db, "attempt")
+		if attempts < 3 {
+			return errConflict
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+	if got := names(t, db); len(got) != 1 {
+		t.Fatalf("expected only the last attempt to be committed, got %v", got)
+	}
+
+	// Retries are bounded by MaxRetries
+	attempts = 0
+	err = db.WithinTx(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return errConflict
+	})
+	if !errors.Is(err, errConflict) || attempts != 3 {
+		t.Fatalf("expected to give up after 3 attempts, got %d attempts and %v", attempts, err)
+	}
+
+	// Other errors are not retried
+	attempts = 0
+	db.WithinTx(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return errors.New("invalid")
+	})
+	if attempts != 1 {
+		t.Fatalf("expected a single attempt for an error that is not retryable, got %d", attempts)
+	}
+}
+
+func TestNestedWithinTxIsNotRetried(t *testing.T) {
+	retryable := func(err error) bool { return errors.Is(err, errConflict) }
+	db := openTestDB(t, Options{MaxRetries: 2, Retryable: retryable})
+
+	outer, inner := 0, 0
+	db.WithinTx(context.Background(), func(ctx context.Context) error {
+		outer++
+		return db.WithinTx(ctx, func(ctx context.Context) error {
+			inner++
+			return errConflict
+		})
+	})
+	// The whole transaction is run again, not only the savepoint
+	if outer != 3 || inner != 3 {
+		t.Fatalf("expected 3 outer and 3 inner attempts, got %d and %d", outer, inner)
+	}
+}
+
+func TestStatementsAreTraced(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	db := openTestDB(t, Options{System: "sqlite"})
+	err := db.WithinTx(context.Background(), func(ctx context.Context) error {
+		insert(t, ctx, db, "a")
+		var name string
+		return db.QueryRowContext(ctx, `SELECT name FROM items WHERE name = $1`, "b").Scan(&name)
+	})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("err = %v, want sql.ErrNoRows", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("spans = %d, want the two statements and the transaction", len(spans))
+	}
+	transaction := spans[2]
+	if transaction.Name() != "db.transaction" || transaction.Status().Code != codes.Error {
+		t.Fatalf("transaction span = %s %v, want a failed db.transaction", transaction.Name(), transaction.Status())
+	}
+	for _, statement := range spans[:2] {
+		if statement.Name() != "db.query" || statement.Parent().SpanID() != transaction.SpanContext().SpanID() {
+			t.Fatalf("span %s is not a statement of the transaction", statement.Name())
+		}
+		// A missing row is not a failure of the statement
+		if statement.Status().Code == codes.Error {
+			t.Fatalf("statement span failed: %v", statement.Status())
+		}
+	}
+	if got := spans[0].Attributes(); !containsAttribute(got, attribute.String("db.system", "sqlite")) {
+		t.Fatalf("attributes = %v, want db.system", got)
+	}
+}
+
+func containsAttribute(attributes []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attributes {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
@@ -1,21 +1,57 @@
 package storage
 
-import "task_API/internal/models"
+import (
+	"context"
+	"fmt"
 
-type Storage interface {
-	// User methods
-	CreateUser(name string, email string, passwordHash string) (models.User, error)
-	GetUserByEmail(email string) (models.User, error)
-	GetUserById(id int) (models.User, error)
+	"task_API/internal/config"
+	"task_API/internal/storage/memory"
+	"task_API/internal/storage/postgres"
+	"task_API/internal/storage/repositories"
+	"task_API/internal/storage/sqlite"
+	"task_API/pkg/logger"
+)
 
-	// Tasks methods
-	CreateTask(title string, userID int) (models.Task, error)
-	GetAllTasks(userID int) ([]models.Task, error)
-	GetTaskById(id int, userID int) (models.Task, error)
-	UpdateTask(id int, title string, completed bool, userID int) (models.Task, error)
-	DeleteTask(id int, userID int) error
+// Storage is a storage backend. Every backend implements the same repository
+// contracts, so services do not depend on where the data lives.
+type Storage interface {
+	Users() repositories.UserRepository
+	Tasks() repositories.TaskRepository
+	TaskEvents() repositories.TaskEventRepository
+	AuditLogs() repositories.AuditLogRepository
+	Webhooks() repositories.WebhookRepository
+	Reminders() repositories.ReminderRepository
+	Notifications() repositories.NotificationRepository
+	// TxManager runs several repository calls as one transaction
+	TxManager() repositories.TxManager
 
-	// setup
-	CreateTables() error
+	// Migrate brings the schema up to date
+	Migrate(ctx context.Context) error
+	// Ping checks that the database answers
+	Ping(ctx context.Context) error
+	// SchemaVersion returns the applied and the latest known migration version
+	SchemaVersion(ctx context.Context) (current int, latest int, err error)
 	Close() error
 }
+
+// Open creates the backend selected by cfg.Driver. Migrations log through appLogger.
+func Open(cfg *config.DatabaseConfig, appLogger *logger.Logger) (Storage, error) {
+	switch cfg.Driver {
+	case "postgres":
+		postgresStorage, err := postgres.Open(cfg, appLogger)
+		if err != nil {
+			return nil, err
+		}
+		return postgresStorage, nil
+	case "sqlite":
+		sqliteStorage, err := sqlite.Open(cfg, appLogger)
+		if err != nil {
+			return nil, err
+		}
+		return sqliteStorage, nil
+	case "memory":
+		return memory.NewStorage(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,120 @@
+// Package storagetest holds the conformance suite every storage backend must pass.
+// A backend test calls Run with a function that opens an empty backend:
+//
+//	func TestConformance(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+//			return memory.NewStorage()
+//		})
+//	}
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/storage/repositories"
+	apperrors "task_API/pkg/errors"
+)
+
+// Backend is the part of a storage backend the suite exercises
+type Backend interface {
+	Users() repositories.UserRepository
+	Tasks() repositories.TaskRepository
+	Webhooks() repositories.WebhookRepository
+	TxManager() repositories.TxManager
+}
+
+// Opener returns a backend without any data. It is called once per test case.
+type Opener func(t *testing.T) Backend
+
+type testCase struct {
+	name string
+	run  func(t *testing.T, ctx context.Context, backend Backend)
+}
+
+// Run runs every conformance test against the backend
+func Run(t *testing.T, open Opener) {
+	t.Run("UserRepository", func(t *testing.T) {
+		runCases(t, open, userCases)
+	})
+	t.Run("TaskRepository", func(t *testing.T) {
+		runCases(t, open, taskCases)
+	})
+	t.Run("WebhookRepository", func(t *testing.T) {
+		runCases(t, open, webhookCases)
+	})
+	t.Run("TxManager", func(t *testing.T) {
+		runCases(t, open, txCases)
+	})
+}
+
+func runCases(t *testing.T, open Opener, cases []testCase) {
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.run(t, context.Background(), open(t))
+		})
+	}
+}
+
+// baseTime is the clock of the suite. Timestamps are rounded by some databases, so
+// the suite uses whole microseconds plus a fraction to check precision.
+var baseTime = time.Date(2024, 5, 1, 9, 30, 0, 123456000, time.UTC)
+
+func newUser(t *testing.T, ctx context.Context, backend Backend, email string) *models.User {
+	t.Helper()
+	user := &models.User{
+		Name:         "User " + email,
+		Email:        email,
+		PasswordHash: "hash-" + email,
+		Role:         models.RoleUser,
+		CreatedAt:    baseTime,
+		UpdatedAt:    baseTime,
+	}
+	if err := backend.Users().CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser(%s): %v", email, err)
+	}
+	return user
+}
+
+func newTask(t *testing.T, ctx context.Context, backend Backend, userId int, title string, createdAt time.Time) *models.Task {
+	t.Helper()
+	task := &models.Task{
+		Title:     title,
+		UserId:    userId,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	if err := backend.Tasks().CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask(%s): %v", title, err)
+	}
+	return task
+}
+
+// sameInstant compares timestamps at the microsecond precision databases keep
+func sameInstant(a, b time.Time) bool {
+	difference := a.Sub(b)
+	return difference > -time.Microsecond && difference < time.Microsecond
+}
+
+func assertSameInstant(t *testing.T, field string, got, want time.Time) {
+	t.Helper()
+	if !sameInstant(got, want) {
+		t.Errorf("%s = %v, want %v", field, got, want)
+	}
+}
+
+func assertNotFound(t *testing.T, err error, message string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected %q error, got nil", message)
+	}
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	if err.Error() != message {
+		t.Fatalf("expected %q error, got %q", message, err.Error())
+	}
+}
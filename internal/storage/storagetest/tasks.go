@@ -0,0 +1,275 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"task_API/internal/models"
+)
+
+var taskCases = []testCase{
+	{
+		name: "create round trips every field",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			user := newUser(t, ctx, backend, "owner@example.com")
+			dueDate := baseTime.Add(48 * time.Hour)
+
+			task := &models.Task{
+				Title:           "Write report",
+				UserId:          user.ID,
+				DueDate:         &dueDate,
+				ReminderOffsets: []int{0, 60},
+				CreatedAt:       baseTime,
+				UpdatedAt:       baseTime,
+			}
+			if err := backend.Tasks().CreateTask(ctx, task); err != nil {
+				t.Fatalf("CreateTask: %v", err)
+			}
+			if task.ID == 0 {
+				t.Fatal("CreateTask did not assign an id")
+			}
+
+			got, err := backend.Tasks().GetTaskById(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("GetTaskById: %v", err)
+			}
+			if got.Title != "Write report" || got.Completed || got.UserId != user.ID || got.DeletedAt != nil {
+				t.Errorf("unexpected task %+v", got)
+			}
+			if got.DueDate == nil {
+				t.Fatal("due date was not stored")
+			}
+			assertSameInstant(t, "DueDate", *got.DueDate, dueDate)
+			assertSameInstant(t, "CreatedAt", got.CreatedAt, baseTime)
+			assertSameInstant(t, "UpdatedAt", got.UpdatedAt, baseTime)
+			if len(got.ReminderOffsets) != 2 || got.ReminderOffsets[0] != 0 || got.ReminderOffsets[1] != 60 {
+				t.Errorf("ReminderOffsets = %v, want [0 60]", got.ReminderOffsets)
+			}
+		},
+	},
+	{
+		name: "create for a missing user fails",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			task := &models.Task{Title: "Nobody's", UserId: 4242, CreatedAt: baseTime, UpdatedAt: baseTime}
+			if err := backend.Tasks().CreateTask(ctx, task); err == nil {
+				t.Fatal("expected an error creating a task for a missing user")
+			}
+		},
+	},
+	{
+		name: "missing task is not found",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			_, err := backend.Tasks().GetTaskById(ctx, 4242)
+			assertNotFound(t, err, "task not found")
+		},
+	},
+	{
+		name: "list returns only the owner's tasks, newest first",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			owner := newUser(t, ctx, backend, "owner@example.com")
+			other := newUser(t, ctx, backend, "other@example.com")
+
+			oldest := newTask(t, ctx, backend, owner.ID, "Oldest", baseTime)
+			newest := newTask(t, ctx, backend, owner.ID, "Newest", baseTime.Add(2*time.Minute))
+			middle := newTask(t, ctx, backend, owner.ID, "Middle", baseTime.Add(time.Minute))
+			newTask(t, ctx, backend, other.ID, "Someone else's", baseTime.Add(time.Hour))
+
+			tasks, err := backend.Tasks().GetAllTasks(ctx, owner.ID)
+			if err != nil {
+				t.Fatalf("GetAllTasks: %v", err)
+			}
+
+			want := []int{newest.ID, middle.ID, oldest.ID}
+			if len(tasks) != len(want) {
+				t.Fatalf("got %d tasks, want %d", len(tasks), len(want))
+			}
+			for index, task := range tasks {
+				if task.ID != want[index] {
+					t.Errorf("tasks[%d] = %d (%s), want %d", index, task.ID, task.Title, want[index])
+				}
+				if task.UserId != owner.ID {
+					t.Errorf("task %d belongs to user %d", task.ID, task.UserId)
+				}
+			}
+		},
+	},
+	{
+		name: "list of a user without tasks is empty",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			user := newUser(t, ctx, backend, "idle@example.com")
+			tasks, err := backend.Tasks().GetAllTasks(ctx, user.ID)
+			if err != nil {
+				t.Fatalf("GetAllTasks: %v", err)
+			}
+			if len(tasks) != 0 {
+				t.Fatalf("expected no tasks, got %d", len(tasks))
+			}
+		},
+	},
+	{
+		name: "update changes fields and keeps created at",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			user := newUser(t, ctx, backend, "owner@example.com")
+			task := newTask(t, ctx, backend, user.ID, "Draft", baseTime)
+			updatedAt := baseTime.Add(time.Hour)
+
+			update := &models.Task{
+				ID:        task.ID,
+				Title:     "Final",
+				Completed: true,
+				UserId:    user.ID,
+				UpdatedAt: updatedAt,
+			}
+			if err := backend.Tasks().UpdateTask(ctx, update); err != nil {
+				t.Fatalf("UpdateTask: %v", err)
+			}
+			assertSameInstant(t, "returned CreatedAt", update.CreatedAt, baseTime)
+
+			got, err := backend.Tasks().GetTaskById(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("GetTaskById: %v", err)
+			}
+			if got.Title != "Final" || !got.Completed {
+				t.Errorf("update not stored: %+v", got)
+			}
+			assertSameInstant(t, "CreatedAt", got.CreatedAt, baseTime)
+			assertSameInstant(t, "UpdatedAt", got.UpdatedAt, updatedAt)
+		},
+	},
+	{
+		name: "update by another user is not found and changes nothing",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			owner := newUser(t, ctx, backend, "owner@example.com")
+			intruder := newUser(t, ctx, backend, "intruder@example.com")
+			task := newTask(t, ctx, backend, owner.ID, "Mine", baseTime)
+
+			err := backend.Tasks().UpdateTask(ctx, &models.Task{
+				ID:        task.ID,
+				Title:     "Stolen",
+				UserId:    intruder.ID,
+				UpdatedAt: baseTime.Add(time.Hour),
+			})
+			assertNotFound(t, err, "task not found")
+
+			got, err := backend.Tasks().GetTaskById(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("GetTaskById: %v", err)
+			}
+			if got.Title != "Mine" || got.UserId != owner.ID {
+				t.Errorf("task changed by another user: %+v", got)
+			}
+		},
+	},
+	{
+		name: "update of a missing task is not found",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			user := newUser(t, ctx, backend, "owner@example.com")
+			err := backend.Tasks().UpdateTask(ctx, &models.Task{ID: 4242, Title: "Ghost", UserId: user.ID, UpdatedAt: baseTime})
+			assertNotFound(t, err, "task not found")
+		},
+	},
+	{
+		name: "delete is soft and hides the task from the list",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			user := newUser(t, ctx, backend, "owner@example.com")
+			task := newTask(t, ctx, backend, user.ID, "Doomed", baseTime)
+			deletedAt := baseTime.Add(time.Hour)
+
+			if err := backend.Tasks().DeleteTask(ctx, task.ID, deletedAt); err != nil {
+				t.Fatalf("DeleteTask: %v", err)
+			}
+
+			got, err := backend.Tasks().GetTaskById(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("GetTaskById of a deleted task: %v", err)
+			}
+			if got.DeletedAt == nil {
+				t.Fatal("DeletedAt not set")
+			}
+			assertSameInstant(t, "DeletedAt", *got.DeletedAt, deletedAt)
+
+			tasks, err := backend.Tasks().GetAllTasks(ctx, user.ID)
+			if err != nil {
+				t.Fatalf("GetAllTasks: %v", err)
+			}
+			if len(tasks) != 0 {
+				t.Errorf("deleted task is still listed")
+			}
+
+			assertNotFound(t, backend.Tasks().DeleteTask(ctx, task.ID, deletedAt), "task not found")
+
+			err = backend.Tasks().UpdateTask(ctx, &models.Task{ID: task.ID, Title: "Revived", UserId: user.ID, UpdatedAt: deletedAt})
+			assertNotFound(t, err, "task not found")
+		},
+	},
+	{
+		name: "delete of a missing task is not found",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			assertNotFound(t, backend.Tasks().DeleteTask(ctx, 4242, baseTime), "task not found")
+		},
+	},
+	{
+		name: "restore brings a deleted task back",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			user := newUser(t, ctx, backend, "owner@example.com")
+			task := newTask(t, ctx, backend, user.ID, "Phoenix", baseTime)
+			restoredAt := baseTime.Add(2 * time.Hour)
+
+			if err := backend.Tasks().DeleteTask(ctx, task.ID, baseTime.Add(time.Hour)); err != nil {
+				t.Fatalf("DeleteTask: %v", err)
+			}
+			if err := backend.Tasks().RestoreTask(ctx, task.ID, restoredAt); err != nil {
+				t.Fatalf("RestoreTask: %v", err)
+			}
+
+			got, err := backend.Tasks().GetTaskById(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("GetTaskById: %v", err)
+			}
+			if got.DeletedAt != nil {
+				t.Error("DeletedAt still set after restore")
+			}
+			assertSameInstant(t, "UpdatedAt", got.UpdatedAt, restoredAt)
+
+			tasks, err := backend.Tasks().GetAllTasks(ctx, user.ID)
+			if err != nil {
+				t.Fatalf("GetAllTasks: %v", err)
+			}
+			if len(tasks) != 1 || tasks[0].ID != task.ID {
+				t.Errorf("restored task is not listed")
+			}
+
+			assertNotFound(t, backend.Tasks().RestoreTask(ctx, task.ID, restoredAt), "deleted task not found")
+		},
+	},
+	{
+		name: "calls with a cancelled context fail with its error",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			user := newUser(t, ctx, backend, "owner@example.com")
+			task := newTask(t, ctx, backend, user.ID, "Unreachable", baseTime)
+
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+
+			if _, err := backend.Tasks().GetTaskById(cancelled, task.ID); !errors.Is(err, context.Canceled) {
+				t.Errorf("GetTaskById: expected context.Canceled, got %v", err)
+			}
+			if _, err := backend.Tasks().GetAllTasks(cancelled, user.ID); !errors.Is(err, context.Canceled) {
+				t.Errorf("GetAllTasks: expected context.Canceled, got %v", err)
+			}
+			if err := backend.Tasks().DeleteTask(cancelled, task.ID, baseTime); !errors.Is(err, context.Canceled) {
+				t.Errorf("DeleteTask: expected context.Canceled, got %v", err)
+			}
+
+			got, err := backend.Tasks().GetTaskById(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("GetTaskById: %v", err)
+			}
+			if got.DeletedAt != nil {
+				t.Error("task was deleted with a cancelled context")
+			}
+		},
+	},
+}
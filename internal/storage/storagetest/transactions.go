@@ -0,0 +1,156 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errRollback = errors.New("rollback")
+
+var txCases = []testCase{
+	{
+		name: "commit keeps every write",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			var userId, taskId int
+			err := backend.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+				user := newUser(t, ctx, backend, "tx@example.com")
+				userId = user.ID
+				taskId = newTask(t, ctx, backend, user.ID, "In a transaction", baseTime).ID
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("WithinTx: %v", err)
+			}
+
+			if _, err := backend.Users().GetUserById(ctx, userId); err != nil {
+				t.Errorf("GetUserById after commit: %v", err)
+			}
+			if _, err := backend.Tasks().GetTaskById(ctx, taskId); err != nil {
+				t.Errorf("GetTaskById after commit: %v", err)
+			}
+		},
+	},
+	{
+		name: "error rolls back every write",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			var taskId int
+			err := backend.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+				user := newUser(t, ctx, backend, "tx@example.com")
+				taskId = newTask(t, ctx, backend, user.ID, "Rolled back", baseTime).ID
+
+				// Writes are visible inside the transaction
+				if _, err := backend.Tasks().GetTaskById(ctx, taskId); err != nil {
+					t.Errorf("GetTaskById inside the transaction: %v", err)
+				}
+				return errRollback
+			})
+			if !errors.Is(err, errRollback) {
+				t.Fatalf("WithinTx returned %v, want the error of fn", err)
+			}
+
+			_, err = backend.Users().GetUserByEmail(ctx, "tx@example.com")
+			assertNotFound(t, err, "user not found")
+			_, err = backend.Tasks().GetTaskById(ctx, taskId)
+			assertNotFound(t, err, "task not found")
+		},
+	},
+	{
+		name: "nested failure rolls back only the inner work",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			var outerId, innerId int
+			err := backend.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+				user := newUser(t, ctx, backend, "tx@example.com")
+				outerId = newTask(t, ctx, backend, user.ID, "Outer", baseTime).ID
+
+				err := backend.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+					innerId = newTask(t, ctx, backend, user.ID, "Inner", baseTime).ID
+					return errRollback
+				})
+				if !errors.Is(err, errRollback) {
+					t.Errorf("nested WithinTx returned %v, want the error of fn", err)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("WithinTx: %v", err)
+			}
+
+			if _, err := backend.Tasks().GetTaskById(ctx, outerId); err != nil {
+				t.Errorf("outer task was rolled back: %v", err)
+			}
+			_, err = backend.Tasks().GetTaskById(ctx, innerId)
+			assertNotFound(t, err, "task not found")
+		},
+	},
+	{
+		name: "panic rolls back and propagates",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Error("expected the panic of fn to propagate")
+					}
+				}()
+				backend.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+					newUser(t, ctx, backend, "tx@example.com")
+					panic("boom")
+				})
+			}()
+
+			_, err := backend.Users().GetUserByEmail(ctx, "tx@example.com")
+			assertNotFound(t, err, "user not found")
+
+			// The backend is still usable after the panic
+			newUser(t, ctx, backend, "after@example.com")
+		},
+	},
+	{
+		name: "task read for update waits for the transaction that locked it",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			user := newUser(t, ctx, backend, "tx@example.com")
+			taskId := newTask(t, ctx, backend, user.ID, "Original", baseTime).ID
+
+			locked := make(chan struct{})
+			first := make(chan error, 1)
+			go func() {
+				first <- backend.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+					task, err := backend.Tasks().GetTaskByIdForUpdate(ctx, taskId)
+					if err != nil {
+						return err
+					}
+					close(locked)
+					time.Sleep(50 * time.Millisecond)
+					task.Title = "Changed first"
+					return backend.Tasks().UpdateTask(ctx, task)
+				})
+			}()
+
+			select {
+			case <-locked:
+			case err := <-first:
+				t.Fatalf("first WithinTx: %v", err)
+			}
+
+			var seen string
+			err := backend.TxManager().WithinTx(ctx, func(ctx context.Context) error {
+				task, err := backend.Tasks().GetTaskByIdForUpdate(ctx, taskId)
+				if err != nil {
+					return err
+				}
+				seen = task.Title
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("second WithinTx: %v", err)
+			}
+			if err := <-first; err != nil {
+				t.Fatalf("first WithinTx: %v", err)
+			}
+			if seen != "Changed first" {
+				t.Errorf("second transaction read %q, want the committed change", seen)
+			}
+		},
+	},
+}
@@ -0,0 +1,146 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"task_API/internal/models"
+	apperrors "task_API/pkg/errors"
+)
+
+var userCases = []testCase{
+	{
+		name: "create assigns distinct ids",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			first := newUser(t, ctx, backend, "first@example.com")
+			second := newUser(t, ctx, backend, "second@example.com")
+			if first.ID == 0 || second.ID == 0 || first.ID == second.ID {
+				t.Fatalf("expected distinct non-zero ids, got %d and %d", first.ID, second.ID)
+			}
+		},
+	},
+	{
+		name: "get by id and email round trips every field",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			created := newUser(t, ctx, backend, "round@example.com")
+
+			byId, err := backend.Users().GetUserById(ctx, created.ID)
+			if err != nil {
+				t.Fatalf("GetUserById: %v", err)
+			}
+			byEmail, err := backend.Users().GetUserByEmail(ctx, created.Email)
+			if err != nil {
+				t.Fatalf("GetUserByEmail: %v", err)
+			}
+
+			for _, got := range []*models.User{byId, byEmail} {
+				if got.ID != created.ID || got.Name != created.Name || got.Email != created.Email ||
+					got.PasswordHash != created.PasswordHash || got.Role != created.Role {
+					t.Errorf("got %+v, want %+v", got, created)
+				}
+				assertSameInstant(t, "CreatedAt", got.CreatedAt, baseTime)
+				assertSameInstant(t, "UpdatedAt", got.UpdatedAt, baseTime)
+			}
+		},
+	},
+	{
+		name: "duplicate email is rejected",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			newUser(t, ctx, backend, "taken@example.com")
+			duplicate := &models.User{
+				Name:         "Other",
+				Email:        "taken@example.com",
+				PasswordHash: "other",
+				Role:         models.RoleUser,
+				CreatedAt:    baseTime,
+				UpdatedAt:    baseTime,
+			}
+			if err := backend.Users().CreateUser(ctx, duplicate); !errors.Is(err, apperrors.ErrAlreadyExists) {
+				t.Fatalf("expected an already exists error for a duplicate email, got %v", err)
+			}
+		},
+	},
+	{
+		name: "missing user is not found",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			_, err := backend.Users().GetUserById(ctx, 4242)
+			assertNotFound(t, err, "user not found")
+
+			_, err = backend.Users().GetUserByEmail(ctx, "nobody@example.com")
+			assertNotFound(t, err, "user not found")
+		},
+	},
+	{
+		name: "update changes fields and keeps created at",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			user := newUser(t, ctx, backend, "before@example.com")
+			updatedAt := baseTime.Add(time.Hour)
+
+			user.Name = "Renamed"
+			user.Email = "after@example.com"
+			user.PasswordHash = "new-hash"
+			user.Role = models.RoleAdmin
+			user.UpdatedAt = updatedAt
+			if err := backend.Users().UpdateUser(ctx, user); err != nil {
+				t.Fatalf("UpdateUser: %v", err)
+			}
+
+			got, err := backend.Users().GetUserByEmail(ctx, "after@example.com")
+			if err != nil {
+				t.Fatalf("GetUserByEmail: %v", err)
+			}
+			if got.ID != user.ID || got.Name != "Renamed" || got.PasswordHash != "new-hash" || got.Role != models.RoleAdmin {
+				t.Errorf("update not stored: %+v", got)
+			}
+			assertSameInstant(t, "CreatedAt", got.CreatedAt, baseTime)
+			assertSameInstant(t, "UpdatedAt", got.UpdatedAt, updatedAt)
+
+			if _, err := backend.Users().GetUserByEmail(ctx, "before@example.com"); err == nil {
+				t.Error("old email still resolves to a user")
+			}
+		},
+	},
+	{
+		name: "update of a missing user fails",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			missing := &models.User{ID: 4242, Name: "Ghost", Email: "ghost@example.com", Role: models.RoleUser, UpdatedAt: baseTime}
+			err := backend.Users().UpdateUser(ctx, missing)
+			assertNotFound(t, err, "user not found")
+		},
+	},
+	{
+		name: "delete removes the user and their tasks",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			user := newUser(t, ctx, backend, "leaving@example.com")
+			task := newTask(t, ctx, backend, user.ID, "Orphan", baseTime)
+
+			if err := backend.Users().DeleteUser(ctx, user.ID); err != nil {
+				t.Fatalf("DeleteUser: %v", err)
+			}
+
+			_, err := backend.Users().GetUserById(ctx, user.ID)
+			assertNotFound(t, err, "user not found")
+
+			_, err = backend.Tasks().GetTaskById(ctx, task.ID)
+			assertNotFound(t, err, "task not found")
+		},
+	},
+	{
+		name: "calls with a cancelled context fail with its error",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			user := newUser(t, ctx, backend, "owner@example.com")
+
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+
+			if _, err := backend.Users().GetUserById(cancelled, user.ID); !errors.Is(err, context.Canceled) {
+				t.Errorf("GetUserById: expected context.Canceled, got %v", err)
+			}
+			if _, err := backend.Users().GetUserByEmail(cancelled, user.Email); !errors.Is(err, context.Canceled) {
+				t.Errorf("GetUserByEmail: expected context.Canceled, got %v", err)
+			}
+		},
+	},
+}
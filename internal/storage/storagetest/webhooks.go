@@ -0,0 +1,93 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"task_API/internal/models"
+	apperrors "task_API/pkg/errors"
+)
+
+var webhookCases = []testCase{
+	{
+		name: "requeue gives a dead delivery a fresh retry budget",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			delivery := newDelivery(t, ctx, backend, models.WebhookDeliveryDead)
+			now := baseTime.Add(time.Hour)
+
+			requeued, err := backend.Webhooks().RequeueDeadDelivery(ctx, delivery.ID, now)
+			if err != nil {
+				t.Fatalf("RequeueDeadDelivery: %v", err)
+			}
+			if requeued.Status != models.WebhookDeliveryPending || requeued.Attempts != 0 || !requeued.NextAttemptAt.Equal(now) {
+				t.Errorf("unexpected delivery %+v", requeued)
+			}
+
+			due, err := backend.Webhooks().ClaimDueDeliveries(ctx, now, time.Minute, 10)
+			if err != nil {
+				t.Fatalf("ClaimDueDeliveries: %v", err)
+			}
+			if len(due) != 1 || due[0].ID != delivery.ID {
+				t.Errorf("claimed %+v, want the requeued delivery", due)
+			}
+		},
+	},
+	{
+		name: "requeue refuses deliveries that are not dead",
+		run: func(t *testing.T, ctx context.Context, backend Backend) {
+			// A pending delivery leased to a dispatcher would be sent twice
+			leased := newDelivery(t, ctx, backend, models.WebhookDeliveryPending)
+			if _, err := backend.Webhooks().ClaimDueDeliveries(ctx, baseTime, time.Minute, 10); err != nil {
+				t.Fatalf("ClaimDueDeliveries: %v", err)
+			}
+			delivered := newDelivery(t, ctx, backend, models.WebhookDeliveryDelivered)
+
+			for _, delivery := range []*models.WebhookDelivery{leased, delivered} {
+				if _, err := backend.Webhooks().RequeueDeadDelivery(ctx, delivery.ID, baseTime); !errors.Is(err, apperrors.ErrConflict) {
+					t.Errorf("RequeueDeadDelivery(%s) error = %v, want a conflict", delivery.Status, err)
+				}
+			}
+
+			got, err := backend.Webhooks().GetDeliveryById(ctx, leased.ID)
+			if err != nil {
+				t.Fatalf("GetDeliveryById: %v", err)
+			}
+			if got.Attempts != leased.Attempts {
+				t.Errorf("attempts = %d, want %d untouched", got.Attempts, leased.Attempts)
+			}
+		},
+	},
+}
+
+func newDelivery(t *testing.T, ctx context.Context, backend Backend, status string) *models.WebhookDelivery {
+	t.Helper()
+	user := newUser(t, ctx, backend, status+"@example.com")
+	subscription := &models.WebhookSubscription{
+		UserId:     user.ID,
+		URL:        "https://hooks.example.com/" + status,
+		EventTypes: []string{},
+		Secret:     "top-secret",
+		Active:     true,
+		CreatedAt:  baseTime,
+	}
+	if err := backend.Webhooks().CreateSubscription(ctx, subscription); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+
+	delivery := &models.WebhookDelivery{
+		SubscriptionId: subscription.ID,
+		EventType:      "task.created",
+		Payload:        []byte(`{"type":"task.created"}`),
+		Status:         status,
+		Attempts:       3,
+		NextAttemptAt:  baseTime,
+		CreatedAt:      baseTime,
+		UpdatedAt:      baseTime,
+	}
+	if err := backend.Webhooks().CreateDelivery(ctx, delivery); err != nil {
+		t.Fatalf("CreateDelivery: %v", err)
+	}
+	return delivery
+}
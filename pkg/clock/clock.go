@@ -0,0 +1,83 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of background workers, so tests can control it
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real is the system clock
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+func (Real) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Fake is a manually advanced clock for tests
+type Fake struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at      time.Time
+	channel chan time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (fake *Fake) Now() time.Time {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	return fake.now
+}
+
+func (fake *Fake) After(d time.Duration) <-chan time.Time {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	channel := make(chan time.Time, 1)
+	at := fake.now.Add(d)
+	if d <= 0 {
+		channel <- fake.now
+		return channel
+	}
+	fake.waiters = append(fake.waiters, fakeWaiter{at: at, channel: channel})
+	return channel
+}
+
+// Advance moves the clock forward and fires every timer that became due
+func (fake *Fake) Advance(d time.Duration) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	fake.now = fake.now.Add(d)
+	pending := fake.waiters[:0]
+	for _, waiter := range fake.waiters {
+		if waiter.at.After(fake.now) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.channel <- fake.now
+	}
+	fake.waiters = pending
+}
+
+// Waiters returns the number of timers that have not fired yet
+func (fake *Fake) Waiters() int {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	return len(fake.waiters)
+}
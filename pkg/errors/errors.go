@@ -5,20 +5,87 @@ import (
 	"net/http"
 )
 
+// AppError is an error with the HTTP status it is answered with. Errors made from one
+// of the sentinels below with New, Newf or Wrap match it with errors.Is, so callers
+// check the kind of an error without comparing messages.
 type AppError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 	Details string `json:"details,omitempty"`
 	Err     error  `json:"error"`
+	// Type is the URI reference identifying the kind of problem, empty for none
+	Type string `json:"type,omitempty"`
+	// Fields lists the invalid fields of a validation failure
+	Fields []FieldError `json:"fields,omitempty"`
+
+	// kind is the sentinel the error was made from
+	kind *AppError
 }
 
 func (app *AppError) Error() string {
 	if app.Err != nil {
-		fmt.Sprintf("%s: %s", app.Message, app.Error)
+		return fmt.Sprintf("%s: %v", app.Message, app.Err)
 	}
 	return app.Message
 }
 
+func (app *AppError) Unwrap() error {
+	return app.Err
+}
+
+// Is reports whether target is the sentinel the error was made from
+func (app *AppError) Is(target error) bool {
+	sentinel, ok := target.(*AppError)
+	return ok && app.kind != nil && sentinel == app.kind
+}
+
+// New returns an error of the same kind with its own message
+func (app *AppError) New(message string) *AppError {
+	kind := app.Kind()
+	return &AppError{Code: kind.Code, Message: message, Type: kind.Type, kind: kind}
+}
+
+// Newf is New with a formatted message
+func (app *AppError) Newf(format string, args ...interface{}) *AppError {
+	return app.New(fmt.Sprintf(format, args...))
+}
+
+// Wrap returns an error of the same kind with its own message, caused by err
+func (app *AppError) Wrap(err error, message string) *AppError {
+	wrapped := app.New(message)
+	wrapped.Err = err
+	return wrapped
+}
+
+// WithFields returns a copy of the error that lists the invalid fields
+func (app *AppError) WithFields(fields ...FieldError) *AppError {
+	copied := *app
+	copied.Fields = append(append([]FieldError(nil), app.Fields...), fields...)
+	if copied.kind == nil {
+		copied.kind = app
+	}
+	return &copied
+}
+
+// Kind returns the sentinel the error was made from, or the error itself
+func (app *AppError) Kind() *AppError {
+	if app.kind != nil {
+		return app.kind
+	}
+	return app
+}
+
+// FieldError is the reason a single field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validation returns the error of a request with invalid fields
+func Validation(fields ...FieldError) *AppError {
+	return ErrValidation.WithFields(fields...)
+}
+
 func NewAppError(code int, message string) *AppError {
 	return &AppError{
 		Code:    code,
@@ -34,23 +101,29 @@ func WrapError(err error, code int, message string) *AppError {
 	}
 }
 
+// problemTypes is the prefix of the problem type of every sentinel
+const problemTypes = "/problems/"
+
+func newSentinel(code int, problemType string, message string) *AppError {
+	return &AppError{Code: code, Message: message, Type: problemTypes + problemType}
+}
+
 // Commonly errors
 var (
-	ErrNotFound     = NewAppError(http.StatusNotFound, "resource not found")
-	ErrUnauthorized = NewAppError(http.StatusUnauthorized, "unauthorized")
-	ErrForBidden    = NewAppError(http.StatusForbidden, "forbidden")
-	ErrBadRequest   = NewAppError(http.StatusBadRequest, "bad request")
-	ErrInterServer  = NewAppError(http.StatusInternalServerError, "internal server error")
+	ErrNotFound           = newSentinel(http.StatusNotFound, "not-found", "resource not found")
+	ErrUnauthorized       = newSentinel(http.StatusUnauthorized, "unauthorized", "unauthorized")
+	ErrForBidden          = newSentinel(http.StatusForbidden, "forbidden", "forbidden")
+	ErrBadRequest         = newSentinel(http.StatusBadRequest, "bad-request", "bad request")
+	ErrValidation         = newSentinel(http.StatusBadRequest, "validation", "validation failed")
+	ErrConflict           = newSentinel(http.StatusConflict, "conflict", "conflict")
+	ErrAlreadyExists      = newSentinel(http.StatusConflict, "already-exists", "already exists")
+	ErrInvalidCredentials = newSentinel(http.StatusUnauthorized, "invalid-credentials", "invalid credentials")
+	ErrPayloadTooLarge    = newSentinel(http.StatusRequestEntityTooLarge, "payload-too-large", "payload too large")
+	ErrUnsupportedMedia   = newSentinel(http.StatusUnsupportedMediaType, "unsupported-media-type", "unsupported media type")
+	ErrTooManyRequests    = newSentinel(http.StatusTooManyRequests, "rate-limited", "too many requests")
+	ErrInterServer        = newSentinel(http.StatusInternalServerError, "internal", "internal server error")
 )
 
 func ErrDatabase(err error) *AppError {
-	return WrapError(err, http.StatusInternalServerError, "database error")
-}
-
-func ErrAlreadyExists() *AppError {
-	return NewAppError(http.StatusConflict, "user already exists")
-}
-
-func ErrInvalidCredentials() *AppError {
-	return NewAppError(http.StatusUnauthorized, "invalid credentials")
+	return ErrInterServer.Wrap(err, "database error")
 }
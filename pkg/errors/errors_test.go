@@ -0,0 +1,61 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsMatchesTheSentinelOfAnError(t *testing.T) {
+	cause := errors.New("no rows")
+	notFound := ErrNotFound.Wrap(cause, "task not found")
+	wrapped := fmt.Errorf("failed to update task: %w", notFound)
+
+	if !errors.Is(wrapped, ErrNotFound) {
+		t.Error("expected a wrapped not found error to match ErrNotFound")
+	}
+	if !errors.Is(wrapped, cause) {
+		t.Error("expected the cause to stay reachable")
+	}
+	if errors.Is(wrapped, ErrForBidden) {
+		t.Error("expected a not found error not to match another sentinel")
+	}
+
+	// Derived errors keep the kind of their sentinel
+	if !errors.Is(notFound.New("other"), ErrNotFound) {
+		t.Error("expected an error derived from a derived error to match the sentinel")
+	}
+
+	// Same status is not the same kind
+	if errors.Is(ErrInvalidCredentials.New("wrong password"), ErrUnauthorized) {
+		t.Error("expected invalid credentials not to match ErrUnauthorized")
+	}
+	if errors.Is(NewAppError(404, "resource not found"), ErrNotFound) {
+		t.Error("expected an error not made from the sentinel not to match it")
+	}
+}
+
+func TestNewKeepsTheStatusOfTheSentinel(t *testing.T) {
+	err := ErrAlreadyExists.Newf("user with email %s already exists", "a@example.com")
+	if err.Code != ErrAlreadyExists.Code {
+		t.Errorf("Code = %d, want %d", err.Code, ErrAlreadyExists.Code)
+	}
+	if err.Error() != "user with email a@example.com already exists" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestValidationListsTheFieldsWithoutSharingThem(t *testing.T) {
+	err := Validation(FieldError{Field: "email", Message: "is required"})
+	if !errors.Is(err, ErrValidation) || err.Code != ErrBadRequest.Code || err.Type != "/problems/validation" {
+		t.Fatalf("unexpected validation error %+v", err)
+	}
+
+	more := err.WithFields(FieldError{Field: "name", Message: "is required"})
+	if len(err.Fields) != 1 || len(more.Fields) != 2 {
+		t.Fatalf("fields = %v and %v, want 1 and 2", err.Fields, more.Fields)
+	}
+	if len(ErrValidation.Fields) != 0 {
+		t.Fatalf("the sentinel gained fields %v", ErrValidation.Fields)
+	}
+}
@@ -1,11 +1,13 @@
 package logger
 
 import (
+	"context"
 	"io"
 	"os"
 	"time"
 
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Logger struct {
@@ -36,22 +38,67 @@ func NewLogger(level string, format string) *Logger {
 		With().
 		Timestamp().
 		Caller().
-		Logger()
+		Logger().
+		Hook(traceHook{})
 
 	return &Logger{logger}
 }
 
+// traceHook adds the trace and span ids to the lines logged with a context carrying
+// a span, so that the logs of a request can be found from its trace
+type traceHook struct{}
+
+func (traceHook) Run(event *zerolog.Event, level zerolog.Level, message string) {
+	spanContext := trace.SpanContextFromContext(event.GetCtx())
+	if !spanContext.IsValid() {
+		return
+	}
+	event.Str("trace_id", spanContext.TraceID().String()).
+		Str("span_id", spanContext.SpanID().String())
+}
+
 // helpers
 func (logger *Logger) Info() *zerolog.Event {
 	return logger.Logger.Info()
 }
 
-func (logger *Logger) APIRequest(method, path string, status int, duration time.Duration) {
-	logger.Info().
-		Str("method", method).
-		Str("path", path).
-		Int("status", status).
-		Dur("duration", duration).
+// RequestLog describes an answered request
+type RequestLog struct {
+	Method    string
+	Path      string
+	Route     string
+	Status    int
+	Bytes     int64
+	Duration  time.Duration
+	RequestId string
+	RemoteIP  string
+	// UserId is zero for anonymous requests
+	UserId int
+}
+
+// APIRequest logs an answered request, server errors at the error level and client
+// errors as warnings
+func (logger *Logger) APIRequest(ctx context.Context, entry RequestLog) {
+	event := logger.Info()
+	switch {
+	case entry.Status >= 500:
+		event = logger.Error()
+	case entry.Status >= 400:
+		event = logger.Warn()
+	}
+	if entry.UserId != 0 {
+		event = event.Int("user_id", entry.UserId)
+	}
+
+	event.Ctx(ctx).
+		Str("request_id", entry.RequestId).
+		Str("method", entry.Method).
+		Str("path", entry.Path).
+		Str("route", entry.Route).
+		Int("status", entry.Status).
+		Int64("bytes", entry.Bytes).
+		Dur("duration", entry.Duration).
+		Str("remote_ip", entry.RemoteIP).
 		Msg("api_request")
 }
 
@@ -62,8 +109,9 @@ func (logger *Logger) DatabaseQeury(query string, duration time.Duration) {
 		Msg("database_query")
 }
 
-func (logger *Logger) AuthEvent(userID int, event string) {
+func (logger *Logger) AuthEvent(ctx context.Context, userID int, event string) {
 	logger.Info().
+		Ctx(ctx).
 		Int("user_id", userID).
 		Str("event", event).
 		Msg("auth_event")
@@ -2,48 +2,138 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
-	"task_API/internal/storage"
+	"task_API/internal/metrics"
+	"task_API/internal/models"
+	"task_API/internal/ratelimit"
+	"task_API/internal/services"
+	"task_API/internal/storage/repositories"
+	apperrors "task_API/pkg/errors"
+	"task_API/pkg/logger"
+	"task_API/pkg/responses"
 	"task_API/pkg/utils"
 )
 
-func AuthMiddleware(storage *storage.PostgresStorage) func(http.Handler) http.Handler {
+// AuthMiddleware signs the user of the request in, from its bearer token or its client
+// certificate. Requests it turns away are charged to the auth limit of the client IP,
+// the one of /login, so that nobody can guess tokens or fill the audit log unthrottled.
+func AuthMiddleware(userRepo repositories.UserRepository, auditService services.AuditService, limiter *ratelimit.Limiter, appLogger *logger.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 			// Auth Header
 			authHeader := request.Header.Get("Authorization")
+
+			// Service accounts sign in with their client certificate instead of a token
+			if email := utils.ServiceAccount(request.Context()); email != "" && authHeader == "" {
+				user, error := userRepo.GetUserByEmail(request.Context(), email)
+				if error != nil {
+					if responses.WriteContextError(writer, request, error) {
+						return
+					}
+					rejectCredentials(writer, request, auditService, limiter, appLogger, nil, "service account "+email+" not found: "+error.Error(), apperrors.ErrUnauthorized.New("Service account not found"))
+					return
+				}
+				serveAuthenticated(writer, request, next, user)
+				return
+			}
+
 			if authHeader == "" {
-				http.Error(writer, "Authorization header is required", http.StatusUnauthorized)
+				rejectCredentials(writer, request, auditService, limiter, appLogger, nil, "missing authorization header", apperrors.ErrUnauthorized.New("Authorization header is required"))
 				return
 			}
 
 			// Bearer check
 			tokenParts := strings.Split(authHeader, " ")
 			if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-				http.Error(writer, "Invalid Authorization header format", http.StatusUnauthorized)
+				rejectCredentials(writer, request, auditService, limiter, appLogger, nil, "invalid authorization header format", apperrors.ErrUnauthorized.New("Invalid Authorization header format"))
 				return
 			}
 
 			// Token Validation
 			tokenStr := tokenParts[1]
-			claim, error := utils.ValidateJWTToken(tokenStr)
+			claim, error := services.ValidateJWT(request.Context(), tokenStr)
 			if error != nil {
-				http.Error(writer, "Invalid token: "+error.Error(), http.StatusUnauthorized)
+				rejectCredentials(writer, request, auditService, limiter, appLogger, nil, "invalid token: "+error.Error(), apperrors.ErrUnauthorized.New("Invalid token: "+error.Error()))
 				return
 			}
 
 			// Get User from DB
-			user, error := storage.GetUserById(claim.UserId)
+			user, error := userRepo.GetUserById(request.Context(), claim.UserId)
 			if error != nil {
-				http.Error(writer, "User not found: "+error.Error(), http.StatusUnauthorized)
+				if responses.WriteContextError(writer, request, error) {
+					return
+				}
+				// The token outlived its user
+				if errors.Is(error, apperrors.ErrNotFound) {
+					rejectCredentials(writer, request, auditService, limiter, appLogger, &claim.UserId, "user not found: "+error.Error(), apperrors.ErrUnauthorized.New("User not found"))
+					return
+				}
+				responses.WriteError(writer, request, error)
+				return
+			}
+
+			serveAuthenticated(writer, request, next, user)
+		})
+	}
+}
+
+// serveAuthenticated passes the request of the user on
+func serveAuthenticated(writer http.ResponseWriter, request *http.Request, next http.Handler, user *models.User) {
+	metrics.UserSeen(user.ID)
+	setLoggedUser(request.Context(), user.ID)
+
+	// Add user to context
+	ctx := context.WithValue(request.Context(), "user", *user)
+	next.ServeHTTP(writer, request.WithContext(ctx))
+}
+
+// RequireAdmin only lets users with the admin role through. It must run after AuthMiddleware.
+func RequireAdmin(auditService services.AuditService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			user, ok := request.Context().Value("user").(models.User)
+			if !ok {
+				responses.WriteError(writer, request, apperrors.ErrInterServer.New("User not found in context"))
+				return
+			}
+
+			if user.Role != models.RoleAdmin {
+				auditService.Record(request.Context(), &models.AuditLog{
+					UserId:    &user.ID,
+					Event:     models.AuditEventPermissionDenied,
+					Email:     user.Email,
+					IPAddress: utils.ClientIP(request),
+					UserAgent: request.UserAgent(),
+					Details:   request.Method + " " + request.URL.Path + ": admin role required",
+				})
+				responses.WriteError(writer, request, apperrors.ErrForBidden.New("Admin access required"))
 				return
 			}
 
-			// Add user to context
-			ctx := context.WithValue(request.Context(), "user", user)
-			next.ServeHTTP(writer, request.WithContext(ctx))
+			next.ServeHTTP(writer, request)
 		})
 	}
 }
+
+// rejectCredentials answers a request whose credentials failed with err and audits the
+// failure. Once the client IP is over the auth limit it answers 429 instead, unaudited.
+func rejectCredentials(writer http.ResponseWriter, request *http.Request, auditService services.AuditService, limiter *ratelimit.Limiter, appLogger *logger.Logger, userId *int, details string, err error) {
+	if rejectOverLimit(writer, request, limiter, ratelimit.ClassAuth, "ip:"+utils.ClientIP(request), appLogger) {
+		return
+	}
+	recordTokenFailure(auditService, request, userId, details)
+	responses.WriteError(writer, request, err)
+}
+
+func recordTokenFailure(auditService services.AuditService, request *http.Request, userId *int, details string) {
+	auditService.Record(request.Context(), &models.AuditLog{
+		UserId:    userId,
+		Event:     models.AuditEventTokenInvalid,
+		IPAddress: utils.ClientIP(request),
+		UserAgent: request.UserAgent(),
+		Details:   request.Method + " " + request.URL.Path + ": " + details,
+	})
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"task_API/internal/models"
+	"task_API/internal/ratelimit"
+	"task_API/pkg/logger"
+)
+
+// recordingAuditService keeps the recorded entries
+type recordingAuditService struct {
+	mutex   sync.Mutex
+	entries []*models.AuditLog
+}
+
+func (audit *recordingAuditService) Record(ctx context.Context, entry *models.AuditLog) {
+	audit.mutex.Lock()
+	defer audit.mutex.Unlock()
+	audit.entries = append(audit.entries, entry)
+}
+
+func (audit *recordingAuditService) GetAuditLogs(ctx context.Context, filter *models.AuditLogFilter) ([]*models.AuditLog, error) {
+	return nil, nil
+}
+
+func (audit *recordingAuditService) VerifyChain(ctx context.Context) (*models.AuditChainVerification, error) {
+	return nil, nil
+}
+
+func TestAuthMiddlewareThrottlesBadTokensPerIP(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+		ratelimit.ClassAuth: {Requests: 3, Period: time.Minute},
+	})
+	audit := &recordingAuditService{}
+	handler := AuthMiddleware(nil, audit, limiter, logger.NewLogger("disabled", "json"))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Fatal("a request with a bad token got through")
+	}))
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		request.RemoteAddr = remoteAddr
+		request.Header.Set("Authorization", "Bearer not-a-token")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if recorder := send("203.0.113.7:4000"); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want 401", attempt, recorder.Code)
+		}
+	}
+
+	denied := send("203.0.113.7:4000")
+	if denied.Code != http.StatusTooManyRequests || denied.Header().Get("Retry-After") == "" {
+		t.Fatalf("status = %d, Retry-After = %q, want 429 with Retry-After", denied.Code, denied.Header().Get("Retry-After"))
+	}
+	// Throttled requests do not reach the audit log
+	if len(audit.entries) != 3 {
+		t.Errorf("audited %d failures, want 3", len(audit.entries))
+	}
+
+	// The limit is per client IP
+	if other := send("198.51.100.9:4000"); other.Code != http.StatusUnauthorized {
+		t.Errorf("other client = %d, want 401", other.Code)
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import "net/http"
+
+// MaxBodySize caps request bodies at limit bytes. Reading past it fails, which
+// utils.DecodeJSON answers with 413. A zero limit leaves bodies unbounded.
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			request.Body = http.MaxBytesReader(writer, request.Body, limit)
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
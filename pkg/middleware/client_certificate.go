@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"task_API/pkg/utils"
+)
+
+// ClientCertificates maps the common name of a client certificate to the service
+// account it signs in as, for AuthMiddleware to authenticate requests without a token.
+// The server only accepts connections whose certificate it verified against the client
+// CA bundle, so the certificate of a request can be trusted here.
+func ClientCertificates(accounts map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(accounts) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.TLS == nil || len(request.TLS.PeerCertificates) == 0 {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			email, ok := accounts[request.TLS.PeerCertificates[0].Subject.CommonName]
+			if !ok {
+				next.ServeHTTP(writer, request)
+				return
+			}
+			next.ServeHTTP(writer, request.WithContext(utils.WithServiceAccount(request.Context(), email)))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"task_API/pkg/utils"
+)
+
+func TestClientCertificatesMapsCommonNamesToServiceAccounts(t *testing.T) {
+	var account string
+	handler := ClientCertificates(map[string]string{"billing": "billing@services.local"})(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		account = utils.ServiceAccount(request.Context())
+	}))
+
+	serve := func(commonName string) string {
+		account = ""
+		request := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		if commonName != "" {
+			request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: commonName}}}}
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		return account
+	}
+
+	if got := serve("billing"); got != "billing@services.local" {
+		t.Fatalf("account = %q, want the billing service account", got)
+	}
+	if got := serve("reports"); got != "" {
+		t.Fatalf("account = %q, want none for an unmapped certificate", got)
+	}
+	if got := serve(""); got != "" {
+		t.Fatalf("account = %q, want none without a certificate", got)
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"task_API/internal/config"
+)
+
+// CORS lets the browsers of the allowed origins call the API. It answers preflight
+// requests itself, so it has to wrap the router: the routes only match their own
+// methods, not OPTIONS. Origins of the form https://*.example.com allow every
+// subdomain.
+func CORS(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	allowedMethods := strings.Join(cfg.AllowedMethods, ", ")
+	exposedHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	anyOrigin := false
+	for _, origin := range cfg.AllowedOrigins {
+		anyOrigin = anyOrigin || origin == "*"
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(cfg.AllowedOrigins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			origin := request.Header.Get("Origin")
+			preflight := request.Method == http.MethodOptions && request.Header.Get("Access-Control-Request-Method") != ""
+
+			header := writer.Header()
+			header.Add("Vary", "Origin")
+			if preflight {
+				header.Add("Vary", "Access-Control-Request-Method")
+				header.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" || !originAllowed(cfg.AllowedOrigins, origin) {
+				if preflight {
+					// Without the allow headers the browser refuses the request
+					writer.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			// The configuration refuses a wildcard with credentials, the origin is never
+			// echoed for it
+			if anyOrigin {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposedHeaders != "" {
+					header.Set("Access-Control-Expose-Headers", exposedHeaders)
+				}
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			if !containsFold(cfg.AllowedMethods, request.Header.Get("Access-Control-Request-Method")) {
+				writer.WriteHeader(http.StatusNoContent)
+				return
+			}
+			requested := requestedHeaders(request.Header.Get("Access-Control-Request-Headers"))
+			for _, name := range requested {
+				if !containsFold(cfg.AllowedHeaders, name) && !containsFold(cfg.AllowedHeaders, "*") {
+					writer.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+
+			header.Set("Access-Control-Allow-Methods", allowedMethods)
+			if len(requested) > 0 {
+				header.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+			}
+			if cfg.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			writer.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+func originAllowed(allowed []string, origin string) bool {
+	for _, pattern := range allowed {
+		if pattern == "*" || strings.EqualFold(pattern, origin) {
+			return true
+		}
+		if scheme, domain, ok := strings.Cut(pattern, "://*."); ok {
+			prefix := strings.ToLower(scheme + "://")
+			suffix := strings.ToLower("." + domain)
+			lower := strings.ToLower(origin)
+			if strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, suffix) && len(lower) > len(prefix)+len(suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func requestedHeaders(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
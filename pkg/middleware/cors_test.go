@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"task_API/internal/config"
+)
+
+func corsHandler(cfg config.CORSConfig) http.Handler {
+	return CORS(cfg)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+}
+
+var testCORSConfig = config.CORSConfig{
+	AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+	AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+	AllowedHeaders: []string{"Authorization", "Content-Type"},
+	ExposedHeaders: []string{"X-Request-ID"},
+	MaxAge:         10 * time.Minute,
+}
+
+func TestCORSAnswersPreflightRequests(t *testing.T) {
+	request := httptest.NewRequest(http.MethodOptions, "/tasks", nil)
+	request.Header.Set("Origin", "https://app.example.com")
+	request.Header.Set("Access-Control-Request-Method", "PUT")
+	request.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+	recorder := httptest.NewRecorder()
+	corsHandler(testCORSConfig).ServeHTTP(recorder, request)
+
+	want := map[string]string{
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET, POST, PUT, DELETE",
+		"Access-Control-Allow-Headers": "authorization, content-type",
+		"Access-Control-Max-Age":       "600",
+	}
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", recorder.Code)
+	}
+	for name, value := range want {
+		if got := recorder.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestCORSRefusesOtherOriginsAndHeaders(t *testing.T) {
+	tests := map[string]struct {
+		origin string
+		header string
+	}{
+		"unknown origin":   {origin: "https://evil.example.com"},
+		"bare domain":      {origin: "https://example.org"},
+		"forbidden header": {origin: "https://app.example.com", header: "X-Admin"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodOptions, "/tasks", nil)
+			request.Header.Set("Origin", test.origin)
+			request.Header.Set("Access-Control-Request-Method", "POST")
+			request.Header.Set("Access-Control-Request-Headers", test.header)
+			recorder := httptest.NewRecorder()
+			corsHandler(testCORSConfig).ServeHTTP(recorder, request)
+
+			if got := recorder.Header().Get("Access-Control-Allow-Methods"); got != "" {
+				t.Fatalf("Access-Control-Allow-Methods = %q, want none", got)
+			}
+		})
+	}
+}
+
+func TestCORSDecoratesSimpleRequests(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	request.Header.Set("Origin", "https://web.example.org")
+	recorder := httptest.NewRecorder()
+	corsHandler(testCORSConfig).ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want the handler's 200", recorder.Code)
+	}
+	if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != "https://web.example.org" {
+		t.Fatalf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := recorder.Header().Get("Access-Control-Expose-Headers"); got != "X-Request-ID" {
+		t.Fatalf("Access-Control-Expose-Headers = %q", got)
+	}
+	if got := recorder.Header().Get("Vary"); got != "Origin" {
+		t.Fatalf("Vary = %q, want Origin", got)
+	}
+}
+
+func TestCORSWildcardNeverEchoesTheOrigin(t *testing.T) {
+	cfg := testCORSConfig
+	cfg.AllowedOrigins = []string{"*"}
+
+	request := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	request.Header.Set("Origin", "https://any.example.net")
+	recorder := httptest.NewRecorder()
+	corsHandler(cfg).ServeHTTP(recorder, request)
+	if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+}
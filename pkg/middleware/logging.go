@@ -1,17 +1,51 @@
 package middleware
 
 import (
-	"log"
+	"context"
 	"net/http"
 	"time"
+
+	"task_API/pkg/logger"
+	"task_API/pkg/utils"
 )
 
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(writer http.ResponseWriter, requrest *http.Request) {
-		start := time.Now()
+// loggedRequestKey is the context key of the loggedRequest of a request
+type loggedRequestKey struct{}
+
+// loggedRequest collects what only the inner middleware learns about a request, such
+// as the authenticated user
+type loggedRequest struct {
+	userId int
+}
+
+// Logging logs every request with its status, size, latency, request id and user
+func Logging(appLogger *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			start := time.Now()
+			recorder := newStatusWriter(writer)
+			entry := &loggedRequest{}
+
+			next.ServeHTTP(recorder, request.WithContext(context.WithValue(request.Context(), loggedRequestKey{}, entry)))
 
-		next.ServeHTTP(writer, requrest)
+			appLogger.APIRequest(request.Context(), logger.RequestLog{
+				Method:    request.Method,
+				Path:      request.URL.Path,
+				Route:     routeTemplate(request),
+				Status:    recorder.Status(),
+				Bytes:     recorder.bytes,
+				Duration:  time.Since(start),
+				RequestId: utils.RequestId(request.Context()),
+				RemoteIP:  utils.ClientIP(request),
+				UserId:    entry.userId,
+			})
+		})
+	}
+}
 
-		log.Printf("%s %s %s", requrest.Method, requrest.URL.Path, time.Since(start))
-	})
+// setLoggedUser records the authenticated user in the log line of the request
+func setLoggedUser(ctx context.Context, userId int) {
+	if entry, ok := ctx.Value(loggedRequestKey{}).(*loggedRequest); ok {
+		entry.userId = userId
+	}
 }
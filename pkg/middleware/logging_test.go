@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"task_API/pkg/logger"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+)
+
+func newTestLogger(output *bytes.Buffer) *logger.Logger {
+	return &logger.Logger{Logger: zerolog.New(output)}
+}
+
+func TestLoggingRecordsTheAnsweredRequest(t *testing.T) {
+	var output bytes.Buffer
+	router := mux.NewRouter()
+	router.Use(RequestId, Logging(newTestLogger(&output)))
+	router.HandleFunc("/tasks/{id}", func(writer http.ResponseWriter, request *http.Request) {
+		setLoggedUser(request.Context(), 42)
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write([]byte(`{"error":"not found"}`))
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/tasks/7", nil)
+	request.Header.Set("X-Request-ID", "abc-123")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &line); err != nil {
+		t.Fatalf("log line %q: %v", output.String(), err)
+	}
+	want := map[string]interface{}{
+		"level":      "warn",
+		"message":    "api_request",
+		"request_id": "abc-123",
+		"method":     "GET",
+		"path":       "/tasks/7",
+		"route":      "/tasks/{id}",
+		"status":     float64(404),
+		"bytes":      float64(21),
+		"user_id":    float64(42),
+	}
+	for key, value := range want {
+		if line[key] != value {
+			t.Errorf("%s = %v, want %v", key, line[key], value)
+		}
+	}
+}
+
+func TestRecoveryAnswersAPanicWith500(t *testing.T) {
+	var output bytes.Buffer
+	appLogger := newTestLogger(&output)
+	router := mux.NewRouter()
+	router.Use(Logging(appLogger), Recovery(appLogger))
+	router.HandleFunc("/tasks", func(writer http.ResponseWriter, request *http.Request) {
+		var tasks map[int]string
+		tasks[1] = "boom"
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.Contains(contentType, "json") {
+		t.Fatalf("content type = %q, want a JSON error", contentType)
+	}
+	logged := output.String()
+	if !strings.Contains(logged, "handler panicked") || !strings.Contains(logged, "assignment to entry in nil map") || !strings.Contains(logged, "logging_test.go") {
+		t.Fatalf("log = %s, want the panic and its stack", logged)
+	}
+	if !strings.Contains(logged, `"status":500`) {
+		t.Fatalf("log = %s, want the request logged as a 500", logged)
+	}
+}
+
+func TestRecoveryLeavesAbortedResponsesToNetHTTP(t *testing.T) {
+	handler := Recovery(newTestLogger(&bytes.Buffer{}))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Fatalf("recovered = %v, want http.ErrAbortHandler", recovered)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
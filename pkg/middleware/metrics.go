@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"task_API/internal/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// Metrics records the status and latency of every request under the template of the
+// route it matched, such as /tasks/{id}
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+		recorder := newStatusWriter(writer)
+
+		next.ServeHTTP(recorder, request)
+
+		metrics.ObserveRequest(request.Method, routeTemplate(request), recorder.Status(), time.Since(start))
+	})
+}
+
+func routeTemplate(request *http.Request) string {
+	if route := mux.CurrentRoute(request); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"task_API/internal/metrics"
+	"task_API/internal/models"
+	"task_API/internal/ratelimit"
+	apperrors "task_API/pkg/errors"
+	"task_API/pkg/logger"
+	"task_API/pkg/responses"
+	"task_API/pkg/utils"
+)
+
+// RouteClass picks the rate limit class of a request
+type RouteClass func(request *http.Request) string
+
+// FixedClass puts every request in the same class
+func FixedClass(class string) RouteClass {
+	return func(request *http.Request) string { return class }
+}
+
+// ClassByMethod counts GET, HEAD and OPTIONS requests as reads and the others as writes
+func ClassByMethod(request *http.Request) string {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ratelimit.ClassRead
+	default:
+		return ratelimit.ClassWrite
+	}
+}
+
+// RateLimit answers 429 to the clients that used up the limit of the route class.
+// Clients are the authenticated user, so it has to run after AuthMiddleware on
+// protected routes, or the client IP otherwise. Requests AuthMiddleware turns away are
+// charged to the auth limit of their IP there. Every limited response carries the
+// RateLimit-* headers, and a 429 also carries Retry-After. When the store fails the
+// request is let through rather than failing the API with it, the failure is logged
+// and counted so that an outage of the store does not go unnoticed.
+func RateLimit(limiter *ratelimit.Limiter, classify RouteClass, appLogger *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if rejectOverLimit(writer, request, limiter, classify(request), rateLimitClient(request), appLogger) {
+				return
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// rejectOverLimit takes a token of the bucket of client in class and sets the
+// RateLimit-* headers. It answers 429 and returns true when the bucket is used up.
+func rejectOverLimit(writer http.ResponseWriter, request *http.Request, limiter *ratelimit.Limiter, class string, client string, appLogger *logger.Logger) bool {
+	result, limited, err := limiter.Allow(request.Context(), class, client)
+	if err != nil {
+		metrics.RateLimitStoreFailed()
+		appLogger.Error().
+			Ctx(request.Context()).
+			Err(err).
+			Str("request_id", utils.RequestId(request.Context())).
+			Str("class", class).
+			Str("client", client).
+			Msg("Rate limit store failed, request let through")
+		return false
+	}
+	if !limited {
+		return false
+	}
+
+	header := writer.Header()
+	header.Set("RateLimit-Policy", strconv.Itoa(result.Limit.Requests)+";w="+strconv.Itoa(seconds(result.Limit.Period)))
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit.Requests))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+	if !result.Allowed {
+		header.Set("Retry-After", strconv.Itoa(max(seconds(result.RetryAfter), 1)))
+		responses.WriteError(writer, request, apperrors.ErrTooManyRequests.New("rate limit exceeded, retry later"))
+		return true
+	}
+	return false
+}
+
+func rateLimitClient(request *http.Request) string {
+	if user, ok := request.Context().Value("user").(models.User); ok {
+		return "user:" + strconv.Itoa(user.ID)
+	}
+	return "ip:" + utils.ClientIP(request)
+}
+
+// seconds rounds up, so that a client waiting that long is never early
+func seconds(duration time.Duration) int {
+	return int((duration + time.Second - 1) / time.Second)
+}
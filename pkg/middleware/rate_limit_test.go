@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"task_API/internal/metrics"
+	"task_API/internal/models"
+	"task_API/internal/ratelimit"
+	"task_API/pkg/logger"
+
+	"github.com/rs/zerolog"
+)
+
+func TestRateLimitAnswers429OnceTheLimitIsUsedUp(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+		ratelimit.ClassWrite: {Requests: 2, Period: time.Minute},
+	})
+	handler := RateLimit(limiter, ClassByMethod, logger.NewLogger("disabled", "json"))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusCreated)
+	}))
+
+	send := func(user int) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+		request = request.WithContext(context.WithValue(request.Context(), "user", models.User{ID: user}))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	first := send(1)
+	if first.Code != http.StatusCreated || first.Header().Get("RateLimit-Remaining") != "1" || first.Header().Get("RateLimit-Policy") != "2;w=60" {
+		t.Fatalf("first = %d %v", first.Code, first.Header())
+	}
+	send(1)
+
+	denied := send(1)
+	if denied.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", denied.Code)
+	}
+	if got := denied.Header().Get("Retry-After"); got != "30" {
+		t.Fatalf("Retry-After = %q, want 30", got)
+	}
+	if got := denied.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Fatalf("RateLimit-Remaining = %q, want 0", got)
+	}
+
+	// Limits are per user
+	if other := send(2); other.Code != http.StatusCreated {
+		t.Fatalf("other user = %d, want 201", other.Code)
+	}
+
+	// Reads are not limited here
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	if recorder.Code != http.StatusCreated || recorder.Header().Get("RateLimit-Limit") != "" {
+		t.Fatalf("read = %d %v, want no limit", recorder.Code, recorder.Header())
+	}
+}
+
+// failingStore is a rate limit store whose database is down
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func TestRateLimitLetsRequestsThroughWhenTheStoreFails(t *testing.T) {
+	limiter := ratelimit.NewLimiter(failingStore{}, map[string]ratelimit.Limit{
+		ratelimit.ClassWrite: {Requests: 1, Period: time.Minute},
+	})
+	var logs bytes.Buffer
+	appLogger := &logger.Logger{Logger: zerolog.New(&logs)}
+	handler := RateLimit(limiter, ClassByMethod, appLogger)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusCreated)
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/tasks", nil))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want the request let through", recorder.Code)
+	}
+
+	// The outage is logged and counted
+	if !strings.Contains(logs.String(), "connection refused") || !strings.Contains(logs.String(), `"level":"error"`) {
+		t.Errorf("logs = %q, want the store error", logs.String())
+	}
+	scrape := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(scrape, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(scrape.Body.String(), "task_api_ratelimit_store_errors_total 1") {
+		t.Errorf("metrics do not count the store error")
+	}
+}
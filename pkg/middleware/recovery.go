@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	apperrors "task_API/pkg/errors"
+	"task_API/pkg/logger"
+	"task_API/pkg/responses"
+	"task_API/pkg/utils"
+)
+
+// Recovery turns a panicking handler into a 500 answer and logs the panic with its
+// stack trace, instead of letting net/http drop the connection. When the handler had
+// already started its response only the log line is left.
+func Recovery(appLogger *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			recorder := newStatusWriter(writer)
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// Aborting a response on purpose is left to net/http
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				appLogger.Error().
+					Ctx(request.Context()).
+					Str("request_id", utils.RequestId(request.Context())).
+					Str("method", request.Method).
+					Str("path", request.URL.Path).
+					Interface("panic", recovered).
+					Str("stack", string(debug.Stack())).
+					Msg("handler panicked")
+
+				if recorder.status == 0 {
+					responses.WriteError(recorder, request, apperrors.ErrInterServer.New("internal server error"))
+				}
+			}()
+
+			next.ServeHTTP(recorder, request)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"task_API/pkg/utils"
+)
+
+// maxRequestIdLength bounds the ids accepted from clients, which end up in logs
+const maxRequestIdLength = 128
+
+// RequestId gives every request an id, available with utils.RequestId and echoed in
+// the X-Request-ID response header. An id sent by the client or a proxy in front is
+// kept when it is printable, so one request can be followed across services.
+func RequestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		id := request.Header.Get(utils.RequestIdHeader)
+		if !validRequestId(id) {
+			generated, err := utils.GenerateSecret(16)
+			if err != nil {
+				next.ServeHTTP(writer, request)
+				return
+			}
+			id = generated
+		}
+
+		writer.Header().Set(utils.RequestIdHeader, id)
+		next.ServeHTTP(writer, request.WithContext(utils.WithRequestId(request.Context(), id)))
+	})
+}
+
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+	for _, char := range id {
+		if char < '!' || char > '~' {
+			return false
+		}
+	}
+	return true
+}
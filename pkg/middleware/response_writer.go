@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// statusWriter records the status and size of a response. It unwraps for
+// http.ResponseController and passes Flush and Hijack through, so event streams and
+// WebSockets keep working behind it.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	// beforeHeader, when set, runs once right before the header is sent
+	beforeHeader func(header http.Header)
+}
+
+func newStatusWriter(writer http.ResponseWriter) *statusWriter {
+	return &statusWriter{ResponseWriter: writer}
+}
+
+// sendHeader records the status of the header about to be sent, the first one only
+func (writer *statusWriter) sendHeader(status int) {
+	if writer.status != 0 {
+		return
+	}
+	writer.status = status
+	if writer.beforeHeader != nil {
+		writer.beforeHeader(writer.ResponseWriter.Header())
+	}
+}
+
+func (writer *statusWriter) WriteHeader(status int) {
+	writer.sendHeader(status)
+	writer.ResponseWriter.WriteHeader(status)
+}
+
+func (writer *statusWriter) Write(data []byte) (int, error) {
+	writer.sendHeader(http.StatusOK)
+	written, err := writer.ResponseWriter.Write(data)
+	writer.bytes += int64(written)
+	return written, err
+}
+
+// Status returns the status sent, 200 when the handler wrote nothing
+func (writer *statusWriter) Status() int {
+	if writer.status == 0 {
+		return http.StatusOK
+	}
+	return writer.status
+}
+
+func (writer *statusWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+func (writer *statusWriter) Flush() {
+	writer.sendHeader(http.StatusOK)
+	http.NewResponseController(writer.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection over, as WebSocket upgrades do. The upgrade answers
+// 101 on the connection itself.
+func (writer *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buffer, err := http.NewResponseController(writer.ResponseWriter).Hijack()
+	if err == nil && writer.status == 0 {
+		writer.status = http.StatusSwitchingProtocols
+	}
+	return conn, buffer, err
+}
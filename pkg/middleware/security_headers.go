@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"task_API/internal/config"
+	"task_API/pkg/utils"
+)
+
+// SecurityHeaders sets the headers that keep browsers from sniffing, framing or
+// leaking the responses. Strict-Transport-Security is only sent over HTTPS, browsers
+// ignore it otherwise, and the Content-Security-Policy only with HTML responses.
+func SecurityHeaders(cfg config.SecurityConfig) func(http.Handler) http.Handler {
+	hsts := "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			header := writer.Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+			header.Set("X-Frame-Options", "DENY")
+			header.Set("Referrer-Policy", "no-referrer")
+			if cfg.HSTSMaxAge > 0 && utils.IsHTTPS(request) {
+				header.Set("Strict-Transport-Security", hsts)
+			}
+
+			recorder := newStatusWriter(writer)
+			recorder.beforeHeader = func(header http.Header) {
+				// A handler serving HTML may bring a policy of its own
+				if cfg.ContentSecurityPolicy != "" && header.Get("Content-Security-Policy") == "" &&
+					strings.HasPrefix(header.Get("Content-Type"), "text/html") {
+					header.Set("Content-Security-Policy", cfg.ContentSecurityPolicy)
+				}
+			}
+			next.ServeHTTP(recorder, request)
+		})
+	}
+}
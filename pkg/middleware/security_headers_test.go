@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"task_API/internal/config"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	cfg := config.SecurityConfig{HSTSMaxAge: 24 * time.Hour, ContentSecurityPolicy: "default-src 'self'"}
+	serve := func(contentType string, https bool) http.Header {
+		handler := SecurityHeaders(cfg)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Content-Type", contentType)
+			writer.Write([]byte("body"))
+		}))
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if https {
+			request.URL.Scheme = "https"
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Header()
+	}
+
+	json := serve("application/json", false)
+	if json.Get("X-Content-Type-Options") != "nosniff" || json.Get("X-Frame-Options") != "DENY" {
+		t.Fatalf("headers = %v", json)
+	}
+	if json.Get("Strict-Transport-Security") != "" || json.Get("Content-Security-Policy") != "" {
+		t.Fatalf("headers = %v, want neither HSTS over HTTP nor a CSP for JSON", json)
+	}
+
+	html := serve("text/html; charset=utf-8", true)
+	if got := html.Get("Strict-Transport-Security"); got != "max-age=86400" {
+		t.Fatalf("Strict-Transport-Security = %q", got)
+	}
+	if got := html.Get("Content-Security-Policy"); got != "default-src 'self'" {
+		t.Fatalf("Content-Security-Policy = %q", got)
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Timeout gives every request a deadline. Queries still running when it passes fail
+// with context.DeadlineExceeded, which handlers answer with 504. A zero timeout
+// leaves requests without a deadline.
+func Timeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			ctx, cancel := context.WithTimeout(request.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"task_API/internal/tracing"
+	"task_API/pkg/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span for every request, continuing the trace of an incoming
+// traceparent header. The span is named after the route template, such as
+// GET /tasks/{id}, and fails when the response is a server error.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		route := routeTemplate(request)
+		ctx := tracing.Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+		ctx, span := tracing.StartKind(ctx, trace.SpanKindServer, request.Method+" "+route,
+			attribute.String("http.request.method", request.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", request.URL.Path),
+			attribute.String("request_id", utils.RequestId(ctx)),
+		)
+		defer span.End()
+
+		recorder := newStatusWriter(writer)
+		next.ServeHTTP(recorder, request.WithContext(ctx))
+
+		status := recorder.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingContinuesTheIncomingTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var handlerTraceId trace.TraceID
+	router := mux.NewRouter()
+	router.Use(Tracing)
+	router.HandleFunc("/tasks/{id}", func(writer http.ResponseWriter, request *http.Request) {
+		handlerTraceId = trace.SpanContextFromContext(request.Context()).TraceID()
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/tasks/7", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("spans = %d, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /tasks/{id}" || span.SpanKind() != trace.SpanKindServer {
+		t.Fatalf("span = %s (%s), want the server span of the route", span.Name(), span.SpanKind())
+	}
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("trace id = %s, want the one of the traceparent header", got)
+	}
+	if got := span.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Fatalf("parent = %s, want the caller's span", got)
+	}
+	if handlerTraceId != span.SpanContext().TraceID() {
+		t.Fatal("expected the handler to run in the span's context")
+	}
+	if span.Status().Code != codes.Error {
+		t.Fatalf("status = %v, want an error for a 503", span.Status())
+	}
+
+	want := attribute.Int("http.response.status_code", http.StatusServiceUnavailable)
+	for _, attr := range span.Attributes() {
+		if attr == want {
+			return
+		}
+	}
+	t.Fatalf("attributes = %v, want %v", span.Attributes(), want)
+}
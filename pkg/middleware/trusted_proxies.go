@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"task_API/pkg/utils"
+)
+
+// ParseTrustedProxies parses addresses and CIDR ranges, such as 10.0.0.0/8 or ::1
+func ParseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	prefixes, err := utils.ParseNetworks(entries)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxy: %w", err)
+	}
+	return prefixes, nil
+}
+
+// TrustedProxies replaces the address of a request sent by one of the proxies with
+// the client address of X-Forwarded-For, and takes its scheme from X-Forwarded-Proto,
+// so that utils.ClientIP and utils.IsHTTPS see the client. The forwarded headers of
+// other peers are ignored, anyone can send them.
+func TrustedProxies(proxies []netip.Prefix) func(http.Handler) http.Handler {
+	trusted := func(addr netip.Addr) bool {
+		return utils.InNetworks(addr, proxies)
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(proxies) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			peer, ok := parseForwardedAddr(request.RemoteAddr)
+			if !ok || !trusted(peer) {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			request = request.Clone(request.Context())
+			if client, ok := forwardedClient(request.Header.Values("X-Forwarded-For"), trusted); ok {
+				request.RemoteAddr = client.String()
+			}
+			// The proxy facing the client sets the first value
+			proto, _, _ := strings.Cut(request.Header.Get("X-Forwarded-Proto"), ",")
+			if proto = strings.ToLower(strings.TrimSpace(proto)); proto == "http" || proto == "https" {
+				request.URL.Scheme = proto
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// forwardedClient walks X-Forwarded-For from the nearest hop back and returns the
+// first address that is not a trusted proxy. Hops further back were added by the
+// client itself and cannot be trusted.
+func forwardedClient(headers []string, trusted func(netip.Addr) bool) (netip.Addr, bool) {
+	var hops []string
+	for _, header := range headers {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	var client netip.Addr
+	found := false
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseForwardedAddr(strings.TrimSpace(hops[i]))
+		if !ok {
+			break
+		}
+		client, found = addr.Unmap(), true
+		if !trusted(addr) {
+			break
+		}
+	}
+	return client, found
+}
+
+// parseForwardedAddr parses an address with or without a port
+func parseForwardedAddr(value string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	addr, err := netip.ParseAddr(value)
+	return addr, err == nil
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"task_API/pkg/utils"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "::1"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies: %v", err)
+	}
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatal("expected an invalid range to be refused")
+	}
+
+	tests := map[string]struct {
+		remoteAddr string
+		forwarded  string
+		proto      string
+		wantIP     string
+		wantHTTPS  bool
+	}{
+		"direct client":         {remoteAddr: "203.0.113.7:4000", wantIP: "203.0.113.7"},
+		"untrusted forwarder":   {remoteAddr: "203.0.113.7:4000", forwarded: "198.51.100.1", proto: "https", wantIP: "203.0.113.7"},
+		"trusted proxy":         {remoteAddr: "10.0.0.2:4000", forwarded: "198.51.100.1", proto: "https", wantIP: "198.51.100.1", wantHTTPS: true},
+		"chain of proxies":      {remoteAddr: "10.0.0.2:4000", forwarded: "198.51.100.1, 10.1.2.3", wantIP: "198.51.100.1"},
+		"spoofed first hop":     {remoteAddr: "10.0.0.2:4000", forwarded: "1.2.3.4, 198.51.100.1", wantIP: "198.51.100.1"},
+		"ipv6 loopback proxy":   {remoteAddr: "[::1]:4000", forwarded: "2001:db8::5", proto: "http", wantIP: "2001:db8::5"},
+		"no forwarded header":   {remoteAddr: "10.0.0.2:4000", wantIP: "10.0.0.2"},
+		"garbage forwarded for": {remoteAddr: "10.0.0.2:4000", forwarded: "unknown", wantIP: "10.0.0.2"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotIP string
+			var gotHTTPS bool
+			handler := TrustedProxies(proxies)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				gotIP = utils.ClientIP(request)
+				gotHTTPS = utils.IsHTTPS(request)
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = test.remoteAddr
+			if test.forwarded != "" {
+				request.Header.Set("X-Forwarded-For", test.forwarded)
+			}
+			if test.proto != "" {
+				request.Header.Set("X-Forwarded-Proto", test.proto)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+
+			if _, err := netip.ParseAddr(gotIP); err != nil || gotIP != test.wantIP {
+				t.Fatalf("client ip = %q, want %q", gotIP, test.wantIP)
+			}
+			if gotHTTPS != test.wantHTTPS {
+				t.Fatalf("https = %v, want %v", gotHTTPS, test.wantHTTPS)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"task_API/internal/config"
+	"task_API/pkg/utils"
+)
+
+// APIVersion stores the API version of a route group in the request context, the
+// handlers shape their responses after it
+func APIVersion(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			next.ServeHTTP(writer, request.WithContext(utils.WithAPIVersion(request.Context(), version)))
+		})
+	}
+}
+
+// Deprecated marks the responses of the unversioned routes as deprecated: Deprecation
+// holds the date they were deprecated (RFC 9745), Sunset the date they go away (RFC 8594)
+// and Link the same route under successor, the version that replaces them.
+func Deprecated(cfg config.APIConfig, successor string) func(http.Handler) http.Handler {
+	deprecation := "@" + strconv.FormatInt(cfg.LegacyDeprecatedAt.Unix(), 10)
+	sunset := ""
+	if !cfg.LegacySunset.IsZero() {
+		sunset = cfg.LegacySunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			header := writer.Header()
+			header.Set("Deprecation", deprecation)
+			if sunset != "" {
+				header.Set("Sunset", sunset)
+			}
+			header.Add("Link", "</"+successor+request.URL.EscapedPath()+`>; rel="successor-version"`)
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"task_API/internal/config"
+	"task_API/pkg/utils"
+)
+
+func TestDeprecated(t *testing.T) {
+	cfg := config.APIConfig{
+		LegacyDeprecatedAt: time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+		LegacySunset:       time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC),
+	}
+	var version string
+	handler := Deprecated(cfg, "v1")(APIVersion("v1")(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		version = utils.APIVersion(request.Context())
+	})))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/tasks/7?completed=true", nil))
+
+	if got := recorder.Header().Get("Deprecation"); got != "@1792368000" {
+		t.Fatalf("Deprecation = %q", got)
+	}
+	if got := recorder.Header().Get("Sunset"); got != "Mon, 19 Apr 2027 00:00:00 GMT" {
+		t.Fatalf("Sunset = %q", got)
+	}
+	if got := recorder.Header().Get("Link"); got != `</v1/tasks/7>; rel="successor-version"` {
+		t.Fatalf("Link = %q", got)
+	}
+	if version != "v1" {
+		t.Fatalf("version = %q, want v1", version)
+	}
+
+	// Without a sunset date only the deprecation is announced
+	recorder = httptest.NewRecorder()
+	Deprecated(config.APIConfig{LegacyDeprecatedAt: cfg.LegacyDeprecatedAt}, "v1")(http.NotFoundHandler()).
+		ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	if got := recorder.Header().Get("Sunset"); got != "" {
+		t.Fatalf("Sunset = %q, want none", got)
+	}
+}
@@ -1,11 +1,24 @@
 package responses
 
 import (
+	"context"
 	"encoding/json"
+	stderrors "errors"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 	"task_API/pkg/errors"
+	"task_API/pkg/utils"
 )
 
+// StatusClientClosedRequest is the non-standard status logged when the client went
+// away before the response was ready, as nginx does
+const StatusClientClosedRequest = 499
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
 type Response struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
@@ -14,9 +27,22 @@ type Response struct {
 }
 
 type Error struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+	Code    int                 `json:"code"`
+	Message string              `json:"message"`
+	Details string              `json:"details,omitempty"`
+	Fields  []errors.FieldError `json:"fields,omitempty"`
+}
+
+// Problem is an RFC 7807 problem details object, with the id of the request and the
+// invalid fields of a validation failure as extension members
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	RequestId string              `json:"request_id,omitempty"`
+	Errors    []errors.FieldError `json:"errors,omitempty"`
 }
 
 type Meta struct {
@@ -41,18 +67,102 @@ func WriterError(writer http.ResponseWriter, appError *errors.AppError) {
 	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(appError.Code)
 
+	// The wrapped error stays internal, only the message and details reach the client
 	response := Response{
 		Success: false,
 		Error: &Error{
 			Code:    appError.Code,
 			Message: appError.Message,
-			Details: appError.Err.Error(),
+			Details: appError.Details,
+			Fields:  appError.Fields,
 		},
 	}
 
 	json.NewEncoder(writer).Encode(response)
 }
 
+// WriteProblem answers with appError as problem details. Errors made from a sentinel
+// take its type and use its message as the title; others are of type about:blank.
+func WriteProblem(writer http.ResponseWriter, request *http.Request, appError *errors.AppError) {
+	kind := appError.Kind()
+	problem := Problem{
+		Type:      kind.Type,
+		Title:     kind.Message,
+		Status:    appError.Code,
+		Detail:    appError.Message,
+		Instance:  request.URL.Path,
+		RequestId: utils.RequestId(request.Context()),
+		Errors:    appError.Fields,
+	}
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+		if title := http.StatusText(appError.Code); title != "" {
+			problem.Title = title
+		}
+	}
+	if problem.Detail == problem.Title {
+		problem.Detail = ""
+	}
+
+	writer.Header().Set("Content-Type", ProblemContentType)
+	writer.WriteHeader(appError.Code)
+	json.NewEncoder(writer).Encode(problem)
+}
+
+// WantsProblem reports whether the client accepts problem details. Only clients that
+// name application/problem+json get them, so the rest keep the error envelope.
+func WantsProblem(request *http.Request) bool {
+	for _, accepted := range strings.Split(request.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil || mediaType != ProblemContentType {
+			continue
+		}
+		if quality, err := strconv.ParseFloat(params["q"], 64); err == nil && quality == 0 {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// WriteError answers with the AppError in the chain of err, as problem details when the
+// client asks for them. Errors of an ended context are answered like WriteContextError
+// does, and any other error with a 500 that does not reveal it.
+func WriteError(writer http.ResponseWriter, request *http.Request, err error) {
+	if WriteContextError(writer, request, err) {
+		return
+	}
+
+	var appError *errors.AppError
+	if !stderrors.As(err, &appError) {
+		appError = errors.ErrInterServer
+	}
+	writeAppError(writer, request, appError)
+}
+
+func writeAppError(writer http.ResponseWriter, request *http.Request, appError *errors.AppError) {
+	if WantsProblem(request) {
+		WriteProblem(writer, request, appError)
+		return
+	}
+	WriterError(writer, appError)
+}
+
 func Paginated(writer http.ResponseWriter, data interface{}, page, perPage, total int) {
 	WriteSuccess(writer, data, http.StatusOK)
 }
+
+// WriteContextError answers a request whose context ended before its work was done:
+// 499 when the client went away and 504 when the deadline passed. It reports whether
+// err was such an error.
+func WriteContextError(writer http.ResponseWriter, request *http.Request, err error) bool {
+	switch {
+	case stderrors.Is(err, context.Canceled):
+		writeAppError(writer, request, errors.NewAppError(StatusClientClosedRequest, "Client closed request"))
+	case stderrors.Is(err, context.DeadlineExceeded):
+		writeAppError(writer, request, errors.NewAppError(http.StatusGatewayTimeout, "Request timed out"))
+	default:
+		return false
+	}
+	return true
+}
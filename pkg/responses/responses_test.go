@@ -0,0 +1,162 @@
+package responses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apperrors "task_API/pkg/errors"
+	"task_API/pkg/utils"
+)
+
+func TestWriteContextError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		handled bool
+		status  int
+	}{
+		{"cancelled", context.Canceled, true, StatusClientClosedRequest},
+		{"wrapped cancellation", fmt.Errorf("failed to get tasks: %w", context.Canceled), true, StatusClientClosedRequest},
+		{"deadline", fmt.Errorf("failed to get task: %w", context.DeadlineExceeded), true, http.StatusGatewayTimeout},
+		{"other error", errors.New("task not found"), false, http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if handled := WriteContextError(recorder, request, tc.err); handled != tc.handled {
+				t.Fatalf("handled = %v, want %v", handled, tc.handled)
+			}
+			if recorder.Code != tc.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, tc.status)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{"not found", apperrors.ErrNotFound.New("task not found"), http.StatusNotFound, "task not found"},
+		{"wrapped", fmt.Errorf("failed to update: %w", apperrors.ErrForBidden.New("not your task")), http.StatusForbidden, "not your task"},
+		{"sentinel", apperrors.ErrInvalidCredentials, http.StatusUnauthorized, "invalid credentials"},
+		{"cause stays internal", apperrors.ErrDatabase(errors.New("connection refused")), http.StatusInternalServerError, "database error"},
+		{"deadline", fmt.Errorf("failed to get task: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "Request timed out"},
+		{"unknown error", errors.New("pq: relation does not exist"), http.StatusInternalServerError, "internal server error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			WriteError(recorder, httptest.NewRequest(http.MethodGet, "/tasks", nil), tc.err)
+
+			if recorder.Code != tc.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, tc.status)
+			}
+			var response Response
+			if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if response.Success || response.Error == nil || response.Error.Code != tc.status || response.Error.Message != tc.message {
+				t.Fatalf("unexpected envelope %+v", response)
+			}
+		})
+	}
+}
+
+func TestWriteErrorProblem(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/register", nil)
+	request.Header.Set("Accept", "application/json, application/problem+json")
+	request = request.WithContext(utils.WithRequestId(request.Context(), "req-1"))
+
+	recorder := httptest.NewRecorder()
+	WriteError(recorder, request, apperrors.Validation(apperrors.FieldError{Field: "email", Message: "is required"}))
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != ProblemContentType {
+		t.Fatalf("content type = %q", contentType)
+	}
+	var problem Problem
+	if err := json.NewDecoder(recorder.Body).Decode(&problem); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := Problem{
+		Type:      "/problems/validation",
+		Title:     "validation failed",
+		Status:    http.StatusBadRequest,
+		Instance:  "/register",
+		RequestId: "req-1",
+	}
+	if problem.Type != want.Type || problem.Title != want.Title || problem.Status != want.Status ||
+		problem.Detail != "" || problem.Instance != want.Instance || problem.RequestId != want.RequestId {
+		t.Fatalf("problem = %+v, want %+v", problem, want)
+	}
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "email" {
+		t.Fatalf("errors = %+v", problem.Errors)
+	}
+}
+
+func TestWriteErrorProblemTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		kind   string
+		title  string
+		detail string
+	}{
+		{"sentinel kind", apperrors.ErrNotFound.New("task not found"), "/problems/not-found", "resource not found", "task not found"},
+		{"unknown error", errors.New("boom"), "/problems/internal", "internal server error", ""},
+		{"deadline", context.DeadlineExceeded, "about:blank", "Gateway Timeout", "Request timed out"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/tasks/7", nil)
+			request.Header.Set("Accept", "application/problem+json")
+			recorder := httptest.NewRecorder()
+			WriteError(recorder, request, tc.err)
+
+			var problem Problem
+			if err := json.NewDecoder(recorder.Body).Decode(&problem); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if problem.Type != tc.kind || problem.Title != tc.title || problem.Detail != tc.detail || problem.Status != recorder.Code {
+				t.Fatalf("problem = %+v", problem)
+			}
+		})
+	}
+}
+
+func TestWantsProblem(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"application/problem+json", true},
+		{"application/json;q=0.9, application/problem+json", true},
+		{"application/problem+json;q=0", false},
+	}
+
+	for _, tc := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Accept", tc.accept)
+		if got := WantsProblem(request); got != tc.want {
+			t.Errorf("WantsProblem(%q) = %v, want %v", tc.accept, got, tc.want)
+		}
+	}
+}
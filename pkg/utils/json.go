@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	apperrors "task_API/pkg/errors"
+)
+
+// DecodeJSON decodes the JSON object in the body of request into dst. The body must be
+// sent as application/json, hold a single object and only fields dst knows. Failures
+// are AppErrors: 415 for another content type, 413 for a body over the limit set by
+// http.MaxBytesReader (see middleware.MaxBodySize) and 400 otherwise, naming the
+// offending field when there is one.
+func DecodeJSON(request *http.Request, dst interface{}) error {
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return apperrors.ErrUnsupportedMedia.New("Content-Type must be application/json")
+	}
+
+	// The body is kept to find the field of a malformed time, see timeFieldPath
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return decodeError(err, body, dst)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err, body, dst)
+	}
+
+	// Anything after the object is rejected rather than silently ignored
+	if err := decoder.Decode(&json.RawMessage{}); !errors.Is(err, io.EOF) {
+		return apperrors.ErrBadRequest.New("Request body must contain a single JSON object")
+	}
+	return nil
+}
+
+func decodeError(err error, body []byte, dst interface{}) error {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	var maxBytesError *http.MaxBytesError
+	var timeError *time.ParseError
+
+	switch {
+	case errors.As(err, &maxBytesError):
+		return apperrors.ErrPayloadTooLarge.Newf("Request body must not be larger than %d bytes", maxBytesError.Limit)
+	case errors.Is(err, io.EOF):
+		return apperrors.ErrBadRequest.New("Request body must not be empty")
+	case errors.As(err, &syntaxError):
+		return apperrors.ErrBadRequest.Newf("Request body contains malformed JSON at offset %d", syntaxError.Offset)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apperrors.ErrBadRequest.New("Request body contains malformed JSON")
+	case errors.As(err, &typeError):
+		if typeError.Field == "" {
+			return apperrors.ErrBadRequest.Newf("Request body must be a JSON object, not %s", typeError.Value)
+		}
+		return apperrors.Validation(apperrors.FieldError{
+			Field:   typeError.Field,
+			Message: fmt.Sprintf("must be %s, not %s", jsonTypeName(typeError.Type.String()), typeError.Value),
+		})
+	case errors.As(err, &timeError):
+		message := fmt.Sprintf("must be an RFC 3339 time such as 2024-06-01T17:00:00Z, not %q", timeError.Value)
+		field := timeFieldPath(body, reflect.TypeOf(dst))
+		if field == "" {
+			return apperrors.ErrBadRequest.New("Request body contains a time that " + message)
+		}
+		return apperrors.Validation(apperrors.FieldError{Field: field, Message: message})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for unknown fields
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return apperrors.Validation(apperrors.FieldError{Field: field, Message: "is not a known field"})
+	default:
+		return apperrors.ErrBadRequest.Newf("Invalid request body: %v", err)
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeFieldPath returns the path, such as owner.due_date, of the time field of data, a
+// JSON object decoded into a valueType, whose value is not a valid time. encoding/json
+// reports these errors without the field. It returns "" when none is found.
+func timeFieldPath(data []byte, valueType reflect.Type) string {
+	for valueType.Kind() == reflect.Pointer {
+		valueType = valueType.Elem()
+	}
+	if valueType.Kind() != reflect.Struct {
+		return ""
+	}
+	var object map[string]json.RawMessage
+	if json.Unmarshal(data, &object) != nil {
+		return ""
+	}
+
+	for index := 0; index < valueType.NumField(); index++ {
+		field := valueType.Field(index)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		raw, ok := jsonMember(object, name)
+		if !ok {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType == timeType {
+			var value time.Time
+			if string(raw) != "null" && json.Unmarshal(raw, &value) != nil {
+				return name
+			}
+			continue
+		}
+		if path := timeFieldPath(raw, fieldType); path != "" {
+			return name + "." + path
+		}
+	}
+	return ""
+}
+
+// jsonMember looks a member up the way encoding/json matches fields, preferring an
+// exact match over a case-insensitive one
+func jsonMember(object map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if raw, ok := object[name]; ok {
+		return raw, true
+	}
+	for key, raw := range object {
+		if strings.EqualFold(key, name) {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
+// jsonTypeName names a Go type the way a JSON client knows it
+func jsonTypeName(goType string) string {
+	goType = strings.TrimLeft(goType, "*")
+	switch {
+	case goType == "string", goType == "time.Time":
+		return "a string"
+	case goType == "bool":
+		return "a boolean"
+	case strings.HasPrefix(goType, "int"), strings.HasPrefix(goType, "uint"), strings.HasPrefix(goType, "float"):
+		return "a number"
+	case strings.HasPrefix(goType, "[]"):
+		return "an array"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "task_API/pkg/errors"
+)
+
+type decodeTarget struct {
+	Title   string     `json:"title"`
+	Count   int        `json:"count"`
+	DueDate *time.Time `json:"due_date"`
+	Owner   struct {
+		Name   string    `json:"name"`
+		Joined time.Time `json:"joined"`
+	} `json:"owner"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		field       string
+	}{
+		{"valid", "application/json", `{"title": "Report", "count": 2}`, 0, ""},
+		{"charset", "application/json; charset=utf-8", `{"title": "Report"}`, 0, ""},
+		{"missing content type", "", `{"title": "Report"}`, http.StatusUnsupportedMediaType, ""},
+		{"form content type", "application/x-www-form-urlencoded", `title=Report`, http.StatusUnsupportedMediaType, ""},
+		{"too large", "application/json", `{"title": "` + strings.Repeat("x", 100) + `"}`, http.StatusRequestEntityTooLarge, ""},
+		{"empty", "application/json", ``, http.StatusBadRequest, ""},
+		{"malformed", "application/json", `{"title": }`, http.StatusBadRequest, ""},
+		{"truncated", "application/json", `{"title": "Rep`, http.StatusBadRequest, ""},
+		{"unknown field", "application/json", `{"title": "Report", "priority": 1}`, http.StatusBadRequest, "priority"},
+		{"wrong type", "application/json", `{"count": "two"}`, http.StatusBadRequest, "count"},
+		{"nested wrong type", "application/json", `{"owner": {"name": 7}}`, http.StatusBadRequest, "owner.name"},
+		{"bad time", "application/json", `{"due_date": "tomorrow"}`, http.StatusBadRequest, "due_date"},
+		{"nested bad time", "application/json", `{"owner": {"joined": "2024-13-01"}}`, http.StatusBadRequest, "owner.joined"},
+		{"not an object", "application/json", `["Report"]`, http.StatusBadRequest, ""},
+		{"trailing data", "application/json", `{"title": "Report"} {"title": "Again"}`, http.StatusBadRequest, ""},
+		{"trailing garbage", "application/json", `{"title": "Report"}]`, http.StatusBadRequest, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				request.Header.Set("Content-Type", tc.contentType)
+			}
+			request.Body = http.MaxBytesReader(httptest.NewRecorder(), request.Body, 64)
+
+			var target decodeTarget
+			err := DecodeJSON(request, &target)
+			if tc.status == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+
+			var appError *apperrors.AppError
+			if !errors.As(err, &appError) {
+				t.Fatalf("expected an AppError, got %v", err)
+			}
+			if appError.Code != tc.status {
+				t.Fatalf("status = %d, want %d (%v)", appError.Code, tc.status, err)
+			}
+			if tc.field != "" && (len(appError.Fields) != 1 || appError.Fields[0].Field != tc.field) {
+				t.Fatalf("fields = %+v, want %s", appError.Fields, tc.field)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONNamesTheFormatOfTimes(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"due_date": "next friday"}`))
+	request.Header.Set("Content-Type", "application/json")
+
+	var appError *apperrors.AppError
+	if err := DecodeJSON(request, &decodeTarget{}); !errors.As(err, &appError) || len(appError.Fields) != 1 {
+		t.Fatalf("expected a field error, got %v", err)
+	}
+	if got := appError.Fields[0].Message; !strings.Contains(got, "RFC 3339") || !strings.Contains(got, `"next friday"`) {
+		t.Fatalf("message = %q, want the expected format and the value", got)
+	}
+}
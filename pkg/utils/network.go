@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ParseNetworks parses addresses and CIDR ranges, such as 10.0.0.0/8 or ::1. An address
+// is a range of its own.
+func ParseNetworks(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q: %w", entry, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// InNetworks reports whether addr is in one of the ranges
+func InNetworks(addr netip.Addr, prefixes []netip.Prefix) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInternalIP reports whether addr is a loopback, private, link-local, unspecified or
+// multicast address, which are not reachable from the internet. Link-local covers the
+// metadata endpoint of cloud providers, 169.254.169.254.
+func IsInternalIP(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsUnspecified() || addr.IsMulticast()
+}
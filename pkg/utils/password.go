@@ -1,11 +1,20 @@
 package utils
 
 import (
+	apperrors "task_API/pkg/errors"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword takes a plain text password and returns the bcrypt hashed password
+// MaxPasswordBytes is the longest password bcrypt hashes
+const MaxPasswordBytes = 72
+
+// HashPassword takes a plain text password and returns the bcrypt hashed password.
+// Passwords longer than MaxPasswordBytes are a bad request.
 func HashPassword(password string) (string, error) {
+	if len(password) > MaxPasswordBytes {
+		return "", apperrors.ErrBadRequest.Newf("password must be at most %d bytes long", MaxPasswordBytes)
+	}
 	hashed, error := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if error != nil {
 		return "", error
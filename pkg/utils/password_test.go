@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	apperrors "task_API/pkg/errors"
+)
+
+func TestHashPasswordRefusesPasswordsOverBcryptsLimit(t *testing.T) {
+	// 19 characters, but 76 bytes
+	if _, err := HashPassword(strings.Repeat("😀", 19)); !errors.Is(err, apperrors.ErrBadRequest) {
+		t.Fatalf("HashPassword() error = %v, want a bad request", err)
+	}
+
+	hashed, err := HashPassword(strings.Repeat("😀", 18))
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if !CheckHashedPassword(hashed, strings.Repeat("😀", 18)) {
+		t.Fatal("the hash does not match its password")
+	}
+}
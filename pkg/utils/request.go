@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// RequestIdHeader carries the id of a request in both directions
+const RequestIdHeader = "X-Request-ID"
+
+type requestIdKey struct{}
+
+// ClientIP returns the address of the client that sent the request
+func ClientIP(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
+
+// WithRequestId returns a copy of ctx that carries the id of the request
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+// RequestId returns the id of the request ctx belongs to, empty when it has none
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// IsHTTPS reports whether the client reached the API over HTTPS, directly or through
+// a trusted proxy that said so in X-Forwarded-Proto
+func IsHTTPS(request *http.Request) bool {
+	return request.TLS != nil || request.URL.Scheme == "https"
+}
+
+type serviceAccountKey struct{}
+
+// WithServiceAccount returns a copy of ctx naming the service account a client
+// certificate signed in as
+func WithServiceAccount(ctx context.Context, email string) context.Context {
+	return context.WithValue(ctx, serviceAccountKey{}, email)
+}
+
+// ServiceAccount returns the email of the service account of the request, empty for
+// clients without a mapped certificate
+func ServiceAccount(ctx context.Context) string {
+	email, _ := ctx.Value(serviceAccountKey{}).(string)
+	return email
+}
+
+type apiVersionKey struct{}
+
+// WithAPIVersion returns a copy of ctx naming the API version the request was routed to
+func WithAPIVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, apiVersionKey{}, version)
+}
+
+// APIVersion returns the API version of the request, empty when its route has none
+func APIVersion(ctx context.Context) string {
+	version, _ := ctx.Value(apiVersionKey{}).(string)
+	return version
+}
@@ -0,0 +1,230 @@
+// Package validator checks request models against the rules in their `validate` struct
+// tags and reports every invalid field at once.
+//
+// Rules are separated by commas and apply to the field they tag:
+//
+//	required          the field is set: non-blank strings, non-nil pointers, non-empty slices
+//	omitempty         skip the remaining rules when the field is not set
+//	email             a bare email address
+//	url               an absolute http or https url
+//	min=N, max=N      the length of strings (in characters) and slices, the value of numbers
+//	maxbytes=N        the length of strings in bytes, characters outside ASCII take up to 4
+//	oneof=a b c       one of the listed values
+//	gtfield=Field     a time after the time in Field, when both are set
+//	requires=Field    Field must be set when this field is
+//	dive              the remaining rules apply to every element of a slice
+//
+// Fields are named in errors by their json name.
+package validator
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	apperrors "task_API/pkg/errors"
+)
+
+// Struct validates the fields of value, a struct or a pointer to one. It returns nil
+// when every rule holds and otherwise an apperrors.Validation error listing the
+// invalid fields, at most one failure per field.
+func Struct(value interface{}) error {
+	structValue := reflect.Indirect(reflect.ValueOf(value))
+	if structValue.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validator: %T is not a struct", value))
+	}
+
+	var fields []apperrors.FieldError
+	structType := structValue.Type()
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		if failure := check(structValue, structValue.Field(index), fieldName(field), splitRules(tag)); failure != nil {
+			fields = append(fields, *failure)
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return apperrors.Validation(fields...)
+}
+
+func splitRules(tag string) []string {
+	var rules []string
+	for _, rule := range strings.Split(tag, ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// check applies rules to value in order and returns the first failure
+func check(parent reflect.Value, value reflect.Value, name string, rules []string) *apperrors.FieldError {
+	for index, rule := range rules {
+		ruleName, param, _ := strings.Cut(rule, "=")
+
+		switch ruleName {
+		case "omitempty":
+			if !isSet(value) {
+				return nil
+			}
+			continue
+		case "dive":
+			elements := reflect.Indirect(value)
+			if elements.Kind() != reflect.Slice && elements.Kind() != reflect.Array {
+				panic(fmt.Sprintf("validator: dive on %s, which is not a slice", name))
+			}
+			for element := 0; element < elements.Len(); element++ {
+				elementName := name + "[" + strconv.Itoa(element) + "]"
+				if failure := check(parent, elements.Index(element), elementName, rules[index+1:]); failure != nil {
+					return failure
+				}
+			}
+			return nil
+		}
+
+		if message := apply(parent, value, ruleName, param); message != "" {
+			return &apperrors.FieldError{Field: name, Message: message}
+		}
+	}
+	return nil
+}
+
+// apply returns why value breaks the rule, or an empty string when it holds. Rules
+// other than required and requires hold for values that are not set.
+func apply(parent reflect.Value, value reflect.Value, rule string, param string) string {
+	switch rule {
+	case "required":
+		if !isSet(value) {
+			return "is required"
+		}
+		return ""
+	case "requires":
+		other, name := siblingField(parent, param)
+		if isSet(value) && !isSet(other) {
+			return "requires " + name
+		}
+		return ""
+	}
+
+	if !isSet(value) {
+		return ""
+	}
+	value = reflect.Indirect(value)
+
+	switch rule {
+	case "email":
+		address, err := mail.ParseAddress(value.String())
+		if err != nil || address.Address != value.String() {
+			return "must be a valid email address"
+		}
+	case "url":
+		target, err := url.Parse(value.String())
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return "must be an absolute http or https url"
+		}
+	case "min", "max":
+		return checkBound(value, rule, param)
+	case "maxbytes":
+		bound, err := strconv.Atoi(param)
+		if err != nil || value.Kind() != reflect.String {
+			panic(fmt.Sprintf("validator: maxbytes=%s on %s", param, value.Kind()))
+		}
+		if len(value.String()) > bound {
+			return fmt.Sprintf("must be at most %d bytes long", bound)
+		}
+	case "oneof":
+		allowed := strings.Fields(param)
+		for _, option := range allowed {
+			if fmt.Sprint(value.Interface()) == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(allowed, ", ")
+	case "gtfield":
+		other, name := siblingField(parent, param)
+		if !isSet(other) {
+			return ""
+		}
+		after, ok := value.Interface().(time.Time)
+		before, otherOk := reflect.Indirect(other).Interface().(time.Time)
+		if !ok || !otherOk {
+			panic(fmt.Sprintf("validator: gtfield=%s compares fields that are not times", param))
+		}
+		if !after.After(before) {
+			return "must be after " + name
+		}
+	default:
+		panic(fmt.Sprintf("validator: unknown rule %q", rule))
+	}
+	return ""
+}
+
+func checkBound(value reflect.Value, rule string, param string) string {
+	bound, err := strconv.Atoi(param)
+	if err != nil {
+		panic(fmt.Sprintf("validator: %s=%s is not a number", rule, param))
+	}
+
+	var actual int
+	var unit string
+	switch value.Kind() {
+	case reflect.String:
+		actual, unit = utf8.RuneCountInString(value.String()), " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		actual, unit = value.Len(), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = int(value.Int())
+	default:
+		panic(fmt.Sprintf("validator: %s on unsupported kind %s", rule, value.Kind()))
+	}
+
+	if rule == "min" && actual < bound {
+		return fmt.Sprintf("must be at least %d%s", bound, unit)
+	}
+	if rule == "max" && actual > bound {
+		return fmt.Sprintf("must be at most %d%s", bound, unit)
+	}
+	return ""
+}
+
+// isSet reports whether a value counts as given. Strings of spaces do not.
+func isSet(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return !value.IsNil()
+	case reflect.Slice, reflect.Map:
+		return value.Len() > 0
+	case reflect.String:
+		return strings.TrimSpace(value.String()) != ""
+	default:
+		return !value.IsZero()
+	}
+}
+
+func siblingField(parent reflect.Value, name string) (reflect.Value, string) {
+	field, ok := parent.Type().FieldByName(name)
+	if !ok {
+		panic(fmt.Sprintf("validator: %s has no field %s", parent.Type(), name))
+	}
+	return parent.FieldByIndex(field.Index), fieldName(field)
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
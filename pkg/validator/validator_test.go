@@ -0,0 +1,151 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "task_API/pkg/errors"
+)
+
+type signup struct {
+	Email    string   `json:"email" validate:"required,email,max=32"`
+	Name     string   `json:"name" validate:"required,max=5"`
+	Password string   `json:"password,omitempty" validate:"required,min=8"`
+	Website  string   `json:"website" validate:"omitempty,url"`
+	Role     string   `json:"role" validate:"omitempty,oneof=user admin"`
+	Tags     []string `json:"tags" validate:"max=2,dive,min=2"`
+	Untagged string   `json:"untagged"`
+}
+
+type schedule struct {
+	Start   *time.Time `json:"start"`
+	End     *time.Time `json:"end" validate:"omitempty,gtfield=Start"`
+	Offsets []int      `json:"offsets" validate:"requires=Start,dive,min=0"`
+}
+
+func fieldErrors(t *testing.T, err error) map[string]string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+
+	var appError *apperrors.AppError
+	if !errors.As(err, &appError) || !errors.Is(err, apperrors.ErrValidation) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	fields := map[string]string{}
+	for _, field := range appError.Fields {
+		if _, ok := fields[field.Field]; ok {
+			t.Fatalf("field %s reported twice", field.Field)
+		}
+		fields[field.Field] = field.Message
+	}
+	return fields
+}
+
+func TestStructAcceptsAValidValue(t *testing.T) {
+	value := signup{Email: "jo@example.com", Name: "Jo", Password: "long enough", Website: "https://jo.dev", Role: "admin", Tags: []string{"go"}}
+	if err := Struct(&value); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestStructReportsEveryInvalidField(t *testing.T) {
+	value := signup{
+		Email:    "Jo <jo@example.com>",
+		Name:     "Jonathan",
+		Password: "   ",
+		Website:  "ftp://jo.dev",
+		Role:     "owner",
+		Tags:     []string{"go", "x"},
+	}
+
+	got := fieldErrors(t, Struct(value))
+	want := map[string]string{
+		"email":    "must be a valid email address",
+		"name":     "must be at most 5 characters long",
+		"password": "is required",
+		"website":  "must be an absolute http or https url",
+		"role":     "must be one of: user, admin",
+		"tags[1]":  "must be at least 2 characters long",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for field, message := range want {
+		if got[field] != message {
+			t.Errorf("%s: got %q, want %q", field, got[field], message)
+		}
+	}
+}
+
+func TestStructCountsCharactersAndItems(t *testing.T) {
+	got := fieldErrors(t, Struct(signup{Email: "jo@example.com", Name: "Jöürñ", Password: "pässwörd", Tags: []string{"a1", "b2", "c3"}}))
+	if len(got) != 1 || got["tags"] != "must be at most 2 items" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestStructCountsBytes(t *testing.T) {
+	type password struct {
+		Password string `json:"password" validate:"required,min=8,maxbytes=72"`
+	}
+
+	// 19 characters of 4 bytes each, well under 72 characters but over 72 bytes
+	long := strings.Repeat("😀", 19)
+	got := fieldErrors(t, Struct(password{Password: long}))
+	if got["password"] != "must be at most 72 bytes long" {
+		t.Fatalf("got %v", got)
+	}
+	if err := Struct(password{Password: strings.Repeat("😀", 18)}); err != nil {
+		t.Fatalf("72 bytes: unexpected error %v", err)
+	}
+}
+
+func TestStructComparesDatesAndDependentFields(t *testing.T) {
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+	after := start.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		value schedule
+		want  map[string]string
+	}{
+		{"ordered", schedule{Start: &start, End: &after, Offsets: []int{0, 60}}, nil},
+		{"end without start", schedule{End: &before}, nil},
+		{"end before start", schedule{Start: &start, End: &before}, map[string]string{"end": "must be after start"}},
+		{"end equal to start", schedule{Start: &start, End: &start}, map[string]string{"end": "must be after start"}},
+		{"offsets without start", schedule{Offsets: []int{10}}, map[string]string{"offsets": "requires start"}},
+		{"negative offset", schedule{Start: &start, Offsets: []int{10, -5}}, map[string]string{"offsets[1]": "must be at least 0"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fieldErrors(t, Struct(tc.value))
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			for field, message := range tc.want {
+				if got[field] != message {
+					t.Errorf("%s: got %q, want %q", field, got[field], message)
+				}
+			}
+		})
+	}
+}
+
+func TestStructPanicsOnAMalformedTag(t *testing.T) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil || !strings.Contains(recovered.(string), "unknown rule") {
+			t.Fatalf("expected a panic on an unknown rule, got %v", recovered)
+		}
+	}()
+
+	Struct(struct {
+		Name string `validate:"required,lowercase"`
+	}{Name: "x"})
+}